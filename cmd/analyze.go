@@ -2,9 +2,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -26,18 +29,60 @@ var (
 	excludeFlag     []string
 	failOnFlag      string
 	configFlag      string
+	sourceFlag      string
+	varFileFlag     []string
+	varFlag         []string
+	sourceRootFlag  string
+	changedOnlyFlag bool
+	regressionsFlag bool
+	failOnRegFlag   bool
+	manifestFlag    string
+	terragruntFlag  bool
+	parallelismFlag int
+	failOnErrFlag   bool
+	timeoutFlag     time.Duration
+	ruleTimeoutFlag time.Duration
+	profileFlag     bool
+	profileJSONFlag string
+	cpuProfileFlag  string
+	rulesDirFlag    []string
+	pluginsDirFlag  []string
 )
 
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze <plan.json>",
-	Short: "Analyze a Terraform plan against AWS Well-Architected Framework",
-	Long: `Parse a Terraform plan JSON file and evaluate it against AWS Well-Architected best practices.
+	Use:   "analyze <plan.json | state | directory | ->...",
+	Short: "Analyze a Terraform plan, state or source directory against AWS Well-Architected Framework",
+	Long: `Parse a Terraform plan JSON file, a state file or a directory of .tf files and evaluate
+it against AWS Well-Architected best practices.
 
 Generate the plan JSON with:
   terraform plan -out=plan.bin
   terraform show -json plan.bin > plan.json
-  wat analyze plan.json`,
-	Args: cobra.ExactArgs(1),
+  wat analyze plan.json
+
+Or pipe it in, without a temporary file:
+  terraform show -json plan.bin | wat analyze -
+
+The binary plan can also be analyzed directly, without the terraform binary:
+  wat analyze plan.bin
+
+Or analyze Terraform source (.tf and .tf.json) directly, without credentials or a plan:
+  wat analyze ./infra
+
+Or analyze what is deployed, from a state file or its JSON form:
+  wat analyze terraform.tfstate
+  terraform show -json > state.json && wat analyze state.json
+
+Several stacks can be analyzed in one run, concurrently, with a combined report.
+Pass several paths or a glob (** matches any number of directories), or a
+manifest listing each stack's name and path:
+  wat analyze 'plans/**/*.json'
+  wat analyze --manifest stacks.yaml
+
+Or analyze every Terragrunt unit of an environment, each unit's module parsed
+with the unit's inputs:
+  wat analyze --terragrunt live/prod`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runAnalyze,
 }
 
@@ -48,30 +93,60 @@ func init() {
 	analyzeCmd.Flags().StringVar(&minSeverityFlag, "min-severity", "", "Minimum severity: CRITICAL, HIGH, MEDIUM, LOW, INFO")
 	analyzeCmd.Flags().StringSliceVar(&excludeFlag, "exclude", nil, "Rule IDs to exclude (e.g., S3-005,EC2-006)")
 	analyzeCmd.Flags().StringVar(&failOnFlag, "fail-on", "any", "Exit code 1 threshold: CRITICAL, HIGH, MEDIUM, LOW, any, none")
-	analyzeCmd.Flags().StringVar(&configFlag, "config", ".wat.yaml", "Path to suppression config file")
+	analyzeCmd.Flags().StringVar(&configFlag, "config", ".wat.yaml", "Path to config file (suppressions and custom rules)")
+	analyzeCmd.Flags().StringArrayVar(&rulesDirFlag, "rules-dir", nil, "Directory of custom rule YAML files, in addition to rules_dir in the config file (repeatable)")
+	analyzeCmd.Flags().StringArrayVar(&pluginsDirFlag, "plugins-dir", nil, "Directory of rule plugin executables, in addition to plugins_dir in the config file (repeatable)")
+	analyzeCmd.Flags().StringVar(&sourceFlag, "source", "auto", "Input type: auto, plan, state, hcl, terragrunt (auto treats directories, .tf and .tf.json files as HCL and detects plan or state JSON)")
+	analyzeCmd.Flags().StringArrayVar(&varFileFlag, "var-file", nil, "Variable definitions file for HCL source (repeatable)")
+	analyzeCmd.Flags().StringVar(&sourceRootFlag, "source-root", "", "Terraform source directory a plan was created from, used to report file and line locations (default: the plan file's directory, or the working directory for stdin, if it contains .tf files)")
+	analyzeCmd.Flags().StringArrayVar(&varFlag, "var", nil, "Variable assignment name=value for HCL source (repeatable)")
+	analyzeCmd.Flags().BoolVar(&changedOnlyFlag, "changed-only", false, "Report findings only for resources the plan creates, updates or replaces")
+	analyzeCmd.Flags().BoolVar(&regressionsFlag, "regressions", false, "Compare each updated or replaced resource with its prior state and mark findings the plan introduces as regressions")
+	analyzeCmd.Flags().BoolVar(&failOnRegFlag, "fail-on-regression", false, "Exit code 1 if the plan introduces any regression (implies --regressions)")
+
+	analyzeCmd.Flags().StringVar(&manifestFlag, "manifest", "", "YAML file listing the stacks to analyze (stacks: [{name, path, source_root}])")
+
+	analyzeCmd.Flags().BoolVar(&terragruntFlag, "terragrunt", false, "Analyze every Terragrunt unit under the given directories, parsing each unit's module with its inputs")
+
+	analyzeCmd.Flags().BoolVar(&failOnErrFlag, "fail-on-engine-error", true, "Exit code 1 if a rule panics or fails to evaluate a resource")
+	analyzeCmd.Flags().DurationVar(&timeoutFlag, "timeout", 0, "Stop the whole run after this long, e.g. 10m (0 means no limit)")
+	analyzeCmd.Flags().DurationVar(&ruleTimeoutFlag, "rule-timeout", time.Minute, "Time budget of one rule evaluation; evaluations over budget are reported as timed out engine errors (0 means no budget)")
+	analyzeCmd.Flags().BoolVar(&profileFlag, "profile", false, "Print the time spent parsing, reporting and in each rule to stderr")
+	analyzeCmd.Flags().StringVar(&profileJSONFlag, "profile-json", "", "Write the parse, report and per-rule timings as JSON to this file")
+	analyzeCmd.Flags().StringVar(&cpuProfileFlag, "cpuprofile", "", "Write a Go pprof CPU profile to this file, with each rule evaluation labelled by rule ID")
+	analyzeCmd.Flags().IntVar(&parallelismFlag, "parallelism", 0, "Maximum number of rule evaluations and stacks processed at once (0 uses GOMAXPROCS)")
 
 	rootCmd.AddCommand(analyzeCmd)
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	planPath := args[0]
-
-	info, err := os.Stat(planPath)
-	if err != nil {
-		return fmt.Errorf("cannot access plan file %q: %w", planPath, err)
+	if parallelismFlag < 0 {
+		return fmt.Errorf("--parallelism must not be negative")
+	}
+	start := time.Now()
+	stopCPUProfile := func() {}
+	if cpuProfileFlag != "" {
+		stop, err := startCPUProfile(cpuProfileFlag)
+		if err != nil {
+			return err
+		}
+		stopCPUProfile = stop
+		defer stop()
 	}
-	if info.IsDir() {
-		return fmt.Errorf("%q is a directory — wat requires a Terraform plan JSON file\n\nGenerate one with:\n  terraform plan -out=plan.bin\n  terraform show -json plan.bin > plan.json", planPath)
+	ctx := cmd.Context()
+	if timeoutFlag > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutFlag)
+		defer cancel()
 	}
-
-	resources, err := parser.ParsePlanFile(planPath)
+	stacks, err := resolveStacks(args)
 	if err != nil {
-		return fmt.Errorf("parsing plan file: %w", err)
+		return err
 	}
-
-	if len(resources) == 0 {
-		fmt.Fprintln(os.Stderr, "No resources found in plan file", planPath)
-		return nil
+	if len(stacks) == 1 && stacks[0].SourceRoot == "" {
+		stacks[0].SourceRoot = sourceRootFlag
+	} else if sourceRootFlag != "" {
+		return fmt.Errorf("--source-root applies to a single plan; set source_root for each stack in a --manifest instead")
 	}
 
 	// Load suppression config
@@ -79,19 +154,71 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	extraRules, closePlugins, err := loadExtraRules(ctx, cfg, rulesDirFlag, pluginsDirFlag)
+	if err != nil {
+		return err
+	}
+	defer closePlugins()
 
 	// Build engine config
 	engConfig := engine.Config{
 		MinSeverity: model.Severity(strings.ToUpper(minSeverityFlag)),
 		ExcludeIDs:  excludeFlag,
+		ChangedOnly: changedOnlyFlag,
+		Regressions: regressionsFlag || failOnRegFlag,
+		Parallelism: parallelismFlag,
+		RuleTimeout: ruleTimeoutFlag,
+		Profile:     profileFlag || profileJSONFlag != "",
+		ExtraRules:  extraRules,
+		RuleParams:  cfg.RuleParams(),
+		Overrides:   cfg.RuleOverrides(),
 	}
 	for _, p := range pillarFlag {
 		engConfig.Pillars = append(engConfig.Pillars, model.Pillar(p))
 	}
 
-	// Run analysis
+	// Run analysis as resources are parsed
 	eng := engine.New(engConfig)
-	findings := eng.Analyze(resources)
+	multi := len(stacks) > 1
+	requested := stackPaths(stacks)
+	stacks, results := skipUndownloaded(stacks, analyzeStacks(ctx, eng, stacks))
+	closePlugins()
+	if err := ctx.Err(); err != nil {
+		return runStopped(err)
+	}
+
+	var findings []model.Finding
+	var engineErrors []model.EngineError
+	var parseTime time.Duration
+	totalResources := 0
+	for i, res := range results {
+		if res.err != nil {
+			if multi {
+				return fmt.Errorf("stack %s: %w", stacks[i].Name, res.err)
+			}
+			return res.err
+		}
+		if res.resources == 0 && multi {
+			fmt.Fprintln(os.Stderr, "No resources found in", stacks[i].Path)
+		}
+		if multi {
+			for j := range res.findings {
+				res.findings[j].Stack = stacks[i].Name
+			}
+			for j := range res.engineErrors {
+				res.engineErrors[j].Stack = stacks[i].Name
+			}
+		}
+		totalResources += res.resources
+		parseTime += res.parseTime
+		findings = append(findings, res.findings...)
+		engineErrors = append(engineErrors, res.engineErrors...)
+	}
+
+	if totalResources == 0 {
+		fmt.Fprintln(os.Stderr, "No resources found in", strings.Join(requested, ", "))
+		return nil
+	}
 
 	// Apply suppressions
 	suppResult := config.Apply(findings, cfg.Suppressions, time.Now())
@@ -101,21 +228,35 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "WARN: suppression for %s/%s expired on %s\n", s.RuleID, s.Resource, s.Expires)
 	}
 
+	// Report rules that panicked or failed on stderr, as the report may not
+	// be read by a person
+	for _, e := range engineErrors {
+		target := e.Resource
+		if target == "" {
+			target = "all resources"
+		}
+		if e.Stack != "" {
+			target = e.Stack + ": " + target
+		}
+		fmt.Fprintf(os.Stderr, "ERROR: rule %s failed on %s: %s\n", e.RuleID, target, e.Message)
+	}
+
 	// Build report summary from kept findings
-	summary := report.BuildSummary(resources, suppResult.Kept)
+	summary := report.NewSummary(totalResources, suppResult.Kept)
 	summary.SuppressedFindings = len(suppResult.Suppressed)
+	summary.EngineErrors = engineErrors
 	for _, s := range suppResult.ExpiredSuppressions {
 		summary.ExpiredSuppressions = append(summary.ExpiredSuppressions, fmt.Sprintf("%s/%s (expired %s)", s.RuleID, s.Resource, s.Expires))
 	}
+	if multi {
+		summary.Stacks = stackSummaries(stacks, results, suppResult)
+	}
 
 	// Collect rule metadata for SARIF output
 	var ruleMeta []model.RuleMetadata
 	for _, r := range eng.Rules() {
 		ruleMeta = append(ruleMeta, r.Metadata())
 	}
-	for _, r := range eng.CrossRules() {
-		ruleMeta = append(ruleMeta, r.Metadata())
-	}
 	summary.RuleMetadata = ruleMeta
 
 	reporter := report.NewReporter(report.Format(formatFlag))
@@ -130,20 +271,233 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		w = f
 	}
 
-	if err := reporter.Generate(w, summary); err != nil {
+	reportStart := time.Now()
+	if err := reporter.Generate(ctx, w, summary); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return runStopped(ctxErr)
+		}
 		return fmt.Errorf("generating report: %w", err)
 	}
 
-	// Exit with code 1 based on --fail-on threshold (only against kept findings)
+	if engConfig.Profile {
+		err := writeProfile(report.Profile{
+			Total:  time.Since(start),
+			Parse:  parseTime,
+			Report: time.Since(reportStart),
+			Rules:  eng.Profile(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// The exit code is set with os.Exit, which skips deferred calls.
+	stopCPUProfile()
+
+	// Exit with code 1 based on --fail-on threshold (only against kept findings).
+	// Findings of all stacks are pooled, so the exit code is that of the worst stack.
 	if shouldFail(suppResult.Kept, failOnFlag) {
 		os.Exit(1)
 	}
 
+	// --fail-on-regression applies independently of the --fail-on threshold.
+	if failOnRegFlag && summary.Regressions > 0 {
+		os.Exit(1)
+	}
+
+	// A rule that did not complete may have missed findings.
+	if failOnErrFlag && len(engineErrors) > 0 {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+// loadResources parses a stack, a plan, a state file or Terraform source
+// depending on --source and the shape of its path, and passes each resource
+// to fn. Plans are streamed, so large plans are never held in memory.
+func loadResources(ctx context.Context, st config.Stack, fn func(model.TerraformResource) error) error {
+	if st.Path == "-" {
+		return loadStdin(ctx, st, fn)
+	}
+	return loadFile(ctx, st, filepath.Dir(st.Path), fn)
+}
+
+// loadFile is loadResources for a path on disk. A plan without a source root
+// is located in defaultRoot if it contains .tf files; as that is only a
+// guess, failing to read it is a warning rather than an error.
+func loadFile(ctx context.Context, st config.Stack, defaultRoot string, fn func(model.TerraformResource) error) error {
+	path := st.Path
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access %q: %w", path, err)
+	}
+
+	source := strings.ToLower(sourceFlag)
+	if terragruntFlag {
+		source = "terragrunt"
+	}
+	if source == "auto" || source == "" {
+		switch {
+		case info.IsDir() || strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tf.json"):
+			source = "hcl"
+		case filepath.Ext(path) == ".tfstate":
+			source = "state"
+		default:
+			format, err := parser.DetectFormat(path)
+			if err != nil {
+				return err
+			}
+			source = string(format)
+		}
+	}
+
+	var resources []model.TerraformResource
+	switch source {
+	case "hcl":
+		p, err := newSourceParser()
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			resources, err = p.ParseDirectory(path)
+		} else {
+			resources, err = p.ParseFile(path)
+		}
+		if err != nil {
+			return fmt.Errorf("parsing Terraform source: %w", err)
+		}
+	case "terragrunt":
+		p, err := newSourceParser()
+		if err != nil {
+			return err
+		}
+		resources, err = p.ParseTerragruntUnit(path)
+		if err != nil {
+			return fmt.Errorf("parsing Terragrunt unit: %w", err)
+		}
+	case "plan":
+		if info.IsDir() {
+			return fmt.Errorf("%q is a directory — use --source hcl to analyze Terraform source, or pass a plan JSON file\n\nGenerate one with:\n  terraform plan -out=plan.bin\n  terraform show -json plan.bin > plan.json", path)
+		}
+		opts := parser.PlanOptions{SourceRoot: st.SourceRoot}
+		if opts.SourceRoot == "" && parser.HasConfigFiles(defaultRoot) {
+			opts.SourceRoot = defaultRoot
+			opts.LocateFailed = func(err error) {
+				fmt.Fprintf(os.Stderr, "WARN: %v; reporting resources without file locations\n", err)
+			}
+		}
+		if err := parser.StreamPlanFile(ctx, path, opts, fn); err != nil {
+			return fmt.Errorf("parsing plan file: %w", err)
+		}
+		return nil
+	case "state":
+		if info.IsDir() {
+			return fmt.Errorf("%q is a directory — pass a terraform.tfstate file or the output of `terraform show -json`", path)
+		}
+		resources, err = parser.ParseStateFile(path)
+		if err != nil {
+			return fmt.Errorf("parsing state file: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown --source %q (expected auto, plan, state, hcl or terragrunt)", sourceFlag)
+	}
+
+	for _, res := range resources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// loadStdin analyzes plan or state JSON read from standard input. The input is
+// copied to a temporary file, since plans are read in two passes. The working
+// directory is the default source root, as `terraform show` runs there.
+func loadStdin(ctx context.Context, st config.Stack, fn func(model.TerraformResource) error) error {
+	if strings.EqualFold(sourceFlag, "hcl") {
+		return fmt.Errorf("cannot read Terraform source from stdin; pass a directory or .tf file")
+	}
+	if terragruntFlag || strings.EqualFold(sourceFlag, "terragrunt") {
+		return fmt.Errorf("cannot read Terragrunt units from stdin; pass a directory")
+	}
+
+	dir, err := os.MkdirTemp("", "wat-stdin-")
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	f, err := os.Create(filepath.Join(dir, "input.json")) // #nosec G304 -- path is inside a directory created above
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	// Reading stdin cannot be interrupted, so the copy is abandoned rather
+	// than awaited when ctx is done.
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(f, os.Stdin)
+		copied <- err
+	}()
+	select {
+	case err = <-copied:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+
+	st.Path = f.Name()
+	return loadFile(ctx, st, ".", fn)
+}
+
+// runStopped explains why the run stopped before it finished.
+func runStopped(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("analysis did not finish within --timeout %s", timeoutFlag)
+	}
+	return fmt.Errorf("analysis interrupted: %w", err)
+}
+
+// newSourceParser returns an HCL parser with the --var-file and --var values.
+func newSourceParser() (*parser.Parser, error) {
+	vars, err := parseVarFlags(varFlag)
+	if err != nil {
+		return nil, err
+	}
+	return parser.NewWithOptions(parser.Options{VarFiles: varFileFlag, Vars: vars}), nil
+}
+
+// parseVarFlags converts repeated --var name=value flags into a map.
+func parseVarFlags(flags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(flags))
+	for _, f := range flags {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected name=value)", f)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
 // shouldFail returns true if any finding meets or exceeds the fail-on severity threshold.
+// Undetermined findings are ignored.
 func shouldFail(findings []model.Finding, failOn string) bool {
+	var determined []model.Finding
+	for _, f := range findings {
+		if !f.Undetermined {
+			determined = append(determined, f)
+		}
+	}
+	findings = determined
+
 	switch strings.ToUpper(failOn) {
 	case "NONE":
 		return false
@@ -163,3 +517,49 @@ func shouldFail(findings []model.Finding, failOn string) bool {
 		return false
 	}
 }
+
+// stackSummaries builds the per-stack summaries of a multi-stack run.
+func stackSummaries(stacks []config.Stack, results []stackResult, supp config.SuppressionResult) []report.StackSummary {
+	kept := make(map[string][]model.Finding)
+	for _, f := range supp.Kept {
+		kept[f.Stack] = append(kept[f.Stack], f)
+	}
+	suppressed := make(map[string]int)
+	for _, f := range supp.Suppressed {
+		suppressed[f.Stack]++
+	}
+
+	summaries := make([]report.StackSummary, 0, len(stacks))
+	for i, st := range stacks {
+		ss := report.NewStackSummary(st.Name, results[i].resources, kept[st.Name])
+		ss.SuppressedFindings = suppressed[st.Name]
+		summaries = append(summaries, ss)
+	}
+	return summaries
+}
+
+// skipUndownloaded drops, with a warning, the Terragrunt units whose module
+// source has not been downloaded, so one un-initialized unit does not abort
+// the analysis of the others.
+func skipUndownloaded(stacks []config.Stack, results []stackResult) ([]config.Stack, []stackResult) {
+	keptStacks := stacks[:0:0]
+	keptResults := results[:0:0]
+	for i, res := range results {
+		if errors.Is(res.err, parser.ErrSourceNotDownloaded) {
+			fmt.Fprintf(os.Stderr, "WARN: skipping %s: %v\n", stacks[i].Name, res.err)
+			continue
+		}
+		keptStacks = append(keptStacks, stacks[i])
+		keptResults = append(keptResults, res)
+	}
+	return keptStacks, keptResults
+}
+
+// stackPaths returns the paths of the stacks.
+func stackPaths(stacks []config.Stack) []string {
+	paths := make([]string, 0, len(stacks))
+	for _, st := range stacks {
+		paths = append(paths, st.Path)
+	}
+	return paths
+}
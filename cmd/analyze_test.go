@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ilijad1/well-architected-terraform/internal/config"
+)
+
+func TestLoadStdin_RejectsSourceInputs(t *testing.T) {
+	defer func(source string, terragrunt bool) { sourceFlag, terragruntFlag = source, terragrunt }(sourceFlag, terragruntFlag)
+
+	tests := []struct {
+		source     string
+		terragrunt bool
+		want       string
+	}{
+		{"hcl", false, "cannot read Terraform source from stdin"},
+		{"terragrunt", false, "cannot read Terragrunt units from stdin"},
+		{"auto", true, "cannot read Terragrunt units from stdin"},
+	}
+	for _, tt := range tests {
+		sourceFlag, terragruntFlag = tt.source, tt.terragrunt
+		err := loadStdin(context.Background(), config.Stack{Path: "-"}, nil)
+		assert.ErrorContains(t, err, tt.want, "--source %s --terragrunt=%v", tt.source, tt.terragrunt)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilijad1/well-architected-terraform/internal/config"
+	"github.com/ilijad1/well-architected-terraform/internal/custom"
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+	"github.com/ilijad1/well-architected-terraform/internal/plugin"
+)
+
+// loadExtraRules returns the rules run alongside the built-in ones: the
+// declarative rules defined in cfg, in its rules directory and in rulesDirs,
+// and the rules of the plugins in its plugins directory and in pluginDirs. It
+// also checks the rule parameters and overrides cfg configures against every
+// rule. The returned function stops the plugins; it must be called once they
+// are no longer needed.
+func loadExtraRules(ctx context.Context, cfg *config.Config, rulesDirs, pluginDirs []string) ([]model.EvalRule, func(), error) {
+	rules, err := loadCustomRules(cfg, rulesDirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	plugins, err := startPlugins(ctx, cfg, pluginDirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	closePlugins := func() {
+		for _, p := range plugins {
+			p.Close()
+		}
+	}
+	for _, p := range plugins {
+		rules = append(rules, p.Rules()...)
+	}
+
+	builtins := engine.AllRules()
+	builtin := make(map[string]bool)
+	for _, r := range builtins {
+		builtin[r.Metadata().ID] = true
+	}
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		id := r.Metadata().ID
+		switch {
+		case builtin[id]:
+			closePlugins()
+			return nil, nil, fmt.Errorf("rule %s: the ID is used by a built-in rule", id)
+		case seen[id]:
+			closePlugins()
+			return nil, nil, fmt.Errorf("rule %s: the ID is used by more than one custom or plugin rule", id)
+		}
+		seen[id] = true
+	}
+
+	all := append(append([]model.EvalRule(nil), builtins...), rules...)
+	if err := engine.ValidateParams(all, cfg.RuleParams()); err != nil {
+		closePlugins()
+		return nil, nil, fmt.Errorf("config parameters: %w", err)
+	}
+	if err := engine.ValidateOverrides(all, cfg.RuleOverrides()); err != nil {
+		closePlugins()
+		return nil, nil, fmt.Errorf("config overrides: %w", err)
+	}
+	return rules, closePlugins, nil
+}
+
+// loadCustomRules compiles the declarative rules defined in cfg, in its rules
+// directory and in dirs.
+func loadCustomRules(cfg *config.Config, dirs []string) ([]model.EvalRule, error) {
+	defs := append([]custom.Definition(nil), cfg.Rules...)
+	if cfg.RulesDir != "" {
+		dirs = append([]string{cfg.RulesDir}, dirs...)
+	}
+	for _, dir := range dirs {
+		d, err := custom.LoadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, d...)
+	}
+	if len(defs) == 0 {
+		return nil, nil
+	}
+
+	rules, err := custom.Compile(defs)
+	if err != nil {
+		return nil, fmt.Errorf("loading custom rules: %w", err)
+	}
+	return rules, nil
+}
+
+// startPlugins starts the executables in cfg's plugins directory and in dirs.
+func startPlugins(ctx context.Context, cfg *config.Config, dirs []string) ([]*plugin.Plugin, error) {
+	if cfg.PluginsDir != "" {
+		dirs = append([]string{cfg.PluginsDir}, dirs...)
+	}
+	var plugins []*plugin.Plugin
+	for _, dir := range dirs {
+		paths, err := plugin.Discover(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			p, err := plugin.Start(ctx, path)
+			if err != nil {
+				for _, started := range plugins {
+					started.Close()
+				}
+				return nil, err
+			}
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins, nil
+}
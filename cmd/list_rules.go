@@ -8,12 +8,18 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ilijad1/well-architected-terraform/internal/config"
 	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	_ "github.com/ilijad1/well-architected-terraform/internal/rules"
 )
 
-var listPillarFlag string
+var (
+	listPillarFlag   string
+	listConfigFlag   string
+	listRulesDirFlag []string
+	listPluginsFlag  []string
+)
 
 var listRulesCmd = &cobra.Command{
 	Use:   "list-rules",
@@ -23,40 +29,67 @@ var listRulesCmd = &cobra.Command{
 
 func init() {
 	listRulesCmd.Flags().StringVar(&listPillarFlag, "pillar", "", "Filter by pillar (e.g., Security)")
+	listRulesCmd.Flags().StringVar(&listConfigFlag, "config", ".wat.yaml", "Path to config file whose custom and plugin rules are listed too")
+	listRulesCmd.Flags().StringArrayVar(&listRulesDirFlag, "rules-dir", nil, "Directory of custom rule YAML files to list too (repeatable)")
+	listRulesCmd.Flags().StringArrayVar(&listPluginsFlag, "plugins-dir", nil, "Directory of rule plugin executables whose rules are listed too (repeatable)")
 	rootCmd.AddCommand(listRulesCmd)
 }
 
 func runListRules(cmd *cobra.Command, args []string) error {
-	// Collect metadata from both single-resource and cross-resource rules.
-	var allMeta []model.RuleMetadata
-	for _, r := range engine.AllRules() {
-		allMeta = append(allMeta, r.Metadata())
+	cfg, err := config.Load(listConfigFlag)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
 	}
-	for _, r := range engine.AllCrossRules() {
+	extraRules, closePlugins, err := loadExtraRules(cmd.Context(), cfg, listRulesDirFlag, listPluginsFlag)
+	if err != nil {
+		return err
+	}
+	closePlugins()
+
+	params := cfg.RuleParams()
+	var allMeta []model.RuleMetadata
+	rules := append(append([]model.EvalRule(nil), engine.AllRules()...), extraRules...)
+	rules = engine.ApplyOverrides(rules, cfg.RuleOverrides())
+	for _, r := range rules {
 		allMeta = append(allMeta, r.Metadata())
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintf(w, "ID\tNAME\tSEVERITY\tPILLAR\tRESOURCES\n")
-	_, _ = fmt.Fprintf(w, "--\t----\t--------\t------\t---------\n")
+	_, _ = fmt.Fprintf(w, "ID\tNAME\tSEVERITY\tPILLAR\tRESOURCES\tPARAMETERS\n")
+	_, _ = fmt.Fprintf(w, "--\t----\t--------\t------\t---------\t----------\n")
 
 	for _, meta := range allMeta {
 		if listPillarFlag != "" && !strings.EqualFold(string(meta.Pillar), listPillarFlag) {
 			continue
 		}
 
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
 			meta.ID,
 			meta.Name,
 			meta.Severity,
 			shortenPillar(meta.Pillar),
 			strings.Join(meta.ResourceTypes, ", "),
+			formatParams(meta, params[meta.ID]),
 		)
 	}
 
 	return w.Flush()
 }
 
+// formatParams lists a rule's parameters with their configured values, or
+// their defaults where none are configured.
+func formatParams(meta model.RuleMetadata, configured map[string]string) string {
+	parts := make([]string, len(meta.Parameters))
+	for i, p := range meta.Parameters {
+		value, ok := configured[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		parts[i] = p.Name + "=" + value
+	}
+	return strings.Join(parts, "; ")
+}
+
 func shortenPillar(p model.Pillar) string {
 	switch p {
 	case model.PillarSecurity:
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sync"
+
+	"github.com/ilijad1/well-architected-terraform/internal/report"
+)
+
+// startCPUProfile starts writing a Go CPU profile to path and returns a
+// function that stops it. Calling the function more than once is harmless.
+func startCPUProfile(path string) (func(), error) {
+	f, err := os.Create(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("creating CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("starting CPU profile: %w", err)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			pprof.StopCPUProfile()
+			_ = f.Close()
+		})
+	}, nil
+}
+
+// writeProfile prints the profile table to stderr with --profile and writes
+// the profile as JSON with --profile-json.
+func writeProfile(p report.Profile) error {
+	if profileFlag {
+		fmt.Fprintln(os.Stderr)
+		if err := report.WriteProfileTable(os.Stderr, p); err != nil {
+			return fmt.Errorf("writing profile: %w", err)
+		}
+	}
+	if profileJSONFlag != "" {
+		f, err := os.Create(profileJSONFlag) // #nosec G304 -- path is a CLI argument supplied by the operator
+		if err != nil {
+			return fmt.Errorf("creating profile: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		if err := report.WriteProfileJSON(f, p); err != nil {
+			return fmt.Errorf("writing profile: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 )
 
@@ -10,6 +15,9 @@ var rootCmd = &cobra.Command{
 	Long:  "Analyze Terraform configurations against the AWS Well-Architected Framework best practices.",
 }
 
+// Execute runs the CLI. An interrupt or SIGTERM cancels the running command.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return rootCmd.ExecuteContext(ctx)
 }
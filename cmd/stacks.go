@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilijad1/well-architected-terraform/internal/config"
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+	"github.com/ilijad1/well-architected-terraform/internal/parser"
+)
+
+// stackResult is the outcome of analyzing one stack.
+type stackResult struct {
+	resources    int
+	findings     []model.Finding
+	engineErrors []model.EngineError
+	parseTime    time.Duration // time reading and parsing input, not evaluating it
+	err          error
+}
+
+// resolveStacks returns the stacks to analyze, from --manifest or from the
+// arguments. Arguments may be glob patterns, where ** matches any number of
+// directories, for shells that do not expand them. With --terragrunt every
+// Terragrunt unit under an argument is a stack.
+func resolveStacks(args []string) ([]config.Stack, error) {
+	if manifestFlag != "" {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("pass either --manifest or input paths, not both")
+		}
+		return config.LoadManifest(manifestFlag)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("requires a plan, state or directory argument, or --manifest")
+	}
+
+	var stacks []config.Stack
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := expandGlob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+			paths = matches
+		}
+		// Stdin is left for loadStdin to reject.
+		if terragruntFlag && arg != "-" {
+			var units []string
+			for _, p := range paths {
+				found, err := parser.FindTerragruntUnits(p)
+				if err != nil {
+					return nil, fmt.Errorf("finding Terragrunt units: %w", err)
+				}
+				if len(found) == 0 {
+					return nil, fmt.Errorf("no terragrunt.hcl found under %q", p)
+				}
+				units = append(units, found...)
+			}
+			paths = units
+		}
+		for _, p := range paths {
+			if !seen[p] {
+				seen[p] = true
+				stacks = append(stacks, config.Stack{Name: p, Path: p})
+			}
+		}
+	}
+	return stacks, nil
+}
+
+// expandGlob returns the paths matching pattern, in lexical order. A "**"
+// path segment matches zero or more directories, other than hidden ones.
+func expandGlob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	// Walk from the longest leading directory without glob characters.
+	root := 0
+	for root < len(segments) && !strings.ContainsAny(segments[root], "*?[") {
+		root++
+	}
+	base := strings.Join(segments[:root], "/")
+	if base == "" {
+		base = "."
+	}
+	if strings.HasPrefix(pattern, "/") && root == 1 {
+		base = "/"
+	}
+
+	var matches []string
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		// Like shells, don't descend into hidden directories such as .terraform.
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if matchSegments(segments[root:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("expanding %q: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// matches any number of segments.
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
+
+// analyzeStacks analyzes the stacks concurrently, at most the engine's
+// parallelism at once, and returns their results in the order of stacks. If
+// ctx is done first, it returns nil without waiting for the stacks still
+// being parsed or analyzed.
+func analyzeStacks(ctx context.Context, eng *engine.Engine, stacks []config.Stack) []stackResult {
+	results := make([]stackResult, len(stacks))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sem := make(chan struct{}, eng.Parallelism())
+		var wg sync.WaitGroup
+		for i, st := range stacks {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, st config.Stack) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				results[i] = analyzeStack(ctx, eng, st)
+			}(i, st)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+		return results
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// analyzeStack parses one stack and runs the engine over its resources.
+func analyzeStack(ctx context.Context, eng *engine.Engine, st config.Stack) stackResult {
+	stream := eng.NewStream(ctx)
+	var planned bool
+	var adding time.Duration
+	start := time.Now()
+	err := loadResources(ctx, st, func(res model.TerraformResource) error {
+		planned = planned || res.Action != ""
+		addStart := time.Now()
+		stream.Add(res)
+		adding += time.Since(addStart)
+		return nil
+	})
+	parseTime := time.Since(start) - adding
+	if err != nil {
+		return stackResult{err: err}
+	}
+	if stream.Count() == 0 {
+		return stackResult{}
+	}
+
+	if changedOnlyFlag && !planned {
+		return stackResult{err: fmt.Errorf("--changed-only requires a plan file with resource_changes")}
+	}
+	if (regressionsFlag || failOnRegFlag) && !planned {
+		return stackResult{err: fmt.Errorf("--regressions requires a plan file with resource_changes")}
+	}
+
+	findings := stream.Finish()
+	if err := ctx.Err(); err != nil {
+		return stackResult{err: err}
+	}
+	return stackResult{resources: stream.Count(), findings: findings, engineErrors: stream.Errors(), parseTime: parseTime}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/plan.json", "plan.json", true},
+		{"**/plan.json", "prod/eu/plan.json", true},
+		{"**/plan.json", "prod/eu/state.json", false},
+		{"envs/**/plan.json", "envs/plan.json", true},
+		{"envs/**/plan.json", "envs/prod/eu/plan.json", true},
+		{"envs/**/plan.json", "stacks/prod/plan.json", false},
+		{"envs/**", "envs", true},
+		{"envs/**", "envs/prod/eu", true},
+		{"envs/*/**/*.json", "envs/prod/plan.json", true},
+		{"envs/*/**/*.json", "envs/plan.json", false},
+		{"**/**/plan.json", "plan.json", true},
+		{"*.json", "prod/plan.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			got := matchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/"))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"plan.json",
+		"prod/plan.json",
+		"prod/eu/plan.json",
+		"prod/eu/state.json",
+		"staging/plan.json",
+		"prod/.terraform/plan.json",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"leading", "**/plan.json", []string{"plan.json", "prod/eu/plan.json", "prod/plan.json", "staging/plan.json"}},
+		{"middle", "prod/**/plan.json", []string{"prod/eu/plan.json", "prod/plan.json"}},
+		{"trailing", "prod/**", []string{"prod/eu", "prod/eu/plan.json", "prod/eu/state.json", "prod/plan.json"}},
+		{"zero segments", "staging/**/plan.json", []string{"staging/plan.json"}},
+		{"no match", "**/missing.json", nil},
+		{"missing root", "missing/**/plan.json", nil},
+		{"without **", "*/plan.json", []string{"prod/plan.json", "staging/plan.json"}},
+		{"absolute", filepath.ToSlash(dir) + "/prod/**/plan.json", []string{dir + "/prod/eu/plan.json", dir + "/prod/plan.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandGlob(tt.pattern)
+			require.NoError(t, err)
+			var want []string
+			for _, w := range tt.want {
+				want = append(want, filepath.FromSlash(w))
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
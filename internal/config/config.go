@@ -1,17 +1,96 @@
-// Package config handles suppression configuration loading and application.
+// Package config handles suppression configuration and stack manifests.
 package config
 
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/ilijad1/well-architected-terraform/internal/custom"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
 // Config represents the .wat.yaml configuration file.
 type Config struct {
 	Version      string        `yaml:"version"`
 	Suppressions []Suppression `yaml:"suppressions"`
+	// Rules are declarative rules run alongside the built-in ones.
+	Rules []custom.Definition `yaml:"rules"`
+	// RulesDir is a directory of rules files, relative to the config file.
+	// Load resolves it against the config file's directory.
+	RulesDir string `yaml:"rules_dir"`
+	// PluginsDir is a directory of rule plugin executables, relative to the
+	// config file. Load resolves it against the config file's directory.
+	PluginsDir string `yaml:"plugins_dir"`
+	// Parameters overrides rule parameters, keyed by rule ID and then by
+	// parameter name.
+	Parameters map[string]map[string]ParamValue `yaml:"parameters"`
+	// Overrides remap the severity and pillar of rules. Later entries win
+	// over earlier ones.
+	Overrides []Override `yaml:"overrides"`
+}
+
+// Override remaps the severity and pillar of the rules whose IDs match a glob.
+type Override struct {
+	RuleID   string `yaml:"rule_id"`  // e.g. "S3-005" or "EC2-*"
+	Severity string `yaml:"severity"` // e.g. "HIGH"; empty keeps the rule's own
+	Pillar   string `yaml:"pillar"`   // e.g. "Cost Optimization"; empty keeps the rule's own
+}
+
+// ParamValue is a configured rule parameter value: a scalar, or a list whose
+// elements are joined with commas.
+type ParamValue string
+
+// UnmarshalYAML accepts a scalar or a list of scalars.
+func (v *ParamValue) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*v = ParamValue(node.Value)
+	case yaml.SequenceNode:
+		elements := make([]string, len(node.Content))
+		for i, e := range node.Content {
+			if e.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: parameter list elements must be scalars", e.Line)
+			}
+			elements[i] = e.Value
+		}
+		*v = ParamValue(strings.Join(elements, ","))
+	default:
+		return fmt.Errorf("line %d: parameter value must be a scalar or a list", node.Line)
+	}
+	return nil
+}
+
+// RuleParams returns the configured parameters in the form the engine takes.
+func (c *Config) RuleParams() map[string]map[string]string {
+	if len(c.Parameters) == 0 {
+		return nil
+	}
+	params := make(map[string]map[string]string, len(c.Parameters))
+	for id, values := range c.Parameters {
+		params[id] = make(map[string]string, len(values))
+		for name, v := range values {
+			params[id][name] = string(v)
+		}
+	}
+	return params
+}
+
+// RuleOverrides returns the configured overrides in the form the engine takes.
+// Load has validated them.
+func (c *Config) RuleOverrides() []model.RuleOverride {
+	overrides := make([]model.RuleOverride, len(c.Overrides))
+	for i, o := range c.Overrides {
+		overrides[i] = model.RuleOverride{RuleID: o.RuleID, Severity: model.Severity(strings.ToUpper(o.Severity))}
+		if o.Pillar != "" {
+			overrides[i].Pillar, _ = model.ParsePillar(o.Pillar)
+		}
+	}
+	return overrides
 }
 
 // Suppression defines a rule+resource combination that should be excluded from findings.
@@ -42,6 +121,12 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
 	}
 
+	for _, dir := range []*string{&cfg.RulesDir, &cfg.PluginsDir} {
+		if *dir != "" && !filepath.IsAbs(*dir) {
+			*dir = filepath.Join(filepath.Dir(path), *dir)
+		}
+	}
+
 	return &cfg, nil
 }
 
@@ -60,5 +145,22 @@ func validate(cfg *Config) error {
 			return fmt.Errorf("suppression[%d]: expires is required", i)
 		}
 	}
+	for i, o := range cfg.Overrides {
+		if o.RuleID == "" {
+			return fmt.Errorf("override[%d]: rule_id is required", i)
+		}
+		if _, err := path.Match(o.RuleID, ""); err != nil {
+			return fmt.Errorf("override[%d]: invalid rule_id pattern %q", i, o.RuleID)
+		}
+		if o.Severity == "" && o.Pillar == "" {
+			return fmt.Errorf("override[%d]: severity or pillar is required", i)
+		}
+		if o.Severity != "" && model.SeverityRank(model.Severity(strings.ToUpper(o.Severity))) == 0 {
+			return fmt.Errorf("override[%d]: invalid severity %q: use CRITICAL, HIGH, MEDIUM, LOW or INFO", i, o.Severity)
+		}
+		if _, ok := model.ParsePillar(o.Pillar); o.Pillar != "" && !ok {
+			return fmt.Errorf("override[%d]: invalid pillar %q", i, o.Pillar)
+		}
+	}
 	return nil
 }
@@ -105,6 +105,79 @@ func TestLoad_EmptyFile(t *testing.T) {
 	assert.Empty(t, cfg.Suppressions)
 }
 
+func TestLoad_Rules(t *testing.T) {
+	content := `rules_dir: policies
+plugins_dir: /opt/wat/plugins
+rules:
+  - id: ACME-001
+    name: Approved KMS key
+    severity: HIGH
+    pillar: Security
+    resource_types: [aws_lambda_function]
+    condition:
+      attribute: kms_key_arn
+      in: [arn:aws:kms:eu-west-1:111111111111:key/approved]
+`
+	path := writeTempFile(t, content)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, "ACME-001", cfg.Rules[0].ID)
+	assert.Equal(t, []string{"aws_lambda_function"}, cfg.Rules[0].ResourceTypes)
+	require.NotNil(t, cfg.Rules[0].Condition)
+	assert.Equal(t, "kms_key_arn", cfg.Rules[0].Condition.Attribute)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "policies"), cfg.RulesDir)
+	assert.Equal(t, "/opt/wat/plugins", cfg.PluginsDir)
+}
+
+func TestLoad_Parameters(t *testing.T) {
+	content := `parameters:
+  VPC-001:
+    ports: [22, 3389, 9200]
+  CW-001:
+    min_retention_days: 365
+`
+	path := writeTempFile(t, content)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"VPC-001": {"ports": "22,3389,9200"},
+		"CW-001":  {"min_retention_days": "365"},
+	}, cfg.RuleParams())
+
+	_, err = Load(writeTempFile(t, "parameters:\n  VPC-001:\n    ports: {ssh: 22}\n"))
+	assert.ErrorContains(t, err, "parameter value must be a scalar or a list")
+}
+
+func TestLoad_Overrides(t *testing.T) {
+	content := `overrides:
+  - rule_id: S3-005
+    severity: high
+  - rule_id: "EC2-*"
+    severity: INFO
+    pillar: Performance Efficiency
+`
+	cfg, err := Load(writeTempFile(t, content))
+	require.NoError(t, err)
+	assert.Equal(t, []model.RuleOverride{
+		{RuleID: "S3-005", Severity: model.SeverityHigh},
+		{RuleID: "EC2-*", Severity: model.SeverityInfo, Pillar: model.PillarPerformanceEfficiency},
+	}, cfg.RuleOverrides())
+
+	for content, msg := range map[string]string{
+		"overrides:\n  - severity: HIGH\n":                        "override[0]: rule_id is required",
+		"overrides:\n  - rule_id: S3-005\n":                       "override[0]: severity or pillar is required",
+		"overrides:\n  - rule_id: S3-005\n    severity: URGENT\n": "override[0]: invalid severity \"URGENT\"",
+		"overrides:\n  - rule_id: S3-005\n    pillar: Speed\n":    "override[0]: invalid pillar \"Speed\"",
+		"overrides:\n  - rule_id: \"S3-[\"\n    severity: HIGH\n": "override[0]: invalid rule_id pattern \"S3-[\"",
+	} {
+		_, err := Load(writeTempFile(t, content))
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
 func TestApply_NoSuppressions(t *testing.T) {
 	findings := []model.Finding{
 		{RuleID: "S3-001", Resource: "aws_s3_bucket.test"},
@@ -185,6 +258,37 @@ func TestApply_NoMatch(t *testing.T) {
 	assert.Empty(t, result.Suppressed)
 }
 
+func TestLoadManifest(t *testing.T) {
+	content := `stacks:
+  - name: network
+    path: network/plan.json
+    source_root: network
+  - path: /abs/app.tfstate
+`
+	path := writeTempFile(t, content)
+	dir := filepath.Dir(path)
+
+	stacks, err := LoadManifest(path)
+	require.NoError(t, err)
+	require.Len(t, stacks, 2)
+	assert.Equal(t, Stack{Name: "network", Path: filepath.Join(dir, "network/plan.json"), SourceRoot: filepath.Join(dir, "network")}, stacks[0])
+	assert.Equal(t, Stack{Name: "/abs/app.tfstate", Path: "/abs/app.tfstate"}, stacks[1])
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no stacks":      "stacks: []\n",
+		"missing path":   "stacks:\n  - name: a\n",
+		"duplicate name": "stacks:\n  - {name: a, path: x.json}\n  - {name: a, path: y.json}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadManifest(writeTempFile(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
 func writeTempFile(t *testing.T, content string) string {
 	t.Helper()
 	dir := t.TempDir()
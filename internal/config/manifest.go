@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest lists the stacks to analyze in a single run.
+type Manifest struct {
+	Stacks []Stack `yaml:"stacks"`
+}
+
+// Stack is one Terraform root module to analyze: a plan, state file or source directory.
+type Stack struct {
+	Name       string `yaml:"name"`        // label for the stack's findings; defaults to the path
+	Path       string `yaml:"path"`        // plan, state or source path
+	SourceRoot string `yaml:"source_root"` // source directory a plan was created from (optional)
+}
+
+// LoadManifest reads a stack manifest. Relative paths in the manifest are
+// resolved against the manifest's directory.
+func LoadManifest(path string) ([]Stack, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest file: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest file %s: %w", path, err)
+	}
+	if len(m.Stacks) == 0 {
+		return nil, fmt.Errorf("manifest file %s lists no stacks", path)
+	}
+
+	dir := filepath.Dir(path)
+	names := make(map[string]bool)
+	for i := range m.Stacks {
+		s := &m.Stacks[i]
+		if s.Path == "" {
+			return nil, fmt.Errorf("invalid manifest file %s: stacks[%d]: path is required", path, i)
+		}
+		if s.Name == "" {
+			s.Name = s.Path
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("invalid manifest file %s: stacks[%d]: duplicate name %q", path, i, s.Name)
+		}
+		names[s.Name] = true
+
+		if !filepath.IsAbs(s.Path) {
+			s.Path = filepath.Join(dir, s.Path)
+		}
+		if s.SourceRoot != "" && !filepath.IsAbs(s.SourceRoot) {
+			s.SourceRoot = filepath.Join(dir, s.SourceRoot)
+		}
+	}
+	return m.Stacks, nil
+}
@@ -0,0 +1,535 @@
+package custom
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// Condition is a predicate over a resource or one of its nested blocks.
+// Exactly one of All, Any, Not, Attribute, AnyBlock, AllBlocks, References and
+// ReferencedBy must be set.
+type Condition struct {
+	All []Condition `yaml:"all"`
+	Any []Condition `yaml:"any"`
+	Not *Condition  `yaml:"not"`
+
+	// Attribute names the attribute the operators below test. A dotted path
+	// reads into map attributes, e.g. "tags.Owner". Every operator set must
+	// hold.
+	Attribute      string        `yaml:"attribute"`
+	Exists         *bool         `yaml:"exists"`
+	Equals         interface{}   `yaml:"equals"`
+	In             []interface{} `yaml:"in"`
+	Matches        string        `yaml:"matches"`
+	GreaterThan    *float64      `yaml:"gt"`
+	GreaterOrEqual *float64      `yaml:"gte"`
+	LessThan       *float64      `yaml:"lt"`
+	LessOrEqual    *float64      `yaml:"lte"`
+
+	// AnyBlock holds if at least one nested block of the type satisfies
+	// Where; AllBlocks holds if every one does, including when there are
+	// none.
+	AnyBlock  *BlockCondition `yaml:"any_block"`
+	AllBlocks *BlockCondition `yaml:"all_blocks"`
+
+	// References holds if the resource's attribute refers to a resource of
+	// the type that satisfies Where. ReferencedBy holds if a resource of the
+	// type that satisfies Where refers to the resource through its
+	// attribute. Neither can be used within a block.
+	References   *ReferenceCondition `yaml:"references"`
+	ReferencedBy *ReferenceCondition `yaml:"referenced_by"`
+}
+
+// BlockCondition is a condition over the nested blocks of a type.
+type BlockCondition struct {
+	Type  string     `yaml:"type"`
+	Where *Condition `yaml:"where"`
+}
+
+// ReferenceCondition is a condition over the resources an attribute links.
+type ReferenceCondition struct {
+	Attribute string     `yaml:"attribute"`
+	Type      string     `yaml:"type"`
+	Where     *Condition `yaml:"where"`
+}
+
+// truth is the outcome of a condition. A condition that depends on a value
+// only known after apply is unknown.
+type truth int
+
+const (
+	fails truth = iota
+	holds
+	unknown
+)
+
+type result struct {
+	value truth
+	// reason explains an unknown result.
+	reason string
+}
+
+// evaluation is what a condition is evaluated against.
+type evaluation struct {
+	resource  model.TerraformResource
+	resources *model.ResourceSet
+	scope     scope
+}
+
+// scope is the resource or nested block whose attributes a condition tests.
+type scope struct {
+	attributes map[string]interface{}
+	unknown    map[string]bool
+	blocks     map[string][]model.Block
+	// prefix qualifies the attribute paths of unknown reasons.
+	prefix string
+}
+
+func resourceScope(res model.TerraformResource) scope {
+	return scope{attributes: res.Attributes, unknown: res.Unknown, blocks: res.Blocks}
+}
+
+func (s scope) block(blockType string, b model.Block) scope {
+	return scope{attributes: b.Attributes, unknown: b.Unknown, blocks: b.Blocks, prefix: s.prefix + blockType + "."}
+}
+
+// lookup returns the value at path and whether it is set, or unknown if it is
+// only known after apply.
+func (s scope) lookup(path string) (v interface{}, set, isUnknown bool) {
+	segments := strings.Split(path, ".")
+	if s.unknown[segments[0]] || s.unknown[path] {
+		return nil, false, true
+	}
+	v = s.attributes[segments[0]]
+	for _, seg := range segments[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false, false
+		}
+		v = m[seg]
+	}
+	return v, v != nil, false
+}
+
+func (s scope) unknownResult(path string) result {
+	return result{value: unknown, reason: s.prefix + path + " is only known after apply."}
+}
+
+type condition interface {
+	eval(ev *evaluation) result
+	// referencedTypes returns the types of the resources the condition reads
+	// besides the one it checks.
+	referencedTypes() []string
+}
+
+func compileCondition(c Condition, path string, inBlock bool) (condition, error) {
+	if c.Attribute == "" && c.hasOperator() {
+		return nil, fmt.Errorf("%s: exists, equals, in, matches, gt, gte, lt and lte require attribute", path)
+	}
+	set := 0
+	for _, ok := range []bool{len(c.All) > 0, len(c.Any) > 0, c.Not != nil, c.Attribute != "", c.AnyBlock != nil, c.AllBlocks != nil, c.References != nil, c.ReferencedBy != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("%s: set exactly one of all, any, not, attribute, any_block, all_blocks, references and referenced_by", path)
+	}
+
+	switch {
+	case len(c.All) > 0 || len(c.Any) > 0:
+		list, key := c.All, "all"
+		if len(c.Any) > 0 {
+			list, key = c.Any, "any"
+		}
+		conds := make([]condition, len(list))
+		for i, sub := range list {
+			cond, err := compileCondition(sub, fmt.Sprintf("%s.%s[%d]", path, key, i), inBlock)
+			if err != nil {
+				return nil, err
+			}
+			conds[i] = cond
+		}
+		return &combined{conds: conds, any: key == "any"}, nil
+	case c.Not != nil:
+		cond, err := compileCondition(*c.Not, path+".not", inBlock)
+		if err != nil {
+			return nil, err
+		}
+		return &negated{cond: cond}, nil
+	case c.Attribute != "":
+		return compileAttribute(c, path)
+	case c.AnyBlock != nil:
+		return compileBlock(*c.AnyBlock, path+".any_block", false)
+	case c.AllBlocks != nil:
+		return compileBlock(*c.AllBlocks, path+".all_blocks", true)
+	case c.References != nil:
+		if inBlock {
+			return nil, fmt.Errorf("%s: references cannot be used within a block", path)
+		}
+		return compileReference(*c.References, path+".references", false)
+	default:
+		if inBlock {
+			return nil, fmt.Errorf("%s: referenced_by cannot be used within a block", path)
+		}
+		return compileReference(*c.ReferencedBy, path+".referenced_by", true)
+	}
+}
+
+func (c Condition) hasOperator() bool {
+	return c.Exists != nil || c.Equals != nil || c.In != nil || c.Matches != "" ||
+		c.GreaterThan != nil || c.GreaterOrEqual != nil || c.LessThan != nil || c.LessOrEqual != nil
+}
+
+// combined is an all or any condition.
+type combined struct {
+	conds []condition
+	any   bool
+}
+
+func (c *combined) eval(ev *evaluation) result {
+	// all fails on the first failing condition and any holds on the first
+	// holding one; otherwise an unknown condition makes the outcome unknown.
+	decisive, res := fails, result{value: holds}
+	if c.any {
+		decisive, res = holds, result{value: fails}
+	}
+	for _, cond := range c.conds {
+		r := cond.eval(ev)
+		if r.value == decisive {
+			return r
+		}
+		if r.value == unknown && res.value != unknown {
+			res = r
+		}
+	}
+	return res
+}
+
+func (c *combined) referencedTypes() []string {
+	var types []string
+	for _, cond := range c.conds {
+		types = append(types, cond.referencedTypes()...)
+	}
+	return types
+}
+
+type negated struct {
+	cond condition
+}
+
+func (c *negated) eval(ev *evaluation) result {
+	r := c.cond.eval(ev)
+	switch r.value {
+	case holds:
+		r.value = fails
+	case fails:
+		r.value = holds
+	}
+	return r
+}
+
+func (c *negated) referencedTypes() []string {
+	return c.cond.referencedTypes()
+}
+
+// attribute tests the value of an attribute.
+type attribute struct {
+	path    string
+	exists  *bool
+	equals  interface{}
+	in      []interface{}
+	matches *regexp.Regexp
+	// bounds compare the value with a number.
+	bounds []bound
+}
+
+type bound struct {
+	limit float64
+	ok    func(v, limit float64) bool
+}
+
+func compileAttribute(c Condition, path string) (condition, error) {
+	if !c.hasOperator() {
+		return nil, fmt.Errorf("%s: attribute %s needs at least one of exists, equals, in, matches, gt, gte, lt and lte", path, c.Attribute)
+	}
+	a := &attribute{path: c.Attribute, exists: c.Exists, equals: normalize(c.Equals)}
+	if c.In != nil {
+		a.in = make([]interface{}, len(c.In))
+		for i, v := range c.In {
+			a.in[i] = normalize(v)
+		}
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid matches pattern: %w", path, err)
+		}
+		a.matches = re
+	}
+	for _, b := range []struct {
+		limit *float64
+		ok    func(v, limit float64) bool
+	}{
+		{c.GreaterThan, func(v, limit float64) bool { return v > limit }},
+		{c.GreaterOrEqual, func(v, limit float64) bool { return v >= limit }},
+		{c.LessThan, func(v, limit float64) bool { return v < limit }},
+		{c.LessOrEqual, func(v, limit float64) bool { return v <= limit }},
+	} {
+		if b.limit != nil {
+			a.bounds = append(a.bounds, bound{limit: *b.limit, ok: b.ok})
+		}
+	}
+	return a, nil
+}
+
+func (a *attribute) eval(ev *evaluation) result {
+	v, set, isUnknown := ev.scope.lookup(a.path)
+	if isUnknown {
+		// An attribute known after apply is set; only its value is unknown.
+		if a.exists != nil && !*a.exists {
+			return result{value: fails}
+		}
+		if a.equals == nil && a.in == nil && a.matches == nil && len(a.bounds) == 0 {
+			return result{value: holds}
+		}
+		return ev.scope.unknownResult(a.path)
+	}
+	if a.exists != nil && set != *a.exists {
+		return result{value: fails}
+	}
+	if !set {
+		if a.equals == nil && a.in == nil && a.matches == nil && len(a.bounds) == 0 {
+			return result{value: holds}
+		}
+		return result{value: fails}
+	}
+
+	v = normalize(v)
+	ok := (a.equals == nil || reflect.DeepEqual(v, a.equals)) &&
+		(a.in == nil || each(v, a.isIn)) &&
+		(a.matches == nil || each(v, a.isMatch))
+	for _, b := range a.bounds {
+		n, isNumber := v.(float64)
+		ok = ok && isNumber && b.ok(n, b.limit)
+	}
+	if ok {
+		return result{value: holds}
+	}
+	return result{value: fails}
+}
+
+func (a *attribute) isIn(v interface{}) bool {
+	for _, option := range a.in {
+		if reflect.DeepEqual(v, option) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *attribute) isMatch(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && a.matches.MatchString(s)
+}
+
+func (a *attribute) referencedTypes() []string {
+	return nil
+}
+
+// each applies ok to v, or to every element of v if it is a list.
+func each(v interface{}, ok func(interface{}) bool) bool {
+	list, isList := v.([]interface{})
+	if !isList {
+		return ok(v)
+	}
+	for _, e := range list {
+		if !ok(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize converts the numbers within v to float64, the type attributes
+// hold them as, so that values decoded from YAML compare equal to them.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, e := range n {
+			out[i] = normalize(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(n))
+		for k, e := range n {
+			out[k] = normalize(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// blocks tests the nested blocks of a type.
+type blocks struct {
+	blockType string
+	where     condition // nil matches every block
+	all       bool
+}
+
+func compileBlock(b BlockCondition, path string, all bool) (condition, error) {
+	if b.Type == "" {
+		return nil, fmt.Errorf("%s: type is required", path)
+	}
+	c := &blocks{blockType: b.Type, all: all}
+	if b.Where != nil {
+		where, err := compileCondition(*b.Where, path+".where", true)
+		if err != nil {
+			return nil, err
+		}
+		c.where = where
+	}
+	return c, nil
+}
+
+func (c *blocks) eval(ev *evaluation) result {
+	list := ev.scope.blocks[c.blockType]
+	if len(list) == 0 && ev.scope.unknown[c.blockType] {
+		return ev.scope.unknownResult(c.blockType)
+	}
+	if c.where == nil {
+		if c.all || len(list) > 0 {
+			return result{value: holds}
+		}
+		return result{value: fails}
+	}
+
+	conds := make([]condition, len(list))
+	for i, b := range list {
+		conds[i] = &inBlock{scope: ev.scope.block(c.blockType, b), cond: c.where}
+	}
+	return (&combined{conds: conds, any: !c.all}).eval(ev)
+}
+
+func (c *blocks) referencedTypes() []string {
+	return nil
+}
+
+// inBlock evaluates a condition against one nested block.
+type inBlock struct {
+	scope scope
+	cond  condition
+}
+
+func (c *inBlock) eval(ev *evaluation) result {
+	return c.cond.eval(&evaluation{resource: ev.resource, resources: ev.resources, scope: c.scope})
+}
+
+func (c *inBlock) referencedTypes() []string {
+	return nil
+}
+
+// reference tests the resources an attribute links the checked resource
+// with: those it refers to, or, for referrers, those referring to it.
+type reference struct {
+	attribute    string
+	resourceType string
+	where        condition // nil matches every resource
+	referrers    bool
+}
+
+func compileReference(r ReferenceCondition, path string, referrers bool) (condition, error) {
+	if r.Attribute == "" {
+		return nil, fmt.Errorf("%s: attribute is required", path)
+	}
+	if r.Type == "" {
+		return nil, fmt.Errorf("%s: type is required", path)
+	}
+	c := &reference{attribute: r.Attribute, resourceType: r.Type, referrers: referrers}
+	if r.Where != nil {
+		where, err := compileCondition(*r.Where, path+".where", false)
+		if err != nil {
+			return nil, err
+		}
+		c.where = where
+	}
+	return c, nil
+}
+
+func (c *reference) eval(ev *evaluation) result {
+	linked, unresolved := c.linked(ev)
+	res := result{value: fails}
+	if unresolved != "" {
+		res = result{value: unknown, reason: unresolved}
+	}
+	for _, other := range linked {
+		if c.where == nil {
+			return result{value: holds}
+		}
+		r := c.where.eval(&evaluation{resource: other, resources: ev.resources, scope: scope{
+			attributes: other.Attributes,
+			unknown:    other.Unknown,
+			blocks:     other.Blocks,
+			prefix:     other.Address() + ".",
+		}})
+		if r.value == holds {
+			return r
+		}
+		if r.value == unknown && res.value != unknown {
+			res = r
+		}
+	}
+	return res
+}
+
+// linked returns the resources the attribute links the checked resource with,
+// and the reason others may be linked if that depends on a value only known
+// after apply.
+func (c *reference) linked(ev *evaluation) ([]model.TerraformResource, string) {
+	if ev.resources == nil {
+		return nil, ""
+	}
+	if !c.referrers {
+		targets := ev.resources.Referenced(ev.resource, c.attribute, c.resourceType)
+		if len(targets) == 0 && ev.resource.IsUnknown(c.attribute) {
+			return nil, c.attribute + " is only known after apply."
+		}
+		return targets, ""
+	}
+
+	var referrers []model.TerraformResource
+	unresolved := ""
+	for _, other := range ev.resources.OfType(c.resourceType) {
+		targets := ev.resources.Referenced(other, c.attribute, ev.resource.Type)
+		for _, t := range targets {
+			if t.Address() == ev.resource.Address() {
+				referrers = append(referrers, other)
+				break
+			}
+		}
+		if len(targets) == 0 && other.IsUnknown(c.attribute) {
+			unresolved = fmt.Sprintf("the %s of an %s resource is only known after apply, so it may refer to this resource.", c.attribute, c.resourceType)
+		}
+	}
+	return referrers, unresolved
+}
+
+func (c *reference) referencedTypes() []string {
+	types := []string{c.resourceType}
+	if c.where != nil {
+		types = append(types, c.where.referencedTypes()...)
+	}
+	return types
+}
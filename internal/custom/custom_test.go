@@ -0,0 +1,342 @@
+package custom
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// compileRule compiles a single rule checking resourceType with the YAML
+// condition.
+func compileRule(t *testing.T, resourceType, condition string) model.EvalRule {
+	t.Helper()
+	var cond Condition
+	require.NoError(t, yaml.Unmarshal([]byte(condition), &cond))
+	rules, err := Compile([]Definition{{
+		ID:            "ACME-001",
+		Name:          "Test rule",
+		Description:   "Resource is not compliant.",
+		Severity:      "HIGH",
+		Pillar:        "Security",
+		ResourceTypes: []string{resourceType},
+		Remediation:   "Fix it.",
+		Condition:     &cond,
+	}})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	return rules[0]
+}
+
+// failing returns the addresses of the resources the rule reports.
+func failing(t *testing.T, rule model.EvalRule, resources ...model.TerraformResource) []string {
+	t.Helper()
+	var addrs []string
+	for _, f := range engine.NewWithRules(rule).Analyze(context.Background(), resources) {
+		addrs = append(addrs, f.Resource)
+	}
+	return addrs
+}
+
+func lambda(name string, attrs map[string]interface{}) model.TerraformResource {
+	return model.TerraformResource{Type: "aws_lambda_function", Name: name, Attributes: attrs}
+}
+
+func TestCompile_Metadata(t *testing.T) {
+	var cond Condition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+references:
+  attribute: kms_key_arn
+  type: aws_kms_key
+`), &cond))
+	rules, err := Compile([]Definition{{
+		ID:            "ACME-001",
+		Name:          "Approved KMS key",
+		Description:   "Functions must use an approved key.",
+		Severity:      "high",
+		Pillar:        "operational excellence",
+		ResourceTypes: []string{"aws_lambda_function"},
+		DocURL:        "https://example.com/acme-001",
+		Compliance:    map[string][]string{"ACME": {"SEC-7"}},
+		Condition:     &cond,
+	}})
+	require.NoError(t, err)
+
+	meta := rules[0].Metadata()
+	assert.Equal(t, "ACME-001", meta.ID)
+	assert.Equal(t, model.SeverityHigh, meta.Severity)
+	assert.Equal(t, model.PillarOperationalExcellence, meta.Pillar)
+	assert.Equal(t, []string{"aws_lambda_function"}, meta.ResourceTypes)
+	assert.Equal(t, []string{"aws_kms_key"}, rules[0].(*Rule).ReferencedTypes())
+	assert.Equal(t, map[string][]string{"ACME": {"SEC-7"}}, meta.ComplianceFrameworks)
+	assert.Equal(t, "https://example.com/acme-001", meta.DocURL)
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	valid := func() Definition {
+		return Definition{
+			ID:            "ACME-001",
+			Name:          "Test rule",
+			Description:   "Resource is not compliant.",
+			Severity:      "HIGH",
+			Pillar:        "Security",
+			ResourceTypes: []string{"aws_lambda_function"},
+			Condition:     &Condition{Attribute: "kms_key_arn", Exists: new(bool)},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(d *Definition)
+		err    string
+	}{
+		{"missing id", func(d *Definition) { d.ID = "" }, "rule[0]: id is required"},
+		{"missing description", func(d *Definition) { d.Description = "" }, "description is required"},
+		{"bad severity", func(d *Definition) { d.Severity = "SEVERE" }, `invalid severity "SEVERE"`},
+		{"bad pillar", func(d *Definition) { d.Pillar = "Speed" }, `invalid pillar "Speed"`},
+		{"missing resource types", func(d *Definition) { d.ResourceTypes = nil }, "resource_types is required"},
+		{"missing condition", func(d *Definition) { d.Condition = nil }, "condition is required"},
+		{"no operator", func(d *Definition) { d.Condition = &Condition{Attribute: "kms_key_arn"} }, "needs at least one of"},
+		{"operator without attribute", func(d *Definition) { d.Condition = &Condition{Matches: "^a"} }, "require attribute"},
+		{"two kinds", func(d *Definition) {
+			d.Condition = &Condition{Attribute: "a", Exists: new(bool), Not: &Condition{Attribute: "b", Exists: new(bool)}}
+		}, "set exactly one of"},
+		{"bad regex", func(d *Definition) { d.Condition = &Condition{Attribute: "a", Matches: "("} }, "condition: invalid matches pattern"},
+		{"reference in block", func(d *Definition) {
+			d.Condition = &Condition{AnyBlock: &BlockCondition{Type: "vpc_config", Where: &Condition{
+				References: &ReferenceCondition{Attribute: "subnet_ids", Type: "aws_subnet"},
+			}}}
+		}, "condition.any_block.where: references cannot be used within a block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := valid()
+			tt.modify(&def)
+			_, err := Compile([]Definition{def})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := Compile([]Definition{valid(), valid()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rule ACME-001: defined more than once")
+}
+
+func TestRule_Attributes(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		attrs     map[string]interface{}
+		fails     bool
+	}{
+		{"exists", "{attribute: kms_key_arn, exists: true}", map[string]interface{}{"kms_key_arn": "arn"}, false},
+		{"exists missing", "{attribute: kms_key_arn, exists: true}", map[string]interface{}{}, true},
+		{"not exists", "{attribute: kms_key_arn, exists: false}", map[string]interface{}{}, false},
+		{"equals", "{attribute: publish, equals: true}", map[string]interface{}{"publish": true}, false},
+		{"equals other", "{attribute: publish, equals: true}", map[string]interface{}{"publish": false}, true},
+		{"equals number", "{attribute: timeout, equals: 30}", map[string]interface{}{"timeout": float64(30)}, false},
+		{"equals missing", "{attribute: publish, equals: true}", map[string]interface{}{}, true},
+		{"in", "{attribute: runtime, in: [python3.12, nodejs20.x]}", map[string]interface{}{"runtime": "python3.12"}, false},
+		{"not in", "{attribute: runtime, in: [python3.12, nodejs20.x]}", map[string]interface{}{"runtime": "python3.8"}, true},
+		{"in list", "{attribute: architectures, in: [arm64]}", map[string]interface{}{"architectures": []interface{}{"arm64"}}, false},
+		{"in list other", "{attribute: architectures, in: [arm64]}", map[string]interface{}{"architectures": []interface{}{"arm64", "x86_64"}}, true},
+		{"matches", `{attribute: function_name, matches: "^acme-"}`, map[string]interface{}{"function_name": "acme-api"}, false},
+		{"does not match", `{attribute: function_name, matches: "^acme-"}`, map[string]interface{}{"function_name": "api"}, true},
+		{"matches number", `{attribute: timeout, matches: "3"}`, map[string]interface{}{"timeout": float64(3)}, true},
+		{"map path", "{attribute: tags.Owner, exists: true}", map[string]interface{}{"tags": map[string]interface{}{"Owner": "team"}}, false},
+		{"map path missing", "{attribute: tags.Owner, exists: true}", map[string]interface{}{"tags": map[string]interface{}{}}, true},
+		{"range", "{attribute: timeout, gte: 3, lt: 60}", map[string]interface{}{"timeout": float64(3)}, false},
+		{"above range", "{attribute: timeout, gte: 3, lt: 60}", map[string]interface{}{"timeout": float64(60)}, true},
+		{"range not a number", "{attribute: timeout, gt: 1}", map[string]interface{}{"timeout": "30"}, true},
+		{"all", "{all: [{attribute: publish, equals: true}, {attribute: timeout, lte: 30}]}", map[string]interface{}{"publish": true, "timeout": float64(60)}, true},
+		{"any", "{any: [{attribute: publish, equals: true}, {attribute: timeout, lte: 30}]}", map[string]interface{}{"publish": true, "timeout": float64(60)}, false},
+		{"not", "{not: {attribute: runtime, in: [python3.8]}}", map[string]interface{}{"runtime": "python3.8"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := compileRule(t, "aws_lambda_function", tt.condition)
+			got := failing(t, rule, lambda("fn", tt.attrs))
+			if tt.fails {
+				assert.Equal(t, []string{"aws_lambda_function.fn"}, got)
+			} else {
+				assert.Empty(t, got)
+			}
+		})
+	}
+}
+
+func TestRule_Finding(t *testing.T) {
+	rule := compileRule(t, "aws_lambda_function", "{attribute: kms_key_arn, exists: true}")
+	res := lambda("fn", nil)
+	res.File, res.Line = "main.tf", 12
+
+	findings := engine.NewWithRules(rule).Analyze(context.Background(), []model.TerraformResource{
+		res,
+		{Type: "aws_s3_bucket", Name: "other"},
+	})
+	require.Len(t, findings, 1)
+	f := findings[0]
+	assert.Equal(t, "ACME-001", f.RuleID)
+	assert.Equal(t, "Test rule", f.RuleName)
+	assert.Equal(t, model.SeverityHigh, f.Severity)
+	assert.Equal(t, "aws_lambda_function.fn", f.Resource)
+	assert.Equal(t, "main.tf", f.File)
+	assert.Equal(t, 12, f.Line)
+	assert.Equal(t, "Resource is not compliant.", f.Description)
+	assert.Equal(t, "Fix it.", f.Remediation)
+	assert.False(t, f.Undetermined)
+}
+
+func TestRule_Unknown(t *testing.T) {
+	res := lambda("fn", map[string]interface{}{})
+	res.Unknown = map[string]bool{"kms_key_arn": true}
+
+	rule := compileRule(t, "aws_lambda_function", "{attribute: kms_key_arn, exists: true}")
+	assert.Empty(t, failing(t, rule, res), "an attribute known after apply is set")
+
+	rule = compileRule(t, "aws_lambda_function", "{attribute: kms_key_arn, in: [arn]}")
+	findings := engine.NewWithRules(rule).Analyze(context.Background(), []model.TerraformResource{res})
+	require.Len(t, findings, 1)
+	assert.True(t, findings[0].Undetermined)
+	assert.Equal(t, "Cannot determine: kms_key_arn is only known after apply.", findings[0].Description)
+
+	rule = compileRule(t, "aws_lambda_function", "{any: [{attribute: kms_key_arn, in: [arn]}, {attribute: publish, equals: true}]}")
+	res.Attributes["publish"] = true
+	assert.Empty(t, failing(t, rule, res), "a holding condition decides any")
+}
+
+func TestRule_Blocks(t *testing.T) {
+	sg := func(cidrs ...string) model.TerraformResource {
+		res := model.TerraformResource{Type: "aws_security_group", Name: "sg", Blocks: map[string][]model.Block{}}
+		for _, c := range cidrs {
+			res.Blocks["ingress"] = append(res.Blocks["ingress"], model.Block{
+				Type:       "ingress",
+				Attributes: map[string]interface{}{"cidr_blocks": []interface{}{c}},
+			})
+		}
+		return res
+	}
+
+	all := compileRule(t, "aws_security_group", `
+all_blocks:
+  type: ingress
+  where: {not: {attribute: cidr_blocks, in: [0.0.0.0/0]}}
+`)
+	assert.Empty(t, failing(t, all, sg("10.0.0.0/8", "192.168.0.0/16")))
+	assert.Empty(t, failing(t, all, sg()), "all_blocks holds without blocks")
+	assert.NotEmpty(t, failing(t, all, sg("10.0.0.0/8", "0.0.0.0/0")))
+
+	anyBlock := compileRule(t, "aws_security_group", `
+any_block:
+  type: ingress
+  where: {attribute: cidr_blocks, in: [10.0.0.0/8]}
+`)
+	assert.Empty(t, failing(t, anyBlock, sg("0.0.0.0/0", "10.0.0.0/8")))
+	assert.NotEmpty(t, failing(t, anyBlock, sg()))
+
+	present := compileRule(t, "aws_security_group", "{any_block: {type: ingress}}")
+	assert.Empty(t, failing(t, present, sg("10.0.0.0/8")))
+	assert.NotEmpty(t, failing(t, present, sg()))
+
+	unknown := sg("10.0.0.0/8")
+	unknown.Blocks["ingress"][0].Attributes = map[string]interface{}{}
+	unknown.Blocks["ingress"][0].Unknown = map[string]bool{"cidr_blocks": true}
+	findings := engine.NewWithRules(anyBlock).Analyze(context.Background(), []model.TerraformResource{unknown})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Cannot determine: ingress.cidr_blocks is only known after apply.", findings[0].Description)
+}
+
+func TestRule_References(t *testing.T) {
+	key := model.TerraformResource{Type: "aws_kms_key", Name: "approved", Attributes: map[string]interface{}{
+		"arn":                 "arn:aws:kms:eu-west-1:111111111111:key/approved",
+		"enable_key_rotation": true,
+	}}
+	unrotated := model.TerraformResource{Type: "aws_kms_key", Name: "unrotated", Attributes: map[string]interface{}{
+		"arn": "arn:aws:kms:eu-west-1:111111111111:key/unrotated",
+	}}
+	byRef := lambda("by_ref", map[string]interface{}{})
+	byRef.References = map[string][]string{"kms_key_arn": {"aws_kms_key.approved"}}
+	byARN := lambda("by_arn", map[string]interface{}{"kms_key_arn": "arn:aws:kms:eu-west-1:111111111111:key/unrotated"})
+	none := lambda("none", map[string]interface{}{})
+	unresolved := lambda("unresolved", map[string]interface{}{})
+	unresolved.Unknown = map[string]bool{"kms_key_arn": true}
+
+	rule := compileRule(t, "aws_lambda_function", `
+references:
+  attribute: kms_key_arn
+  type: aws_kms_key
+  where: {attribute: enable_key_rotation, equals: true}
+`)
+	findings := engine.NewWithRules(rule).Analyze(context.Background(), []model.TerraformResource{key, unrotated, byRef, byARN, none, unresolved})
+	require.Len(t, findings, 3)
+	assert.Equal(t, "aws_lambda_function.by_arn", findings[0].Resource)
+	assert.False(t, findings[0].Undetermined)
+	assert.Equal(t, "aws_lambda_function.none", findings[1].Resource)
+	assert.Equal(t, "aws_lambda_function.unresolved", findings[2].Resource)
+	assert.True(t, findings[2].Undetermined)
+}
+
+func TestRule_ReferencedBy(t *testing.T) {
+	vpc := func(name string) model.TerraformResource {
+		return model.TerraformResource{Type: "aws_vpc", Name: name, Attributes: map[string]interface{}{"id": "vpc-" + name}}
+	}
+	flowLog := func(name, vpcID, traffic string) model.TerraformResource {
+		return model.TerraformResource{Type: "aws_flow_log", Name: name, Attributes: map[string]interface{}{"vpc_id": vpcID, "traffic_type": traffic}}
+	}
+	rule := compileRule(t, "aws_vpc", `
+referenced_by:
+  type: aws_flow_log
+  attribute: vpc_id
+  where: {attribute: traffic_type, equals: ALL}
+`)
+	assert.Equal(t, []string{"aws_vpc.rejects", "aws_vpc.none"}, failing(t, rule,
+		vpc("logged"), vpc("rejects"), vpc("none"),
+		flowLog("logged", "vpc-logged", "ALL"),
+		flowLog("rejects", "vpc-rejects", "REJECT"),
+	))
+
+	unresolved := flowLog("unresolved", "", "ALL")
+	delete(unresolved.Attributes, "vpc_id")
+	unresolved.Unknown = map[string]bool{"vpc_id": true}
+	findings := engine.NewWithRules(rule).Analyze(context.Background(), []model.TerraformResource{vpc("a"), unresolved})
+	require.Len(t, findings, 1)
+	assert.True(t, findings[0].Undetermined)
+	assert.Contains(t, findings[0].Description, "the vpc_id of an aws_flow_log resource is only known after apply")
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("b.yml", `rules:
+  - id: ACME-002
+    name: Second
+`)
+	write("a.yaml", `rules:
+  - id: ACME-001
+    name: First
+`)
+	write("notes.txt", "not rules")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o750))
+
+	defs, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	assert.Equal(t, "ACME-001", defs[0].ID)
+	assert.Equal(t, "ACME-002", defs[1].ID)
+
+	write("c.yaml", "rules: [")
+	_, err = LoadDir(dir)
+	assert.ErrorContains(t, err, "c.yaml")
+
+	_, err = LoadDir(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
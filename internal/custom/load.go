@@ -0,0 +1,49 @@
+package custom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a rules file: a list of definitions under a rules key, as in
+// .wat.yaml.
+type File struct {
+	Rules []Definition `yaml:"rules"`
+}
+
+// LoadDir reads the definitions of every .yaml and .yml file in dir, in file
+// name order. Subdirectories are not read.
+func LoadDir(dir string) ([]Definition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var defs []Definition
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path) // #nosec G304 -- path is within a rules directory supplied by the operator
+		if err != nil {
+			return nil, fmt.Errorf("reading rules file: %w", err)
+		}
+		var f File
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parsing rules file %s: %w", path, err)
+		}
+		defs = append(defs, f.Rules...)
+	}
+	return defs, nil
+}
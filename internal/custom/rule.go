@@ -0,0 +1,160 @@
+// Package custom compiles declarative rules, defined in YAML, into rules the
+// engine runs alongside the built-in ones.
+package custom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// Definition is a declarative rule as written in .wat.yaml or a rules file.
+type Definition struct {
+	ID            string              `yaml:"id"`
+	Name          string              `yaml:"name"`
+	Description   string              `yaml:"description"`
+	Severity      string              `yaml:"severity"`
+	Pillar        string              `yaml:"pillar"`
+	ResourceTypes []string            `yaml:"resource_types"`
+	DocURL        string              `yaml:"doc_url"`
+	Compliance    map[string][]string `yaml:"compliance"`
+	// Message is the description of each finding. It defaults to Description.
+	Message     string `yaml:"message"`
+	Remediation string `yaml:"remediation"`
+	// Condition describes a compliant resource. Resources for which it does
+	// not hold are reported.
+	Condition *Condition `yaml:"condition"`
+}
+
+// Rule is a compiled Definition.
+type Rule struct {
+	meta        model.RuleMetadata
+	targets     map[string]bool
+	referenced  []string // types the condition refers to that are not targets
+	condition   condition
+	message     string
+	remediation string
+}
+
+// Compile validates the definitions and returns their rules, in order.
+func Compile(defs []Definition) ([]model.EvalRule, error) {
+	rules := make([]model.EvalRule, 0, len(defs))
+	seen := make(map[string]bool)
+	for i, def := range defs {
+		rule, err := compile(def)
+		if err != nil {
+			if def.ID != "" {
+				return nil, fmt.Errorf("rule %s: %w", def.ID, err)
+			}
+			return nil, fmt.Errorf("rule[%d]: %w", i, err)
+		}
+		if seen[def.ID] {
+			return nil, fmt.Errorf("rule %s: defined more than once", def.ID)
+		}
+		seen[def.ID] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func compile(def Definition) (*Rule, error) {
+	if def.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if def.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if def.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	severity := model.Severity(strings.ToUpper(def.Severity))
+	if model.SeverityRank(severity) == 0 {
+		return nil, fmt.Errorf("invalid severity %q: use CRITICAL, HIGH, MEDIUM, LOW or INFO", def.Severity)
+	}
+	pillar, ok := model.ParsePillar(def.Pillar)
+	if !ok {
+		return nil, fmt.Errorf("invalid pillar %q", def.Pillar)
+	}
+	if len(def.ResourceTypes) == 0 {
+		return nil, fmt.Errorf("resource_types is required")
+	}
+	if def.Condition == nil {
+		return nil, fmt.Errorf("condition is required")
+	}
+	cond, err := compileCondition(*def.Condition, "condition", false)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]bool)
+	for _, t := range def.ResourceTypes {
+		targets[t] = true
+	}
+	var referenced []string
+	for _, t := range cond.referencedTypes() {
+		if !targets[t] && !contains(referenced, t) {
+			referenced = append(referenced, t)
+		}
+	}
+
+	message := def.Message
+	if message == "" {
+		message = def.Description
+	}
+	return &Rule{
+		meta: model.RuleMetadata{
+			ID:                   def.ID,
+			Name:                 def.Name,
+			Description:          def.Description,
+			Severity:             severity,
+			Pillar:               pillar,
+			ResourceTypes:        def.ResourceTypes,
+			DocURL:               def.DocURL,
+			ComplianceFrameworks: def.Compliance,
+		},
+		targets:     targets,
+		referenced:  referenced,
+		condition:   cond,
+		message:     message,
+		remediation: def.Remediation,
+	}, nil
+}
+
+// Metadata returns the rule's metadata.
+func (r *Rule) Metadata() model.RuleMetadata {
+	return r.meta
+}
+
+// ReferencedTypes returns the resource types the condition refers to besides
+// those the rule checks, so the engine indexes them too.
+func (r *Rule) ReferencedTypes() []string {
+	return r.referenced
+}
+
+// Check reports the resource if the rule's condition does not hold for it,
+// or an undetermined finding if the outcome depends on a value only known
+// after apply.
+func (r *Rule) Check(ctx *model.EvalContext) {
+	if !r.targets[ctx.Resource.Type] {
+		return
+	}
+	res := r.condition.eval(&evaluation{resource: ctx.Resource, resources: ctx.Resources, scope: resourceScope(ctx.Resource)})
+	switch res.value {
+	case holds:
+		return
+	case unknown:
+		ctx.Report(ctx.Finding(ctx.Resource, r.message, r.remediation).AsUndetermined(res.reason))
+	default:
+		ctx.Report(ctx.Finding(ctx.Resource, r.message, r.remediation))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package engine
+
+import "github.com/ilijad1/well-architected-terraform/internal/model"
+
+// FromRule adapts a single-resource Rule to the EvalRule contract. The engine
+// still evaluates adapted rules as each resource is added to a stream.
+func FromRule(r model.Rule) model.EvalRule {
+	return ruleAdapter{r}
+}
+
+// FromCrossRule adapts a CrossResourceRule to the EvalRule contract. The
+// engine checks adapted rules once per analysis rather than once per resource.
+func FromCrossRule(r model.CrossResourceRule) model.EvalRule {
+	return crossRuleAdapter{r}
+}
+
+// ResourceOnly marks an EvalRule that reads only the resource it checks and
+// its parameters. The engine evaluates it as each resource is added to a
+// stream, with a nil ctx.Resources, instead of holding the resources of its
+// types until the stream is finished.
+func ResourceOnly(r model.EvalRule) model.EvalRule {
+	return resourceOnlyRule{r}
+}
+
+type resourceOnlyRule struct {
+	model.EvalRule
+}
+
+type ruleAdapter struct {
+	rule model.Rule
+}
+
+func (a ruleAdapter) Metadata() model.RuleMetadata {
+	return a.rule.Metadata()
+}
+
+func (a ruleAdapter) Check(ctx *model.EvalContext) {
+	ctx.Report(a.rule.Evaluate(ctx.Resource)...)
+}
+
+type crossRuleAdapter struct {
+	rule model.CrossResourceRule
+}
+
+func (a crossRuleAdapter) Metadata() model.RuleMetadata {
+	return a.rule.Metadata()
+}
+
+func (a crossRuleAdapter) Check(ctx *model.EvalContext) {
+	ctx.Report(a.rule.EvaluateAll(ctx.Resources)...)
+}
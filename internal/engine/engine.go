@@ -2,6 +2,14 @@
 package engine
 
 import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync"
+	"time"
+
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
@@ -11,109 +19,241 @@ type Config struct {
 	MinSeverity model.Severity
 	RuleIDs     []string
 	ExcludeIDs  []string
+	// ChangedOnly restricts findings to resources the plan creates, updates or
+	// replaces. Cross-resource rules still see every resource.
+	ChangedOnly bool
+	// Regressions re-runs the rules against the prior version of every
+	// updated or replaced resource and marks findings the change introduces.
+	Regressions bool
+	// RuleParams holds each rule's configured parameters, keyed by rule ID
+	// and then by parameter name.
+	RuleParams map[string]map[string]string
+	// Parallelism bounds how many rule evaluations run at once across every
+	// stream of the engine. Zero or less means runtime.GOMAXPROCS(0).
+	Parallelism int
+	// RuleTimeout is the time budget of one rule evaluation: one rule
+	// checking one resource, or a cross-resource rule checking the whole
+	// set. An evaluation over budget is reported as a timed out engine error
+	// and the run moves on, releasing the evaluation slot even if the rule
+	// ignores its context. Zero means no budget.
+	RuleTimeout time.Duration
+	// ExtraRules run alongside the registered rules, e.g. declarative rules
+	// loaded from configuration. They are filtered like registered rules.
+	ExtraRules []model.EvalRule
+	// Overrides remap the severity and pillar of the rules they match. They
+	// apply before the rules are filtered, so MinSeverity and Pillars see the
+	// overridden values.
+	Overrides []model.RuleOverride
+	// Profile records the evaluations, findings and wall time of each rule,
+	// and labels each evaluation with its rule ID in CPU profiles.
+	Profile bool
 }
 
 // Engine runs rules against parsed Terraform resources.
 type Engine struct {
-	rules      []model.Rule
-	crossRules []model.CrossResourceRule
+	rules       []model.EvalRule
+	params      map[string]map[string]string
+	changedOnly bool
+	regressions bool
+	ruleTimeout time.Duration
+	profile     map[string]*ruleStats // nil unless profiling
+	// sem holds a slot for each evaluation in progress. It is nil when the
+	// engine evaluates sequentially.
+	sem chan struct{}
 }
 
 // New creates an Engine with rules filtered by the given config.
 func New(config Config) *Engine {
+	all := append(append([]model.EvalRule(nil), AllRules()...), config.ExtraRules...)
+	rules := filterRules(ApplyOverrides(all, config.Overrides), config)
+	var profile map[string]*ruleStats
+	if config.Profile {
+		profile = newProfile(rules)
+	}
 	return &Engine{
-		rules:      filterRules(AllRules(), config),
-		crossRules: filterCrossRules(AllCrossRules(), config),
+		rules:       rules,
+		profile:     profile,
+		params:      config.RuleParams,
+		changedOnly: config.ChangedOnly,
+		regressions: config.Regressions,
+		ruleTimeout: config.RuleTimeout,
+		sem:         newSemaphore(config.Parallelism),
 	}
 }
 
 // NewWithRules creates an Engine with an explicit set of rules (useful for testing).
-func NewWithRules(rules []model.Rule, crossRules []model.CrossResourceRule) *Engine {
-	return &Engine{rules: rules, crossRules: crossRules}
+// Legacy rules can be passed through FromRule and FromCrossRule.
+func NewWithRules(rules ...model.EvalRule) *Engine {
+	return &Engine{rules: rules}
 }
 
-// Rules returns the engine's active single-resource rules.
-func (e *Engine) Rules() []model.Rule {
+// Rules returns the engine's active rules.
+func (e *Engine) Rules() []model.EvalRule {
 	return e.rules
 }
 
-// CrossRules returns the engine's active cross-resource rules.
-func (e *Engine) CrossRules() []model.CrossResourceRule {
-	return e.crossRules
+// Parallelism returns how many rule evaluations the engine runs at once.
+func (e *Engine) Parallelism() int {
+	if e.sem == nil {
+		return 1
+	}
+	return cap(e.sem)
 }
 
 // Analyze runs all applicable rules against the resources and returns findings.
-// Single-resource rules are dispatched per resource type; cross-resource rules
-// receive the full resource list.
-func (e *Engine) Analyze(resources []model.TerraformResource) []model.Finding {
-	// Build dispatch map: resource type -> applicable rules
-	rulesByType := make(map[string][]model.Rule)
-	for _, r := range e.rules {
-		for _, rt := range r.Metadata().ResourceTypes {
-			rulesByType[rt] = append(rulesByType[rt], r)
-		}
-	}
-
-	var findings []model.Finding
+// Single-resource rules are dispatched per resource type; other rules receive
+// every resource of the types they declare. Once ctx is done no further rules
+// are evaluated.
+func (e *Engine) Analyze(ctx context.Context, resources []model.TerraformResource) []model.Finding {
+	s := e.NewStream(ctx)
 	for _, resource := range resources {
-		for _, rule := range rulesByType[resource.Type] {
-			results := rule.Evaluate(resource)
-			findings = append(findings, results...)
-		}
+		s.Add(resource)
 	}
+	return s.Finish()
+}
 
-	// Run cross-resource rules against the full resource list.
-	for _, rule := range e.crossRules {
-		results := rule.EvaluateAll(resources)
-		findings = append(findings, results...)
+// check runs one rule against one resource and returns what it reports. A
+// rule that panics, calls Fail or goes over the rule time budget yields an
+// engine error and no findings. Nothing is evaluated once ctx is done.
+func (e *Engine) check(ctx context.Context, rule model.EvalRule, meta model.RuleMetadata, resource model.TerraformResource, resources *model.ResourceSet) (findings []model.Finding, engineErr *model.EngineError) {
+	if ctx.Err() != nil {
+		return nil, nil
 	}
-
-	return findings
+	if stats := e.profile[meta.ID]; stats != nil {
+		start := time.Now()
+		defer func() { stats.record(time.Since(start), len(findings)) }()
+		pprof.Do(ctx, pprof.Labels("rule", meta.ID), func(ctx context.Context) {
+			findings, engineErr = e.checkWithin(ctx, rule, meta, resource, resources)
+		})
+		return findings, engineErr
+	}
+	return e.checkWithin(ctx, rule, meta, resource, resources)
 }
 
-func filterCrossRules(rules []model.CrossResourceRule, config Config) []model.CrossResourceRule {
-	if len(config.Pillars) == 0 && config.MinSeverity == "" && len(config.RuleIDs) == 0 && len(config.ExcludeIDs) == 0 {
-		return rules
+// checkWithin runs check's evaluation within the rule time budget. The rule
+// runs on a goroutine of its own so that one ignoring its context cannot hold
+// the run: once the budget runs out or ctx is done the evaluation is given up
+// on, and a rule still running keeps its goroutine but its results are
+// dropped. Without a budget or a cancellable ctx the rule runs directly.
+func (e *Engine) checkWithin(ctx context.Context, rule model.EvalRule, meta model.RuleMetadata, resource model.TerraformResource, resources *model.ResourceSet) ([]model.Finding, *model.EngineError) {
+	if e.ruleTimeout <= 0 && ctx.Done() == nil {
+		return e.evaluate(ctx, rule, meta, resource, resources)
 	}
 
-	pillarSet := toStringSet(pillarsToStrings(config.Pillars))
-	includeSet := toStringSet(config.RuleIDs)
-	excludeSet := toStringSet(config.ExcludeIDs)
-	minRank := model.SeverityRank(config.MinSeverity)
-
-	var filtered []model.CrossResourceRule
-	for _, r := range rules {
-		meta := r.Metadata()
+	budget, cancel := ctx, context.CancelFunc(func() {})
+	if e.ruleTimeout > 0 {
+		budget, cancel = context.WithTimeout(ctx, e.ruleTimeout)
+	}
+	defer cancel()
+	done := make(chan ruleResult, 1)
+	go func() {
+		findings, err := e.evaluate(budget, rule, meta, resource, resources)
+		done <- ruleResult{findings, err, time.Now()}
+	}()
 
-		if len(excludeSet) > 0 {
-			if _, excluded := excludeSet[meta.ID]; excluded {
-				continue
-			}
+	r, finished := awaitRule(budget, done)
+	switch {
+	case ctx.Err() != nil:
+		return nil, nil
+	case !finished:
+		return nil, &model.EngineError{
+			RuleID:   meta.ID,
+			Resource: resourceAddress(resource),
+			Message:  fmt.Sprintf("timed out after %s", e.ruleTimeout),
+			TimedOut: true,
 		}
+	}
+	return r.findings, r.err
+}
 
-		if len(includeSet) > 0 {
-			if _, included := includeSet[meta.ID]; !included {
-				continue
-			}
-		}
+// ruleResult is the outcome of an evaluation run against a time budget.
+type ruleResult struct {
+	findings []model.Finding
+	err      *model.EngineError
+	finished time.Time
+}
 
-		if len(pillarSet) > 0 {
-			if _, ok := pillarSet[string(meta.Pillar)]; !ok {
-				continue
-			}
+// awaitRule waits for an evaluation to finish or its budget to run out. It
+// reports false if the evaluation did not finish within the budget. Which of
+// the two the wait sees first does not matter: a result that was ready as the
+// budget expired still counts.
+func awaitRule(budget context.Context, done <-chan ruleResult) (ruleResult, bool) {
+	var r ruleResult
+	select {
+	case r = <-done:
+	case <-budget.Done():
+		select {
+		case r = <-done:
+		default:
+			return ruleResult{}, false
 		}
+	}
+	if deadline, ok := budget.Deadline(); ok && !r.finished.Before(deadline) {
+		return ruleResult{}, false
+	}
+	return r, true
+}
 
-		if minRank > 0 && model.SeverityRank(meta.Severity) < minRank {
-			continue
+// evaluate calls the rule's Check, turning a panic or a call to Fail into an
+// engine error.
+func (e *Engine) evaluate(ctx context.Context, rule model.EvalRule, meta model.RuleMetadata, resource model.TerraformResource, resources *model.ResourceSet) (findings []model.Finding, engineErr *model.EngineError) {
+	defer func() {
+		if r := recover(); r != nil {
+			findings = nil
+			engineErr = &model.EngineError{RuleID: meta.ID, Resource: resourceAddress(resource), Message: fmt.Sprintf("panic: %v", r), Trace: string(debug.Stack())}
 		}
+	}()
 
-		filtered = append(filtered, r)
+	evalCtx := model.NewEvalContext(ctx, meta, resource, resources, e.params[meta.ID])
+	rule.Check(evalCtx)
+	if err := evalCtx.Err(); err != nil {
+		return nil, &model.EngineError{RuleID: meta.ID, Resource: resourceAddress(resource), Message: err.Error()}
 	}
+	findings = evalCtx.Findings()
+	if r, ok := rule.(overriddenRule); ok {
+		r.apply(findings)
+	}
+	return findings, nil
+}
 
-	return filtered
+// resourceAddress returns the address of the resource an evaluation checks,
+// or "" for a rule checked once against the whole set.
+func resourceAddress(resource model.TerraformResource) string {
+	if resource.Type == "" {
+		return ""
+	}
+	return resource.Address()
+}
+
+// run calls fn, on a new goroutine added to wg when the engine evaluates in
+// parallel. It blocks while the engine's evaluation slots are all in use.
+func (e *Engine) run(wg *sync.WaitGroup, fn func()) {
+	if e.sem == nil {
+		fn()
+		return
+	}
+	e.sem <- struct{}{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() { <-e.sem }()
+		fn()
+	}()
+}
+
+// newSemaphore returns the evaluation slots for the given parallelism, or nil
+// for sequential evaluation.
+func newSemaphore(parallelism int) chan struct{} {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	if parallelism == 1 {
+		return nil
+	}
+	return make(chan struct{}, parallelism)
 }
 
-func filterRules(rules []model.Rule, config Config) []model.Rule {
+func filterRules(rules []model.EvalRule, config Config) []model.EvalRule {
 	if len(config.Pillars) == 0 && config.MinSeverity == "" && len(config.RuleIDs) == 0 && len(config.ExcludeIDs) == 0 {
 		return rules
 	}
@@ -123,7 +263,7 @@ func filterRules(rules []model.Rule, config Config) []model.Rule {
 	excludeSet := toStringSet(config.ExcludeIDs)
 	minRank := model.SeverityRank(config.MinSeverity)
 
-	var filtered []model.Rule
+	var filtered []model.EvalRule
 	for _, r := range rules {
 		meta := r.Metadata()
 
@@ -1,9 +1,14 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
@@ -39,7 +44,7 @@ func TestEngine_Analyze_DispatchesByResourceType(t *testing.T) {
 	s3Rule := &mockRule{id: "S3-TEST", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}
 	ec2Rule := &mockRule{id: "EC2-TEST", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_instance"}}
 
-	eng := NewWithRules([]model.Rule{s3Rule, ec2Rule}, nil)
+	eng := NewWithRules(FromRule(s3Rule), FromRule(ec2Rule))
 
 	resources := []model.TerraformResource{
 		{Type: "aws_s3_bucket", Name: "test"},
@@ -47,16 +52,16 @@ func TestEngine_Analyze_DispatchesByResourceType(t *testing.T) {
 		{Type: "aws_rds_cluster", Name: "test"}, // no matching rule
 	}
 
-	findings := eng.Analyze(resources)
+	findings := eng.Analyze(context.Background(), resources)
 	assert.Len(t, findings, 2)
 	assert.Equal(t, "S3-TEST", findings[0].RuleID)
 	assert.Equal(t, "EC2-TEST", findings[1].RuleID)
 }
 
 func TestFilterRules_ByPillar(t *testing.T) {
-	rules := []model.Rule{
-		&mockRule{id: "SEC-1", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}},
-		&mockRule{id: "REL-1", pillar: model.PillarReliability, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}},
+	rules := []model.EvalRule{
+		FromRule(&mockRule{id: "SEC-1", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}),
+		FromRule(&mockRule{id: "REL-1", pillar: model.PillarReliability, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}),
 	}
 
 	filtered := filterRules(rules, Config{Pillars: []model.Pillar{model.PillarSecurity}})
@@ -65,9 +70,9 @@ func TestFilterRules_ByPillar(t *testing.T) {
 }
 
 func TestFilterRules_ByMinSeverity(t *testing.T) {
-	rules := []model.Rule{
-		&mockRule{id: "HIGH-1", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}},
-		&mockRule{id: "LOW-1", pillar: model.PillarSecurity, severity: model.SeverityLow, resourceTypes: []string{"aws_s3_bucket"}},
+	rules := []model.EvalRule{
+		FromRule(&mockRule{id: "HIGH-1", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}),
+		FromRule(&mockRule{id: "LOW-1", pillar: model.PillarSecurity, severity: model.SeverityLow, resourceTypes: []string{"aws_s3_bucket"}}),
 	}
 
 	filtered := filterRules(rules, Config{MinSeverity: model.SeverityHigh})
@@ -76,9 +81,9 @@ func TestFilterRules_ByMinSeverity(t *testing.T) {
 }
 
 func TestFilterRules_ByExcludeIDs(t *testing.T) {
-	rules := []model.Rule{
-		&mockRule{id: "S3-001", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}},
-		&mockRule{id: "S3-002", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}},
+	rules := []model.EvalRule{
+		FromRule(&mockRule{id: "S3-001", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}),
+		FromRule(&mockRule{id: "S3-002", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}),
 	}
 
 	filtered := filterRules(rules, Config{ExcludeIDs: []string{"S3-001"}})
@@ -86,6 +91,64 @@ func TestFilterRules_ByExcludeIDs(t *testing.T) {
 	assert.Equal(t, "S3-002", filtered[0].Metadata().ID)
 }
 
+func TestNew_ExtraRules(t *testing.T) {
+	extra := &loggedBucketRule{}
+
+	eng := New(Config{ExtraRules: []model.EvalRule{extra}})
+	assert.Contains(t, eng.Rules(), model.EvalRule(extra))
+
+	eng = New(Config{ExtraRules: []model.EvalRule{extra}, ExcludeIDs: []string{"S3-EVAL"}})
+	assert.NotContains(t, eng.Rules(), model.EvalRule(extra))
+}
+
+func TestNew_Overrides(t *testing.T) {
+	tags := &mockRule{id: "S3-005", pillar: model.PillarOperationalExcellence, severity: model.SeverityLow, resourceTypes: []string{"aws_s3_bucket"}}
+	monitoring := &mockRule{id: "EC2-004", pillar: model.PillarOperationalExcellence, severity: model.SeverityMedium, resourceTypes: []string{"aws_instance"}}
+	cross := &mockCrossRule{id: "S3-CROSS", pillar: model.PillarSecurity, severity: model.SeverityLow}
+	eng := New(Config{
+		ExtraRules: []model.EvalRule{FromRule(tags), FromRule(monitoring), FromCrossRule(cross), &loggedBucketRule{}},
+		Overrides: []model.RuleOverride{
+			{RuleID: "S3-*", Severity: model.SeverityHigh},
+			{RuleID: "S3-005", Pillar: model.PillarCostOptimization},
+			{RuleID: "EC2-004", Severity: model.SeverityInfo},
+		},
+		MinSeverity: model.SeverityHigh,
+	})
+
+	// EC2-004 falls below the minimum severity once overridden.
+	var ids []string
+	for _, r := range eng.Rules() {
+		ids = append(ids, r.Metadata().ID)
+	}
+	assert.Equal(t, []string{"S3-005", "S3-CROSS", "S3-EVAL"}, ids)
+	assert.Equal(t, model.PillarCostOptimization, eng.Rules()[0].Metadata().Pillar)
+
+	// Findings take the overridden values, including those of legacy rules
+	// that set their own.
+	findings := eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "a"},
+		{Type: "aws_instance", Name: "web"},
+	})
+	require.Len(t, findings, 3)
+	for _, f := range findings {
+		assert.Equal(t, model.SeverityHigh, f.Severity, f.RuleID)
+	}
+	assert.Equal(t, "S3-005", findings[0].RuleID)
+	assert.Equal(t, model.PillarCostOptimization, findings[0].Pillar)
+	assert.Equal(t, model.PillarSecurity, findings[1].Pillar)
+	assert.Equal(t, model.PillarOperationalExcellence, findings[2].Pillar)
+}
+
+func TestValidateOverrides(t *testing.T) {
+	rules := []model.EvalRule{FromRule(&mockRule{id: "S3-005"})}
+
+	assert.NoError(t, ValidateOverrides(rules, []model.RuleOverride{{RuleID: "S3-*", Severity: model.SeverityHigh}}))
+	assert.EqualError(t, ValidateOverrides(rules, []model.RuleOverride{
+		{RuleID: "S3-005", Severity: model.SeverityHigh},
+		{RuleID: "S3-05", Severity: model.SeverityHigh},
+	}), "override[1]: S3-05 matches no rule")
+}
+
 // mockCrossRule is a test cross-resource rule.
 type mockCrossRule struct {
 	id       string
@@ -103,7 +166,7 @@ func (r *mockCrossRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *mockCrossRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
+func (r *mockCrossRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
 	return []model.Finding{{
 		RuleID:   r.id,
 		Resource: "cross-check",
@@ -116,54 +179,760 @@ func TestEngine_Analyze_CrossResourceRules(t *testing.T) {
 	singleRule := &mockRule{id: "S3-001", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}
 	crossRule := &mockCrossRule{id: "S3-CROSS", pillar: model.PillarSecurity, severity: model.SeverityCritical}
 
-	eng := NewWithRules([]model.Rule{singleRule}, []model.CrossResourceRule{crossRule})
+	eng := NewWithRules(FromRule(singleRule), FromCrossRule(crossRule))
 
 	resources := []model.TerraformResource{
 		{Type: "aws_s3_bucket", Name: "test"},
 	}
 
-	findings := eng.Analyze(resources)
+	findings := eng.Analyze(context.Background(), resources)
 	assert.Len(t, findings, 2)
 	assert.Equal(t, "S3-001", findings[0].RuleID)
 	assert.Equal(t, "S3-CROSS", findings[1].RuleID)
 }
 
-func TestEngine_CrossRules_Accessor(t *testing.T) {
+// everyResourceCrossRule reports one finding per resource it is given.
+type everyResourceCrossRule struct {
+	mockCrossRule
+}
+
+func (r *everyResourceCrossRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	var findings []model.Finding
+	for _, res := range resources.All() {
+		findings = append(findings, model.Finding{RuleID: r.id, Resource: res.Address()})
+	}
+	return findings
+}
+
+func TestEngine_Analyze_ChangedOnly(t *testing.T) {
+	singleRule := &mockRule{id: "S3-001", pillar: model.PillarSecurity, severity: model.SeverityHigh, resourceTypes: []string{"aws_s3_bucket"}}
+	crossRule := &everyResourceCrossRule{mockCrossRule{id: "S3-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh}}
+
+	eng := NewWithRules(FromRule(singleRule), FromCrossRule(crossRule))
+	eng.changedOnly = true
+
+	resources := []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "created", Action: model.ActionCreate},
+		{Type: "aws_s3_bucket", Name: "replaced", Action: model.ActionReplace},
+		{Type: "aws_s3_bucket", Name: "untouched", Action: model.ActionNoOp},
+	}
+
+	findings := eng.Analyze(context.Background(), resources)
+	var addrs []string
+	for _, f := range findings {
+		addrs = append(addrs, f.RuleID+" "+f.Resource)
+	}
+	assert.ElementsMatch(t, []string{
+		"S3-001 aws_s3_bucket.created",
+		"S3-001 aws_s3_bucket.replaced",
+		"S3-CROSS aws_s3_bucket.created",
+		"S3-CROSS aws_s3_bucket.replaced",
+	}, addrs)
+}
+
+// attrRule reports a finding when a boolean attribute is false.
+type attrRule struct {
+	mockRule
+	attr string
+}
+
+func (r *attrRule) Evaluate(resource model.TerraformResource) []model.Finding {
+	if v, ok := resource.GetBoolAttr(r.attr); ok && v {
+		return nil
+	}
+	return r.mockRule.Evaluate(resource)
+}
+
+func TestEngine_Analyze_Regressions(t *testing.T) {
+	encRule := &attrRule{mockRule{id: "RDS-ENC", resourceTypes: []string{"aws_db_instance"}}, "storage_encrypted"}
+	protRule := &attrRule{mockRule{id: "RDS-PROT", resourceTypes: []string{"aws_db_instance"}}, "deletion_protection"}
+
+	eng := NewWithRules(FromRule(encRule), FromRule(protRule))
+	eng.regressions = true
+
+	before := model.TerraformResource{Type: "aws_db_instance", Name: "main", Attributes: map[string]interface{}{
+		"storage_encrypted": false, "deletion_protection": true,
+	}}
+	resources := []model.TerraformResource{
+		{Type: "aws_db_instance", Name: "main", Action: model.ActionUpdate, Before: &before, Attributes: map[string]interface{}{
+			"storage_encrypted": false, "deletion_protection": false,
+		}},
+		{Type: "aws_db_instance", Name: "new", Action: model.ActionCreate, Attributes: map[string]interface{}{}},
+	}
+
+	regressions := make(map[string]bool)
+	for _, f := range eng.Analyze(context.Background(), resources) {
+		regressions[f.RuleID+" "+f.Resource] = f.Regression
+	}
+	assert.Equal(t, map[string]bool{
+		"RDS-ENC aws_db_instance.main":  false, // existing debt
+		"RDS-PROT aws_db_instance.main": true,  // removed by the change
+		"RDS-ENC aws_db_instance.new":   false, // new resources are not regressions
+		"RDS-PROT aws_db_instance.new":  false,
+	}, regressions)
+}
+
+func TestEngine_Rules_Accessor(t *testing.T) {
+	singleRule := &mockRule{id: "TEST-SINGLE", pillar: model.PillarSecurity, severity: model.SeverityHigh}
 	crossRule := &mockCrossRule{id: "TEST-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh}
-	eng := NewWithRules(nil, []model.CrossResourceRule{crossRule})
-	assert.Len(t, eng.CrossRules(), 1)
-	assert.Equal(t, "TEST-CROSS", eng.CrossRules()[0].Metadata().ID)
+	eng := NewWithRules(FromRule(singleRule), FromCrossRule(crossRule))
+	require.Len(t, eng.Rules(), 2)
+	assert.Equal(t, "TEST-SINGLE", eng.Rules()[0].Metadata().ID)
+	assert.Equal(t, "TEST-CROSS", eng.Rules()[1].Metadata().ID)
 }
 
-func TestFilterCrossRules_ByPillar(t *testing.T) {
-	rules := []model.CrossResourceRule{
-		&mockCrossRule{id: "SEC-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh},
-		&mockCrossRule{id: "REL-CROSS", pillar: model.PillarReliability, severity: model.SeverityHigh},
+func TestFilterRules_CrossByPillar(t *testing.T) {
+	rules := []model.EvalRule{
+		FromCrossRule(&mockCrossRule{id: "SEC-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh}),
+		FromCrossRule(&mockCrossRule{id: "REL-CROSS", pillar: model.PillarReliability, severity: model.SeverityHigh}),
 	}
 
-	filtered := filterCrossRules(rules, Config{Pillars: []model.Pillar{model.PillarSecurity}})
+	filtered := filterRules(rules, Config{Pillars: []model.Pillar{model.PillarSecurity}})
 	assert.Len(t, filtered, 1)
 	assert.Equal(t, "SEC-CROSS", filtered[0].Metadata().ID)
 }
 
-func TestFilterCrossRules_ByMinSeverity(t *testing.T) {
-	rules := []model.CrossResourceRule{
-		&mockCrossRule{id: "HIGH-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh},
-		&mockCrossRule{id: "LOW-CROSS", pillar: model.PillarSecurity, severity: model.SeverityLow},
+func TestFilterRules_CrossByMinSeverity(t *testing.T) {
+	rules := []model.EvalRule{
+		FromCrossRule(&mockCrossRule{id: "HIGH-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh}),
+		FromCrossRule(&mockCrossRule{id: "LOW-CROSS", pillar: model.PillarSecurity, severity: model.SeverityLow}),
 	}
 
-	filtered := filterCrossRules(rules, Config{MinSeverity: model.SeverityHigh})
+	filtered := filterRules(rules, Config{MinSeverity: model.SeverityHigh})
 	assert.Len(t, filtered, 1)
 	assert.Equal(t, "HIGH-CROSS", filtered[0].Metadata().ID)
 }
 
-func TestFilterCrossRules_ByExcludeIDs(t *testing.T) {
-	rules := []model.CrossResourceRule{
-		&mockCrossRule{id: "CROSS-A", pillar: model.PillarSecurity, severity: model.SeverityHigh},
-		&mockCrossRule{id: "CROSS-B", pillar: model.PillarSecurity, severity: model.SeverityHigh},
+func TestFilterRules_CrossByExcludeIDs(t *testing.T) {
+	rules := []model.EvalRule{
+		FromCrossRule(&mockCrossRule{id: "CROSS-A", pillar: model.PillarSecurity, severity: model.SeverityHigh}),
+		FromCrossRule(&mockCrossRule{id: "CROSS-B", pillar: model.PillarSecurity, severity: model.SeverityHigh}),
 	}
 
-	filtered := filterCrossRules(rules, Config{ExcludeIDs: []string{"CROSS-A"}})
+	filtered := filterRules(rules, Config{ExcludeIDs: []string{"CROSS-A"}})
 	assert.Len(t, filtered, 1)
 	assert.Equal(t, "CROSS-B", filtered[0].Metadata().ID)
 }
+
+func TestStream_IndexesOnlyDeclaredTypes(t *testing.T) {
+	crossRule := &everyResourceCrossRule{mockCrossRule{id: "S3-CROSS", pillar: model.PillarSecurity, severity: model.SeverityHigh}}
+	eng := NewWithRules(FromCrossRule(crossRule))
+
+	s := eng.NewStream(context.Background())
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a"})
+	s.Add(model.TerraformResource{Type: "aws_instance", Name: "web"})
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket_public_access_block", Name: "a"})
+
+	assert.Equal(t, 3, s.Count())
+	var addrs []string
+	for _, f := range s.Finish() {
+		addrs = append(addrs, f.Resource)
+	}
+	assert.Equal(t, []string{"aws_s3_bucket.a", "aws_s3_bucket_public_access_block.a"}, addrs)
+}
+
+// versionsRule is an EvalRule reading only its resource and parameters. It
+// reports buckets keeping more versions than its max_versions parameter.
+type versionsRule struct{}
+
+func (r *versionsRule) Metadata() model.RuleMetadata {
+	return model.RuleMetadata{
+		ID:            "S3-VERSIONS",
+		ResourceTypes: []string{"aws_s3_bucket"},
+		Parameters:    []model.RuleParameter{{Name: "max_versions", Type: model.ParamInt, Default: "1"}},
+	}
+}
+
+func (r *versionsRule) Check(ctx *model.EvalContext) {
+	if ctx.Resources != nil {
+		ctx.Fail(fmt.Errorf("resource-only rule got the resource set"))
+		return
+	}
+	if v, ok := ctx.Resource.GetNumberAttr("versions"); ok && v > float64(ctx.IntParam("max_versions")) {
+		ctx.Report(ctx.Finding(ctx.Resource, "too many versions", ""))
+	}
+}
+
+func TestStream_ResourceOnlyRule(t *testing.T) {
+	crossRule := &everyResourceCrossRule{mockCrossRule{id: "S3-CROSS"}}
+	eng := NewWithRules(FromCrossRule(crossRule), ResourceOnly(&versionsRule{}))
+	eng.params = map[string]map[string]string{"S3-VERSIONS": {"max_versions": "2"}}
+
+	s := eng.NewStream(context.Background())
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a", Attributes: map[string]interface{}{"versions": float64(3)}})
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "b", Attributes: map[string]interface{}{"versions": float64(2)}})
+	s.Add(model.TerraformResource{Type: "aws_instance", Name: "web", Attributes: map[string]interface{}{"versions": float64(3)}})
+
+	var got []string
+	for _, f := range s.Finish() {
+		got = append(got, f.RuleID+" "+f.Resource)
+	}
+	assert.Equal(t, []string{
+		"S3-VERSIONS aws_s3_bucket.a",
+		"S3-CROSS aws_s3_bucket.a",
+		"S3-CROSS aws_s3_bucket.b",
+	}, got, "resource-only findings come in resource order, before the set rules'")
+	assert.Empty(t, s.Errors())
+
+	// Only the cross rule needs the resource set.
+	eng = NewWithRules(ResourceOnly(&versionsRule{}))
+	s = eng.NewStream(context.Background())
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a"})
+	assert.Empty(t, s.index)
+}
+
+// unblockedBucketRule reports buckets no public access block references.
+type unblockedBucketRule struct {
+	mockCrossRule
+}
+
+func (r *unblockedBucketRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	var findings []model.Finding
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if len(resources.Referrers(res, "aws_s3_bucket_public_access_block", "bucket")) == 0 {
+			findings = append(findings, model.Finding{RuleID: r.id, Resource: res.Address()})
+		}
+	}
+	return findings
+}
+
+func TestEngine_Analyze_ResourceSetReferences(t *testing.T) {
+	crossRule := &unblockedBucketRule{mockCrossRule{id: "S3-REF", pillar: model.PillarSecurity, severity: model.SeverityHigh}}
+	eng := NewWithRules(FromCrossRule(crossRule))
+
+	findings := eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "a"},
+		{Type: "aws_s3_bucket", Name: "b"},
+		{Type: "aws_s3_bucket_public_access_block", Name: "a", References: map[string][]string{"bucket": {"aws_s3_bucket.a"}}},
+	})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "aws_s3_bucket.b", findings[0].Resource)
+}
+
+// loggedBucketRule is an EvalRule reporting buckets no aws_s3_bucket_logging
+// references. The "remediation" parameter overrides its remediation text.
+type loggedBucketRule struct {
+	checked []string
+}
+
+func (r *loggedBucketRule) Metadata() model.RuleMetadata {
+	return model.RuleMetadata{
+		ID:            "S3-EVAL",
+		Name:          "Bucket Logging",
+		Severity:      model.SeverityMedium,
+		Pillar:        model.PillarOperationalExcellence,
+		ResourceTypes: []string{"aws_s3_bucket", "aws_s3_bucket_logging"},
+		DocURL:        "https://example.com/s3-eval",
+	}
+}
+
+func (r *loggedBucketRule) Check(ctx *model.EvalContext) {
+	r.checked = append(r.checked, ctx.Resource.Address())
+	if ctx.Resource.Type != "aws_s3_bucket" || len(ctx.Resources.Referrers(ctx.Resource, "aws_s3_bucket_logging", "bucket")) > 0 {
+		return
+	}
+	remediation := "Add an aws_s3_bucket_logging resource"
+	if v, ok := ctx.Params["remediation"]; ok {
+		remediation = v
+	}
+	ctx.Report(ctx.Finding(ctx.Resource, "Bucket has no access logging", remediation))
+}
+
+func TestEngine_Analyze_EvalRule(t *testing.T) {
+	rule := &loggedBucketRule{}
+	eng := NewWithRules(rule)
+
+	findings := eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "a", File: "main.tf", Line: 3},
+		{Type: "aws_s3_bucket", Name: "b", File: "main.tf", Line: 7},
+		{Type: "aws_instance", Name: "web"},
+		{Type: "aws_s3_bucket_logging", Name: "a", References: map[string][]string{"bucket": {"aws_s3_bucket.a"}}},
+	})
+
+	assert.Equal(t, []string{"aws_s3_bucket.a", "aws_s3_bucket.b", "aws_s3_bucket_logging.a"}, rule.checked)
+	require.Len(t, findings, 1)
+	assert.Equal(t, model.Finding{
+		RuleID:      "S3-EVAL",
+		RuleName:    "Bucket Logging",
+		Severity:    model.SeverityMedium,
+		Pillar:      model.PillarOperationalExcellence,
+		Resource:    "aws_s3_bucket.b",
+		File:        "main.tf",
+		Line:        7,
+		Description: "Bucket has no access logging",
+		Remediation: "Add an aws_s3_bucket_logging resource",
+		DocURL:      "https://example.com/s3-eval",
+	}, findings[0])
+}
+
+func TestEngine_Analyze_EvalRuleParams(t *testing.T) {
+	eng := NewWithRules(&loggedBucketRule{})
+	eng.params = map[string]map[string]string{"S3-EVAL": {"remediation": "Use the central logging module"}}
+
+	findings := eng.Analyze(context.Background(), []model.TerraformResource{{Type: "aws_s3_bucket", Name: "a"}})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Use the central logging module", findings[0].Remediation)
+}
+
+// paramRule reports every resource with the values of its parameters.
+type paramRule struct {
+	params []model.RuleParameter
+}
+
+func (r *paramRule) Metadata() model.RuleMetadata {
+	params := r.params
+	if params == nil {
+		params = []model.RuleParameter{
+			{Name: "days", Type: model.ParamInt, Default: "7"},
+			{Name: "ratio", Type: model.ParamNumber, Default: "0.5"},
+			{Name: "strict", Type: model.ParamBool, Default: "false"},
+			{Name: "prefix", Type: model.ParamString, Default: "prod-"},
+			{Name: "ports", Type: model.ParamIntList, Default: "22,3389"},
+			{Name: "regions", Type: model.ParamStringList, Default: "eu-west-1"},
+		}
+	}
+	return model.RuleMetadata{ID: "PARAM", ResourceTypes: []string{"aws_s3_bucket"}, Parameters: params}
+}
+
+func (r *paramRule) Check(ctx *model.EvalContext) {
+	ctx.Report(ctx.Finding(ctx.Resource, fmt.Sprintf("%d %g %t %s %v %v",
+		ctx.IntParam("days"),
+		ctx.NumberParam("ratio"),
+		ctx.BoolParam("strict"),
+		ctx.StringParam("prefix"),
+		ctx.IntListParam("ports"),
+		ctx.StringListParam("regions"),
+	), ""))
+}
+
+func TestCheckResource_Params(t *testing.T) {
+	bucket := model.TerraformResource{Type: "aws_s3_bucket", Name: "a"}
+
+	findings, err := CheckResource(&paramRule{}, bucket, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "7 0.5 false prod- [22 3389] [eu-west-1]", findings[0].Description)
+
+	findings, err = CheckResource(&paramRule{}, bucket, map[string]string{
+		"days":    "365",
+		"strict":  "true",
+		"ports":   "22, 9200,",
+		"regions": "eu-west-1,eu-central-1",
+	})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "365 0.5 true prod- [22 9200] [eu-west-1 eu-central-1]", findings[0].Description)
+
+	_, err = CheckResource(&paramRule{}, bucket, map[string]string{"days": "a week"})
+	assert.ErrorContains(t, err, "parameter days")
+}
+
+func TestValidateParams(t *testing.T) {
+	rules := []model.EvalRule{&paramRule{}, FromRule(&mockRule{id: "S3-001"})}
+
+	assert.NoError(t, ValidateParams(rules, nil))
+	assert.NoError(t, ValidateParams(rules, map[string]map[string]string{"PARAM": {"days": "30", "ports": "22,9200"}}))
+
+	tests := []struct {
+		name   string
+		rules  []model.EvalRule
+		params map[string]map[string]string
+		err    string
+	}{
+		{"unknown rule", rules, map[string]map[string]string{"S3-999": {"days": "1"}}, "parameters for unknown rule S3-999"},
+		{"unknown parameter", rules, map[string]map[string]string{"S3-001": {"days": "1"}}, "rule S3-001 has no parameter days"},
+		{"invalid value", rules, map[string]map[string]string{"PARAM": {"ports": "22,ssh"}}, `rule PARAM: parameter ports: "22,ssh" is not a valid int_list`},
+		{"invalid default", []model.EvalRule{&paramRule{params: []model.RuleParameter{{Name: "days", Type: model.ParamInt, Default: "seven"}}}}, nil, `rule PARAM: parameter days: default: "seven" is not a valid int`},
+		{"unknown type", []model.EvalRule{&paramRule{params: []model.RuleParameter{{Name: "days", Type: "duration", Default: "1h"}}}}, nil, `rule PARAM: parameter days: default: unknown parameter type "duration"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, ValidateParams(tt.rules, tt.params), tt.err)
+		})
+	}
+}
+
+func TestEngine_Analyze_EvalRuleChangedOnly(t *testing.T) {
+	eng := NewWithRules(&loggedBucketRule{})
+	eng.changedOnly = true
+
+	findings := eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "created", Action: model.ActionCreate},
+		{Type: "aws_s3_bucket", Name: "untouched", Action: model.ActionNoOp},
+	})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "aws_s3_bucket.created", findings[0].Resource)
+}
+
+func TestEngine_Analyze_ParallelMatchesSequential(t *testing.T) {
+	rules := []model.EvalRule{
+		FromRule(&mockRule{id: "S3-A", resourceTypes: []string{"aws_s3_bucket"}}),
+		FromRule(&attrRule{mockRule{id: "S3-B", resourceTypes: []string{"aws_s3_bucket"}}, "versioned"}),
+		FromRule(&mockRule{id: "EC2-A", resourceTypes: []string{"aws_instance"}}),
+		FromCrossRule(&everyResourceCrossRule{mockCrossRule{id: "S3-CROSS"}}),
+		FromCrossRule(&unblockedBucketRule{mockCrossRule{id: "S3-REF"}}),
+		&loggedBucketRule{},
+	}
+
+	var resources []model.TerraformResource
+	for i := 0; i < 200; i++ {
+		before := model.TerraformResource{Type: "aws_s3_bucket", Name: fmt.Sprintf("b%d", i), Attributes: map[string]interface{}{"versioned": true}}
+		resources = append(resources,
+			model.TerraformResource{Type: "aws_s3_bucket", Name: fmt.Sprintf("b%d", i), Action: model.ActionUpdate, Before: &before, Attributes: map[string]interface{}{"versioned": i%3 == 0}},
+			model.TerraformResource{Type: "aws_instance", Name: fmt.Sprintf("i%d", i), Action: model.ActionCreate},
+		)
+		if i%2 == 0 {
+			resources = append(resources, model.TerraformResource{
+				Type: "aws_s3_bucket_public_access_block", Name: fmt.Sprintf("b%d", i), Action: model.ActionCreate,
+				References: map[string][]string{"bucket": {fmt.Sprintf("aws_s3_bucket.b%d", i)}},
+			})
+		}
+	}
+
+	sequential := NewWithRules(rules...)
+	sequential.regressions = true
+	want := sequential.Analyze(context.Background(), resources)
+	require.NotEmpty(t, want)
+
+	for _, parallelism := range []int{2, 8} {
+		eng := NewWithRules(rules...)
+		eng.regressions = true
+		eng.sem = newSemaphore(parallelism)
+		assert.Equal(t, parallelism, eng.Parallelism())
+		for run := 0; run < 5; run++ {
+			assert.Equal(t, want, eng.Analyze(context.Background(), resources), "parallelism %d, run %d", parallelism, run)
+		}
+	}
+}
+
+// panicRule panics on every resource, as a rule asserting an attribute shape
+// a provider does not return would.
+type panicRule struct {
+	mockRule
+}
+
+func (r *panicRule) Evaluate(resource model.TerraformResource) []model.Finding {
+	_ = resource.Attributes["tags"].([]interface{})
+	return nil
+}
+
+type panicCrossRule struct {
+	mockCrossRule
+}
+
+func (r *panicCrossRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	panic("index out of range")
+}
+
+// failingRule reports a finding, then fails on buckets without a name.
+type failingRule struct{}
+
+func (r *failingRule) Metadata() model.RuleMetadata {
+	return model.RuleMetadata{ID: "S3-FAIL", ResourceTypes: []string{"aws_s3_bucket"}}
+}
+
+func (r *failingRule) Check(ctx *model.EvalContext) {
+	ctx.Report(ctx.Finding(ctx.Resource, "partial", ""))
+	if _, ok := ctx.Resource.GetStringAttr("bucket"); !ok {
+		ctx.Fail(errors.New("bucket is not a string"))
+	}
+}
+
+func TestStream_Errors(t *testing.T) {
+	for _, parallelism := range []int{1, 4} {
+		eng := NewWithRules(
+			FromRule(&panicRule{mockRule{id: "S3-PANIC", resourceTypes: []string{"aws_s3_bucket"}}}),
+			FromRule(&mockRule{id: "S3-OK", resourceTypes: []string{"aws_s3_bucket"}}),
+			FromCrossRule(&panicCrossRule{mockCrossRule{id: "S3-CROSS-PANIC"}}),
+			FromCrossRule(&mockCrossRule{id: "S3-CROSS-OK"}),
+			&failingRule{},
+		)
+		eng.sem = newSemaphore(parallelism)
+
+		s := eng.NewStream(context.Background())
+		s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a", Attributes: map[string]interface{}{"tags": map[string]interface{}{}}})
+		s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "b", Attributes: map[string]interface{}{"bucket": "b", "tags": map[string]interface{}{}}})
+
+		var got []string
+		for _, f := range s.Finish() {
+			got = append(got, f.RuleID+" "+f.Resource)
+		}
+		assert.Equal(t, []string{
+			"S3-OK aws_s3_bucket.a",
+			"S3-OK aws_s3_bucket.b",
+			"S3-CROSS-OK cross-check",
+			"S3-FAIL aws_s3_bucket.b",
+		}, got, "parallelism %d", parallelism)
+
+		errs := s.Errors()
+		require.Len(t, errs, 4)
+		assert.Equal(t, model.EngineError{RuleID: "S3-CROSS-PANIC", Message: "panic: index out of range", Trace: errs[0].Trace}, errs[0])
+		assert.Contains(t, errs[0].Trace, "EvaluateAll")
+		assert.Equal(t, model.EngineError{RuleID: "S3-FAIL", Resource: "aws_s3_bucket.a", Message: "bucket is not a string"}, errs[1])
+		assert.Equal(t, "S3-PANIC", errs[2].RuleID)
+		assert.Equal(t, "aws_s3_bucket.a", errs[2].Resource)
+		assert.Contains(t, errs[2].Message, "panic: interface conversion")
+		assert.Equal(t, "aws_s3_bucket.b", errs[3].Resource)
+	}
+}
+
+func TestStream_Errors_Regressions(t *testing.T) {
+	eng := NewWithRules(FromRule(&panicRule{mockRule{id: "S3-PANIC", resourceTypes: []string{"aws_s3_bucket"}}}))
+	eng.regressions = true
+
+	before := model.TerraformResource{Type: "aws_s3_bucket", Name: "a"}
+	s := eng.NewStream(context.Background())
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a", Action: model.ActionUpdate, Before: &before})
+
+	assert.Empty(t, s.Finish())
+	assert.Len(t, s.Errors(), 1, "failures on the prior version are not engine errors")
+}
+
+func TestStream_ProfileRegressions(t *testing.T) {
+	rules := []model.EvalRule{
+		FromRule(&attrRule{mockRule{id: "RDS-ENC", resourceTypes: []string{"aws_db_instance"}}, "storage_encrypted"}),
+		FromCrossRule(&everyResourceCrossRule{mockCrossRule{id: "S3-CROSS"}}),
+	}
+	eng := NewWithRules(rules...)
+	eng.regressions = true
+	eng.profile = newProfile(rules)
+
+	db := model.TerraformResource{Type: "aws_db_instance", Name: "main", Attributes: map[string]interface{}{"storage_encrypted": true}}
+	bucket := model.TerraformResource{Type: "aws_s3_bucket", Name: "a", Attributes: map[string]interface{}{"bucket": "old"}}
+	eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_db_instance", Name: "main", Action: model.ActionUpdate, Before: &db},
+		{Type: "aws_s3_bucket", Name: "a", Action: model.ActionUpdate, Before: &bucket},
+	})
+
+	evaluations := make(map[string]int)
+	for _, p := range eng.Profile() {
+		evaluations[p.RuleID] = p.Evaluations
+	}
+	assert.Equal(t, map[string]int{"RDS-ENC": 1, "S3-CROSS": 1}, evaluations, "prior versions are not profiled")
+}
+
+// slowRule blocks on resources named "slow": for delay, or, when it honours
+// its context, until the context is done.
+type slowRule struct {
+	delay      time.Duration
+	honoursCtx bool
+}
+
+func (r *slowRule) Metadata() model.RuleMetadata {
+	return model.RuleMetadata{ID: "SLOW", ResourceTypes: []string{"aws_s3_bucket"}}
+}
+
+func (r *slowRule) Check(ctx *model.EvalContext) {
+	if ctx.Resource.Name == "slow" {
+		if r.honoursCtx {
+			<-ctx.Context().Done()
+		} else {
+			time.Sleep(r.delay)
+		}
+	}
+	ctx.Report(ctx.Finding(ctx.Resource, "checked", ""))
+}
+
+func TestEngine_RuleTimeout(t *testing.T) {
+	for _, honoursCtx := range []bool{false, true} {
+		rule := &slowRule{delay: 50 * time.Millisecond, honoursCtx: honoursCtx}
+		eng := NewWithRules(rule, FromRule(&mockRule{id: "S3-OK", resourceTypes: []string{"aws_s3_bucket"}}))
+		eng.ruleTimeout = 20 * time.Millisecond
+
+		s := eng.NewStream(context.Background())
+		s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "slow"})
+		s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "fast"})
+
+		var got []string
+		for _, f := range s.Finish() {
+			got = append(got, f.RuleID+" "+f.Resource)
+		}
+		assert.Equal(t, []string{"S3-OK aws_s3_bucket.slow", "S3-OK aws_s3_bucket.fast", "SLOW aws_s3_bucket.fast"}, got)
+		assert.Equal(t, []model.EngineError{{
+			RuleID:   "SLOW",
+			Resource: "aws_s3_bucket.slow",
+			Message:  "timed out after 20ms",
+			TimedOut: true,
+		}}, s.Errors(), "honoursCtx %v", honoursCtx)
+	}
+}
+
+func TestEngine_Analyze_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	eng := NewWithRules(
+		FromRule(&mockRule{id: "S3-OK", resourceTypes: []string{"aws_s3_bucket"}}),
+		FromCrossRule(&mockCrossRule{id: "S3-CROSS"}),
+		&loggedBucketRule{},
+	)
+	s := eng.NewStream(ctx)
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "a"})
+	assert.Empty(t, s.Finish())
+	assert.Empty(t, s.Errors())
+	assert.Equal(t, 1, s.Count())
+}
+
+func TestEngine_RuleTimeout_CancelledRunIsNotATimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rule := &slowRule{honoursCtx: true}
+	eng := NewWithRules(rule)
+	eng.ruleTimeout = time.Minute
+
+	s := eng.NewStream(ctx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "slow"})
+	assert.Empty(t, s.Finish())
+	assert.Empty(t, s.Errors())
+}
+
+func TestAwaitRule_ResultAtDeadline(t *testing.T) {
+	start := time.Now()
+	budget, cancel := context.WithDeadline(context.Background(), start.Add(time.Millisecond))
+	defer cancel()
+	<-budget.Done()
+
+	// The budget has run out, but the rule finished within it: its result is
+	// kept whichever the wait sees first.
+	for i := 0; i < 20; i++ {
+		done := make(chan ruleResult, 1)
+		done <- ruleResult{findings: []model.Finding{{RuleID: "S3-A"}}, finished: start}
+		r, finished := awaitRule(budget, done)
+		assert.True(t, finished)
+		assert.Len(t, r.findings, 1)
+	}
+
+	done := make(chan ruleResult, 1)
+	done <- ruleResult{findings: []model.Finding{{RuleID: "S3-A"}}, finished: time.Now()}
+	_, finished := awaitRule(budget, done)
+	assert.False(t, finished, "a rule that finished after its budget timed out")
+
+	_, finished = awaitRule(budget, make(chan ruleResult, 1))
+	assert.False(t, finished)
+}
+
+// stuckRule never returns from Check on resources named "stuck", whatever
+// its context, until release is closed.
+type stuckRule struct {
+	release chan struct{}
+}
+
+func (r *stuckRule) Metadata() model.RuleMetadata {
+	return model.RuleMetadata{ID: "STUCK", ResourceTypes: []string{"aws_s3_bucket"}}
+}
+
+func (r *stuckRule) Check(ctx *model.EvalContext) {
+	if ctx.Resource.Name == "stuck" {
+		<-r.release
+	}
+	ctx.Report(ctx.Finding(ctx.Resource, "checked", ""))
+}
+
+func TestEngine_RuleTimeout_RuleIgnoringContext(t *testing.T) {
+	rule := &stuckRule{release: make(chan struct{})}
+	t.Cleanup(func() { close(rule.release) })
+	eng := NewWithRules(ResourceOnly(rule))
+	eng.ruleTimeout = 50 * time.Millisecond
+	eng.sem = newSemaphore(1)
+
+	s := eng.NewStream(context.Background())
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "stuck"})
+	s.Add(model.TerraformResource{Type: "aws_s3_bucket", Name: "fast"})
+
+	var got []string
+	for _, f := range s.Finish() {
+		got = append(got, f.Resource)
+	}
+	assert.Equal(t, []string{"aws_s3_bucket.fast"}, got, "the stuck rule's slot is released")
+	require.Len(t, s.Errors(), 1)
+	assert.True(t, s.Errors()[0].TimedOut)
+}
+
+func TestEngine_Analyze_CancelledWhileRuleIgnoresContext(t *testing.T) {
+	rule := &stuckRule{release: make(chan struct{})}
+	t.Cleanup(func() { close(rule.release) })
+	// No rule budget: only the run's context bounds the evaluation.
+	eng := NewWithRules(ResourceOnly(rule))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan []model.Finding)
+	go func() {
+		done <- eng.Analyze(ctx, []model.TerraformResource{{Type: "aws_s3_bucket", Name: "stuck"}})
+	}()
+	select {
+	case findings := <-done:
+		assert.Empty(t, findings)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Analyze did not return once its context was done")
+	}
+}
+
+func TestEngine_Profile(t *testing.T) {
+	rules := []model.EvalRule{
+		FromRule(&mockRule{id: "S3-A", resourceTypes: []string{"aws_s3_bucket"}}),
+		FromRule(&mockRule{id: "EC2-A", resourceTypes: []string{"aws_instance"}}),
+		FromCrossRule(&everyResourceCrossRule{mockCrossRule{id: "S3-CROSS"}}),
+		&loggedBucketRule{},
+	}
+	eng := NewWithRules(rules...)
+	assert.Nil(t, eng.Profile(), "profiling is off by default")
+
+	eng.profile = newProfile(rules)
+	eng.sem = newSemaphore(4)
+	eng.Analyze(context.Background(), []model.TerraformResource{
+		{Type: "aws_s3_bucket", Name: "a"},
+		{Type: "aws_s3_bucket", Name: "b"},
+		{Type: "aws_s3_bucket_public_access_block", Name: "a"},
+	})
+
+	profiles := make(map[string]model.RuleProfile)
+	for _, p := range eng.Profile() {
+		assert.Positive(t, p.WallTime, p.RuleID)
+		p.WallTime = 0
+		profiles[p.RuleID] = p
+	}
+	assert.Equal(t, map[string]model.RuleProfile{
+		"S3-A":     {RuleID: "S3-A", Kind: "single", Evaluations: 2, Findings: 2},
+		"S3-CROSS": {RuleID: "S3-CROSS", Kind: "cross", Evaluations: 1, Findings: 3},
+		"S3-EVAL":  {RuleID: "S3-EVAL", Kind: "eval", Evaluations: 2, Findings: 2},
+	}, profiles, "rules never evaluated are left out")
+}
+
+func TestEngine_ProfileOverriddenRules(t *testing.T) {
+	eng := New(Config{
+		ExtraRules: []model.EvalRule{
+			FromRule(&mockRule{id: "S3-A", resourceTypes: []string{"aws_s3_bucket"}}),
+			FromCrossRule(&everyResourceCrossRule{mockCrossRule{id: "S3-CROSS"}}),
+		},
+		Overrides: []model.RuleOverride{{RuleID: "S3-*", Severity: model.SeverityHigh}},
+		Profile:   true,
+	})
+	eng.Analyze(context.Background(), []model.TerraformResource{{Type: "aws_s3_bucket", Name: "a"}})
+
+	kinds := make(map[string]string)
+	for _, p := range eng.Profile() {
+		kinds[p.RuleID] = p.Kind
+	}
+	assert.Equal(t, "single", kinds["S3-A"])
+	assert.Equal(t, "cross", kinds["S3-CROSS"])
+}
+
+// BenchmarkEngine_RuleTimeout compares evaluating without a rule time budget
+// and within one, which adds a timer context and a goroutine per evaluation.
+func BenchmarkEngine_RuleTimeout(b *testing.B) {
+	resources := make([]model.TerraformResource, 100)
+	for i := range resources {
+		resources[i] = model.TerraformResource{Type: "aws_s3_bucket", Name: fmt.Sprintf("b%d", i)}
+	}
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		b.Run(fmt.Sprintf("timeout=%s", timeout), func(b *testing.B) {
+			eng := NewWithRules(FromRule(&mockRule{id: "S3-OK", resourceTypes: []string{"aws_s3_bucket"}}))
+			eng.ruleTimeout = timeout
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				eng.Analyze(context.Background(), resources)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// ApplyOverrides returns rules with overrides applied, in order, to the
+// metadata of the rules they match, so a later override wins over an earlier
+// one. Findings of an overridden rule take its new severity and pillar.
+func ApplyOverrides(rules []model.EvalRule, overrides []model.RuleOverride) []model.EvalRule {
+	if len(overrides) == 0 {
+		return rules
+	}
+	result := make([]model.EvalRule, len(rules))
+	for i, r := range rules {
+		result[i] = r
+		meta := r.Metadata()
+		overridden := overriddenRule{EvalRule: r}
+		for _, o := range overrides {
+			if o.Matches(meta.ID) {
+				meta = o.Apply(meta)
+				overridden.severity = overridden.severity || o.Severity != ""
+				overridden.pillar = overridden.pillar || o.Pillar != ""
+			}
+		}
+		if overridden.severity || overridden.pillar {
+			overridden.meta = meta
+			result[i] = overridden
+		}
+	}
+	return result
+}
+
+// ValidateOverrides checks that every override matches at least one of rules,
+// to catch misspelt rule IDs.
+func ValidateOverrides(rules []model.EvalRule, overrides []model.RuleOverride) error {
+	for i, o := range overrides {
+		matched := false
+		for _, r := range rules {
+			if o.Matches(r.Metadata().ID) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("override[%d]: %s matches no rule", i, o.RuleID)
+		}
+	}
+	return nil
+}
+
+// overriddenRule is a rule whose severity or pillar is overridden.
+type overriddenRule struct {
+	model.EvalRule
+	meta     model.RuleMetadata
+	severity bool // whether the severity is overridden
+	pillar   bool // whether the pillar is overridden
+}
+
+func (r overriddenRule) Metadata() model.RuleMetadata {
+	return r.meta
+}
+
+// apply gives findings of the rule its overridden severity and pillar, as
+// rules may set them on findings themselves.
+func (r overriddenRule) apply(findings []model.Finding) {
+	for i := range findings {
+		if r.severity {
+			findings[i].Severity = r.meta.Severity
+		}
+		if r.pillar {
+			findings[i].Pillar = r.meta.Pillar
+		}
+	}
+}
+
+// unwrap returns the rule an overridden rule wraps, or rule itself.
+func unwrap(rule model.EvalRule) model.EvalRule {
+	if r, ok := rule.(overriddenRule); ok {
+		return r.EvalRule
+	}
+	return rule
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// ValidateParams checks the parameters rules declare and the values params
+// configures for them, keyed by rule ID and then by parameter name: every
+// configured rule and parameter must be declared, and every default and
+// configured value must have the parameter's type.
+func ValidateParams(rules []model.EvalRule, params map[string]map[string]string) error {
+	metas := make(map[string]model.RuleMetadata, len(rules))
+	for _, r := range rules {
+		meta := r.Metadata()
+		metas[meta.ID] = meta
+		for _, p := range meta.Parameters {
+			if err := p.Type.Validate(p.Default); err != nil {
+				return fmt.Errorf("rule %s: parameter %s: default: %w", meta.ID, p.Name, err)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(params))
+	for id := range params {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		meta, ok := metas[id]
+		if !ok {
+			return fmt.Errorf("parameters for unknown rule %s", id)
+		}
+		names := make([]string, 0, len(params[id]))
+		for name := range params[id] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p, ok := meta.Param(name)
+			if !ok {
+				return fmt.Errorf("rule %s has no parameter %s", id, name)
+			}
+			if err := p.Type.Validate(params[id][name]); err != nil {
+				return fmt.Errorf("rule %s: parameter %s: %w", id, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckResource runs rule against a single resource, with no other resources
+// and the given parameters, and returns its findings or the error it failed
+// with. It is meant for testing rules.
+func CheckResource(rule model.EvalRule, resource model.TerraformResource, params map[string]string) ([]model.Finding, error) {
+	ctx := model.NewEvalContext(context.Background(), rule.Metadata(), resource, model.NewResourceSet([]model.TerraformResource{resource}), params)
+	rule.Check(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ctx.Findings(), nil
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// ruleStats accumulates the evaluations of one rule. Streams update it
+// concurrently.
+type ruleStats struct {
+	kind        string
+	evaluations atomic.Int64
+	findings    atomic.Int64
+	wallTime    atomic.Int64 // nanoseconds
+}
+
+func (s *ruleStats) record(d time.Duration, findings int) {
+	s.evaluations.Add(1)
+	s.findings.Add(int64(findings))
+	s.wallTime.Add(int64(d))
+}
+
+// newProfile returns empty stats for every rule, keyed by rule ID.
+func newProfile(rules []model.EvalRule) map[string]*ruleStats {
+	profile := make(map[string]*ruleStats, len(rules))
+	for _, r := range rules {
+		profile[r.Metadata().ID] = &ruleStats{kind: ruleKind(r)}
+	}
+	return profile
+}
+
+// ruleKind names the interface a rule implements, looking through overrides.
+func ruleKind(r model.EvalRule) string {
+	switch unwrap(r).(type) {
+	case ruleAdapter:
+		return "single"
+	case crossRuleAdapter:
+		return "cross"
+	default:
+		return "eval"
+	}
+}
+
+// Profile returns the time each rule evaluated so far has taken, slowest
+// first. It returns nil unless the engine was created with Config.Profile.
+func (e *Engine) Profile() []model.RuleProfile {
+	var profiles []model.RuleProfile
+	for id, s := range e.profile {
+		if s.evaluations.Load() == 0 {
+			continue
+		}
+		profiles = append(profiles, model.RuleProfile{
+			RuleID:      id,
+			Kind:        s.kind,
+			Evaluations: int(s.evaluations.Load()),
+			Findings:    int(s.findings.Load()),
+			WallTime:    time.Duration(s.wallTime.Load()),
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].WallTime != profiles[j].WallTime {
+			return profiles[i].WallTime > profiles[j].WallTime
+		}
+		return profiles[i].RuleID < profiles[j].RuleID
+	})
+	return profiles
+}
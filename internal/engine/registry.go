@@ -2,26 +2,32 @@ package engine
 
 import "github.com/ilijad1/well-architected-terraform/internal/model"
 
-var globalRegistry []model.Rule
-var globalCrossRegistry []model.CrossResourceRule
+var globalRegistry []model.EvalRule
 
 // Register adds a single-resource rule to the global registry. Called from init() in each rule package.
 func Register(r model.Rule) {
-	globalRegistry = append(globalRegistry, r)
+	globalRegistry = append(globalRegistry, FromRule(r))
 }
 
 // RegisterCross adds a cross-resource rule to the global registry. Called from init() in rule packages
 // that need to evaluate findings across the full set of resources.
 func RegisterCross(r model.CrossResourceRule) {
-	globalCrossRegistry = append(globalCrossRegistry, r)
+	globalRegistry = append(globalRegistry, FromCrossRule(r))
 }
 
-// AllRules returns all registered single-resource rules.
-func AllRules() []model.Rule {
-	return globalRegistry
+// RegisterEval adds a rule implementing the EvalRule contract to the global registry. Called from init()
+// in rule packages.
+func RegisterEval(r model.EvalRule) {
+	globalRegistry = append(globalRegistry, r)
 }
 
-// AllCrossRules returns all registered cross-resource rules.
-func AllCrossRules() []model.CrossResourceRule {
-	return globalCrossRegistry
+// RegisterResourceEval adds an EvalRule that reads only the resource it checks and its parameters, never
+// ctx.Resources, to the global registry. Such rules are evaluated as each resource streams in.
+func RegisterResourceEval(r model.EvalRule) {
+	globalRegistry = append(globalRegistry, ResourceOnly(r))
+}
+
+// AllRules returns all registered rules in registration order.
+func AllRules() []model.EvalRule {
+	return globalRegistry
 }
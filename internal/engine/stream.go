@@ -0,0 +1,328 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// Stream evaluates resources one at a time, so callers never need to hold
+// the full resource set in memory. Single-resource rules, and EvalRules marked
+// ResourceOnly, run as each resource is added. Every other rule runs when the
+// stream is finished, against a ResourceSet of only the resource types the
+// rules declare in their metadata or as referenced types.
+// When the engine evaluates in parallel, Add hands each resource to a worker
+// and Finish waits for them, so Finish must be called once every resource is
+// added.
+type Stream struct {
+	ctx         context.Context
+	e           *Engine
+	rulesByType map[string][]model.EvalRule // rules adapted from model.Rule or marked ResourceOnly
+	setRules    []model.EvalRule            // rules that need the ResourceSet
+	setTypes    map[string]bool
+	indexAll    bool
+
+	count int
+	index []model.TerraformResource
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	pending []resourceFindings  // single-resource findings, guarded by mu
+	errors  []model.EngineError // guarded by mu
+
+	changed map[string]bool // addresses the plan creates, updates or replaces
+	prior   []model.TerraformResource
+	updated map[string]bool // addresses with a prior version
+}
+
+// resourceFindings are the single-resource findings of the seq-th resource
+// added to a stream.
+type resourceFindings struct {
+	seq      int
+	findings []model.Finding
+}
+
+// NewStream starts a streaming evaluation. Once ctx is done the stream
+// evaluates no further rules; its findings are then incomplete.
+func (e *Engine) NewStream(ctx context.Context) *Stream {
+	s := &Stream{
+		ctx:         ctx,
+		e:           e,
+		rulesByType: make(map[string][]model.EvalRule),
+		setTypes:    make(map[string]bool),
+		changed:     make(map[string]bool),
+		updated:     make(map[string]bool),
+	}
+	for _, r := range e.rules {
+		types := r.Metadata().ResourceTypes
+		switch unwrap(r).(type) {
+		case ruleAdapter, resourceOnlyRule:
+			for _, rt := range types {
+				s.rulesByType[rt] = append(s.rulesByType[rt], r)
+			}
+			continue
+		}
+		s.setRules = append(s.setRules, r)
+		if len(types) == 0 {
+			// A rule that declares no types may read any resource.
+			s.indexAll = true
+		}
+		for _, rt := range types {
+			s.setTypes[rt] = true
+		}
+		if ref, ok := unwrap(r).(model.ReferencingRule); ok {
+			for _, rt := range ref.ReferencedTypes() {
+				s.setTypes[rt] = true
+			}
+		}
+	}
+	return s
+}
+
+// Add evaluates one resource against the single-resource rules and, if another
+// rule reads its type, adds it to the ResourceSet index.
+func (s *Stream) Add(resource model.TerraformResource) {
+	s.count++
+	addr := resource.Address()
+	if s.e.changedOnly && resource.IsChanged() {
+		s.changed[addr] = true
+	}
+
+	if len(s.rulesByType[resource.Type]) > 0 && (!s.e.changedOnly || resource.IsChanged()) {
+		seq, res := s.count, resource
+		s.e.run(&s.wg, func() {
+			if findings := s.evaluate(res); len(findings) > 0 {
+				s.mu.Lock()
+				s.pending = append(s.pending, resourceFindings{seq: seq, findings: findings})
+				s.mu.Unlock()
+			}
+		})
+	}
+
+	if !s.indexAll && !s.setTypes[resource.Type] {
+		return
+	}
+	if s.e.regressions {
+		switch {
+		case resource.Before != nil:
+			s.updated[addr] = true
+			s.prior = append(s.prior, *resource.Before)
+		case resource.Action != model.ActionCreate:
+			s.prior = append(s.prior, resource)
+		}
+		// The prior version is all regression checks need from it.
+		resource.Before = nil
+	}
+	s.index = append(s.index, resource)
+}
+
+// Count returns the number of resources added to the stream.
+func (s *Stream) Count() int {
+	return s.count
+}
+
+// Finish runs the rules that need the ResourceSet and returns every finding.
+// With ChangedOnly, their findings are kept only for changed resources.
+// Findings are returned in the same order whatever the parallelism: those of
+// single-resource rules in the order resources were added, then those of the
+// other rules in rule order.
+func (s *Stream) Finish() []model.Finding {
+	s.wg.Wait()
+	sort.Slice(s.pending, func(i, j int) bool { return s.pending[i].seq < s.pending[j].seq })
+	var findings []model.Finding
+	for _, p := range s.pending {
+		findings = append(findings, p.findings...)
+	}
+
+	set := model.NewResourceSet(s.index)
+	crossFindings := s.checkSetRules(set)
+	if s.e.changedOnly {
+		crossFindings = s.changedFindings(crossFindings)
+	}
+
+	if s.e.regressions && len(s.updated) > 0 {
+		prior := s.checkPriorSet(model.NewResourceSet(s.prior))
+		for i, f := range crossFindings {
+			if s.updated[f.Resource] {
+				prior.markRegressions(crossFindings[i : i+1])
+			}
+		}
+	}
+
+	return append(findings, crossFindings...)
+}
+
+// check runs one rule against one resource and records any engine error.
+func (s *Stream) check(rule model.EvalRule, meta model.RuleMetadata, resource model.TerraformResource, resources *model.ResourceSet) []model.Finding {
+	findings, err := s.e.check(s.ctx, rule, meta, resource, resources)
+	if err != nil {
+		s.mu.Lock()
+		s.errors = append(s.errors, *err)
+		s.mu.Unlock()
+	}
+	return findings
+}
+
+// checkPrior runs one rule against the prior version of a resource and adds
+// what it reports to prior. Prior versions are only compared against: their
+// evaluations are not profiled and their failures are not engine errors.
+func (s *Stream) checkPrior(rule model.EvalRule, meta model.RuleMetadata, resource model.TerraformResource, resources *model.ResourceSet, prior *priorFindings) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	findings, err := s.e.checkWithin(s.ctx, rule, meta, resource, resources)
+	prior.mu.Lock()
+	defer prior.mu.Unlock()
+	if err != nil {
+		prior.failed[meta.ID+"|"+err.Resource] = true
+		return
+	}
+	for _, f := range findings {
+		prior.keys[findingKey(f)] = true
+	}
+}
+
+// Errors returns the rule evaluations that panicked or failed, sorted by rule
+// ID, resource and message. Call it after Finish.
+func (s *Stream) Errors() []model.EngineError {
+	sort.Slice(s.errors, func(i, j int) bool {
+		a, b := s.errors[i], s.errors[j]
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Message < b.Message
+	})
+	return s.errors
+}
+
+// evaluate runs the single-resource rules against a resource and, with
+// Regressions, marks the findings its prior version does not have.
+func (s *Stream) evaluate(resource model.TerraformResource) []model.Finding {
+	var findings []model.Finding
+	for _, rule := range s.rulesByType[resource.Type] {
+		findings = append(findings, s.check(rule, rule.Metadata(), resource, nil)...)
+	}
+	if s.e.regressions && resource.Before != nil {
+		s.evaluateBefore(*resource.Before).markRegressions(findings)
+	}
+	return findings
+}
+
+// checkSetRules runs every rule that needs the ResourceSet against set and
+// returns their findings in rule order.
+func (s *Stream) checkSetRules(set *model.ResourceSet) []model.Finding {
+	results := make([][]model.Finding, len(s.setRules))
+	var wg sync.WaitGroup
+	for i, rule := range s.setRules {
+		s.e.run(&wg, func() {
+			results[i] = s.checkSet(rule, set)
+		})
+	}
+	wg.Wait()
+
+	var findings []model.Finding
+	for _, r := range results {
+		findings = append(findings, r...)
+	}
+	return findings
+}
+
+// checkSet runs a rule against a ResourceSet. A rule adapted from
+// model.CrossResourceRule is checked once; any other rule is checked against
+// each resource of its declared types, or of every type if it declares none.
+func (s *Stream) checkSet(rule model.EvalRule, set *model.ResourceSet) []model.Finding {
+	meta := rule.Metadata()
+	if _, ok := unwrap(rule).(crossRuleAdapter); ok {
+		return s.check(rule, meta, model.TerraformResource{}, set)
+	}
+
+	var findings []model.Finding
+	for _, res := range setResources(meta, set) {
+		findings = append(findings, s.check(rule, meta, res, set)...)
+	}
+	return findings
+}
+
+// setResources returns the resources of set a rule is checked against: those
+// of its declared types, or all of them if it declares none.
+func setResources(meta model.RuleMetadata, set *model.ResourceSet) []model.TerraformResource {
+	if len(meta.ResourceTypes) > 0 {
+		return set.OfType(meta.ResourceTypes...)
+	}
+	return set.All()
+}
+
+// evaluateBefore runs the single-resource rules against a resource's prior
+// version.
+func (s *Stream) evaluateBefore(before model.TerraformResource) *priorFindings {
+	prior := newPriorFindings()
+	for _, rule := range s.rulesByType[before.Type] {
+		s.checkPrior(rule, rule.Metadata(), before, nil, prior)
+	}
+	return prior
+}
+
+// checkPriorSet runs every rule that needs the ResourceSet against the prior
+// versions of the resources.
+func (s *Stream) checkPriorSet(set *model.ResourceSet) *priorFindings {
+	prior := newPriorFindings()
+	var wg sync.WaitGroup
+	for _, rule := range s.setRules {
+		s.e.run(&wg, func() {
+			meta := rule.Metadata()
+			if _, ok := unwrap(rule).(crossRuleAdapter); ok {
+				s.checkPrior(rule, meta, model.TerraformResource{}, set, prior)
+				return
+			}
+			for _, res := range setResources(meta, set) {
+				s.checkPrior(rule, meta, res, set, prior)
+			}
+		})
+	}
+	wg.Wait()
+	return prior
+}
+
+// priorFindings is what the prior versions of resources report.
+type priorFindings struct {
+	mu     sync.Mutex
+	keys   map[string]bool // findingKey of each finding
+	failed map[string]bool // rule ID and resource of each failed evaluation
+}
+
+func newPriorFindings() *priorFindings {
+	return &priorFindings{keys: make(map[string]bool), failed: make(map[string]bool)}
+}
+
+// markRegressions marks findings that are not among the prior versions'
+// findings. A finding whose rule failed on the prior version cannot be
+// compared and is left unmarked.
+func (p *priorFindings) markRegressions(findings []model.Finding) {
+	for i, f := range findings {
+		key := findingKey(f)
+		if f.Undetermined || p.keys[key] || p.failed[key] || p.failed[f.RuleID+"|"] {
+			continue
+		}
+		findings[i].Regression = true
+	}
+}
+
+// changedFindings keeps the findings reported against resources the plan changes.
+func (s *Stream) changedFindings(findings []model.Finding) []model.Finding {
+	var kept []model.Finding
+	for _, f := range findings {
+		if s.changed[f.Resource] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func findingKey(f model.Finding) string {
+	return f.RuleID + "|" + f.Resource
+}
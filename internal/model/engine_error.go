@@ -0,0 +1,20 @@
+package model
+
+// EngineError records a rule evaluation that panicked, reported an internal
+// error or ran out of time instead of completing. Findings of a failed
+// evaluation are discarded.
+type EngineError struct {
+	RuleID string `json:"rule_id"`
+	// Resource is the address of the resource being checked. It is empty for
+	// rules checked once against every resource, such as cross-resource rules.
+	Resource string `json:"resource,omitempty"`
+	Message  string `json:"message"`
+	// Trace is the goroutine stack trace of a panic.
+	Trace string `json:"trace,omitempty"`
+	// TimedOut is set when the evaluation went over the rule time budget.
+	TimedOut bool `json:"timed_out,omitempty"`
+
+	// Stack names the plan, state or source being analyzed when a run
+	// analyzes several stacks. It is empty for single-stack runs.
+	Stack string `json:"stack,omitempty"`
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EvalContext is what an EvalRule receives for each evaluation.
+type EvalContext struct {
+	// Resource is the resource being checked.
+	Resource TerraformResource
+
+	// Resources indexes every resource of the rule's declared types. It is
+	// shared between rules and must not be modified.
+	Resources *ResourceSet
+
+	// Params holds the rule's configured parameters by name. Parameters
+	// that are not configured are absent; the typed accessors such as
+	// IntParam fall back to their declared defaults.
+	Params map[string]string
+
+	ctx      context.Context
+	meta     RuleMetadata
+	findings []Finding
+	err      error
+}
+
+// NewEvalContext returns the context for checking resource with the rule
+// described by meta.
+func NewEvalContext(ctx context.Context, meta RuleMetadata, resource TerraformResource, resources *ResourceSet, params map[string]string) *EvalContext {
+	return &EvalContext{
+		ctx:       ctx,
+		Resource:  resource,
+		Resources: resources,
+		Params:    params,
+		meta:      meta,
+	}
+}
+
+// Context returns the context of the evaluation. It is done when the run is
+// cancelled or the rule's time budget runs out; long-running rules should
+// stop then.
+func (c *EvalContext) Context() context.Context {
+	return c.ctx
+}
+
+// Finding returns a finding of the rule against res, with the rule's ID, name,
+// severity, pillar and documentation URL and the resource's address and
+// location filled in.
+func (c *EvalContext) Finding(res TerraformResource, description, remediation string) Finding {
+	return Finding{
+		RuleID:      c.meta.ID,
+		RuleName:    c.meta.Name,
+		Severity:    c.meta.Severity,
+		Pillar:      c.meta.Pillar,
+		Resource:    res.Address(),
+		File:        res.File,
+		Line:        res.Line,
+		Description: description,
+		Remediation: remediation,
+		DocURL:      c.meta.DocURL,
+	}
+}
+
+// Report records findings.
+func (c *EvalContext) Report(findings ...Finding) {
+	c.findings = append(c.findings, findings...)
+}
+
+// Findings returns the findings reported so far.
+func (c *EvalContext) Findings() []Finding {
+	return c.findings
+}
+
+// Fail records that the rule could not complete its check, e.g. because an
+// attribute has a shape the rule does not handle. The engine reports err as
+// an engine error and discards the evaluation's findings.
+func (c *EvalContext) Fail(err error) {
+	c.err = err
+}
+
+// Err returns the error recorded by Fail, if any.
+func (c *EvalContext) Err() error {
+	return c.err
+}
+
+// param returns the configured value of the named parameter, or its declared
+// default.
+func (c *EvalContext) param(name string) string {
+	if v, ok := c.Params[name]; ok {
+		return v
+	}
+	p, _ := c.meta.Param(name)
+	return p.Default
+}
+
+// failParam records that a parameter value could not be parsed.
+func (c *EvalContext) failParam(name string, err error) {
+	c.Fail(fmt.Errorf("parameter %s: %w", name, err))
+}
+
+// IntParam returns the value of an int parameter. A value that is not an int
+// fails the evaluation.
+func (c *EvalContext) IntParam(name string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(c.param(name)))
+	if err != nil {
+		c.failParam(name, err)
+	}
+	return n
+}
+
+// NumberParam returns the value of a number parameter. A value that is not a
+// number fails the evaluation.
+func (c *EvalContext) NumberParam(name string) float64 {
+	n, err := strconv.ParseFloat(strings.TrimSpace(c.param(name)), 64)
+	if err != nil {
+		c.failParam(name, err)
+	}
+	return n
+}
+
+// BoolParam returns the value of a bool parameter. A value that is not a bool
+// fails the evaluation.
+func (c *EvalContext) BoolParam(name string) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(c.param(name)))
+	if err != nil {
+		c.failParam(name, err)
+	}
+	return b
+}
+
+// StringParam returns the value of a string parameter.
+func (c *EvalContext) StringParam(name string) string {
+	return c.param(name)
+}
+
+// IntListParam returns the value of an int_list parameter. A value that is not
+// a list of ints fails the evaluation.
+func (c *EvalContext) IntListParam(name string) []int {
+	list, err := parseIntList(c.param(name))
+	if err != nil {
+		c.failParam(name, err)
+	}
+	return list
+}
+
+// StringListParam returns the value of a string_list parameter.
+func (c *EvalContext) StringListParam(name string) []string {
+	return splitList(c.param(name))
+}
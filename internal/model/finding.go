@@ -1,6 +1,8 @@
 // Package model defines core types for rules, findings, and Terraform resources.
 package model
 
+import "strings"
+
 type Severity string
 
 const (
@@ -52,6 +54,18 @@ func AllPillars() []Pillar {
 	}
 }
 
+// ParsePillar returns the pillar named s, ignoring case and spaces, so that
+// "Cost Optimization" names PillarCostOptimization.
+func ParsePillar(s string) (Pillar, bool) {
+	normalized := strings.ReplaceAll(s, " ", "")
+	for _, p := range AllPillars() {
+		if strings.EqualFold(string(p), normalized) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
 type Finding struct {
 	RuleID      string   `json:"rule_id"`
 	RuleName    string   `json:"rule_name"`
@@ -63,4 +77,25 @@ type Finding struct {
 	Description string   `json:"description"`
 	Remediation string   `json:"remediation"`
 	DocURL      string   `json:"doc_url,omitempty"`
+
+	// Undetermined is set when the rule could not decide whether the resource
+	// complies because a value it depends on is only known after apply.
+	// Undetermined findings are reported but never fail the run.
+	Undetermined bool `json:"undetermined,omitempty"`
+
+	// Regression is set when the finding is introduced by the plan: the
+	// resource exists today and did not have this finding before the change.
+	Regression bool `json:"regression,omitempty"`
+
+	// Stack names the plan, state or source the finding came from when a run
+	// analyzes several stacks. It is empty for single-stack runs.
+	Stack string `json:"stack,omitempty"`
+}
+
+// AsUndetermined returns a copy of the finding marked as undetermined, with its
+// description replaced by reason.
+func (f Finding) AsUndetermined(reason string) Finding {
+	f.Undetermined = true
+	f.Description = "Cannot determine: " + reason
+	return f
 }
@@ -0,0 +1,153 @@
+package model
+
+import "sort"
+
+// Graph indexes the references between a set of resources, so rules can ask
+// exact questions such as "which aws_flow_log references this aws_vpc"
+// instead of matching names and IDs.
+type Graph struct {
+	resources []TerraformResource
+	// byAddress indexes resources by full address and by address without the
+	// instance key, so a reference naming no instance matches every instance.
+	byAddress map[string][]int
+	referrers map[int][]edge // target index -> resources referring to it
+}
+
+// edge is one attribute of a resource that refers to another resource.
+type edge struct {
+	from int
+	attr string
+}
+
+// NewGraph builds the reference graph of resources from their References.
+// References to resources outside the set are ignored.
+func NewGraph(resources []TerraformResource) *Graph {
+	g := &Graph{
+		resources: resources,
+		byAddress: make(map[string][]int),
+		referrers: make(map[int][]edge),
+	}
+	for i, res := range resources {
+		addr := res.Address()
+		g.byAddress[addr] = append(g.byAddress[addr], i)
+		if base := withoutInstanceKey(addr); base != addr {
+			g.byAddress[base] = append(g.byAddress[base], i)
+		}
+	}
+	for i, res := range resources {
+		for attr, refs := range res.References {
+			for _, ref := range refs {
+				for _, target := range g.byAddress[ref] {
+					g.referrers[target] = append(g.referrers[target], edge{from: i, attr: attr})
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Resources returns the resources in the graph.
+func (g *Graph) Resources() []TerraformResource {
+	return g.resources
+}
+
+// Targets returns the resources that the expression of an attribute of res
+// refers to. An empty attr matches every attribute.
+func (g *Graph) Targets(res TerraformResource, attr string) []TerraformResource {
+	attrs := []string{attr}
+	if attr == "" {
+		attrs = attrs[:0]
+		for a := range res.References {
+			attrs = append(attrs, a)
+		}
+		sort.Strings(attrs)
+	}
+
+	var indices []int
+	for _, a := range attrs {
+		for _, ref := range res.References[a] {
+			indices = append(indices, g.byAddress[ref]...)
+		}
+	}
+	return g.at(dedupe(indices))
+}
+
+// Referrers returns the resources of resourceType with an attribute whose
+// expression refers to target. An empty resourceType matches every type and
+// an empty attr every attribute.
+func (g *Graph) Referrers(target TerraformResource, resourceType, attr string) []TerraformResource {
+	var indices []int
+	for _, i := range g.byAddress[target.Address()] {
+		if g.resources[i].Address() != target.Address() {
+			continue
+		}
+		for _, e := range g.referrers[i] {
+			if (resourceType == "" || g.resources[e.from].Type == resourceType) && (attr == "" || e.attr == attr) {
+				indices = append(indices, e.from)
+			}
+		}
+	}
+	return g.at(dedupe(indices))
+}
+
+func (g *Graph) at(indices []int) []TerraformResource {
+	if len(indices) == 0 {
+		return nil
+	}
+	out := make([]TerraformResource, len(indices))
+	for j, i := range indices {
+		out[j] = g.resources[i]
+	}
+	return out
+}
+
+// dedupe removes repeated indices, keeping the first occurrence of each.
+func dedupe(indices []int) []int {
+	if len(indices) < 2 {
+		return indices
+	}
+	seen := make(map[int]bool, len(indices))
+	out := indices[:0]
+	for _, i := range indices {
+		if !seen[i] {
+			seen[i] = true
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// withoutInstanceKey removes a trailing instance key from a resource address,
+// turning `module.a["x"].aws_s3_bucket.b[0]` into `module.a["x"].aws_s3_bucket.b`.
+func withoutInstanceKey(addr string) string {
+	if len(addr) == 0 || addr[len(addr)-1] != ']' {
+		return addr
+	}
+	open := -1
+	depth := 0
+	inString := false
+	for i := 0; i < len(addr); i++ {
+		ch := addr[i]
+		switch {
+		case inString:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"' && depth > 0:
+			inString = true
+		case ch == '[':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case ch == ']':
+			depth--
+		}
+	}
+	if open < 0 {
+		return addr
+	}
+	return addr[:open]
+}
@@ -1,5 +1,17 @@
 package model
 
+// Action is the change a plan makes to a resource.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionReplace Action = "replace"
+	ActionDelete  Action = "delete"
+	ActionRead    Action = "read"
+	ActionNoOp    Action = "no-op"
+)
+
 // TerraformResource represents a parsed Terraform resource block.
 type TerraformResource struct {
 	Type        string                 `json:"type"`
@@ -9,6 +21,28 @@ type TerraformResource struct {
 	FullAddress string                 `json:"address,omitempty"`
 	Attributes  map[string]interface{} `json:"attributes"`
 	Blocks      map[string][]Block     `json:"blocks"`
+
+	// Unknown holds the names of attributes whose values cannot be determined
+	// statically, e.g. references to other resources or unset variables, or
+	// that a plan reports as known only after apply. Such attributes are
+	// absent from Attributes (or hold nil for their unknown elements); rules
+	// should not treat them as "not configured".
+	Unknown map[string]bool `json:"unknown,omitempty"`
+
+	// References maps attribute paths to the addresses of the resources their
+	// expressions refer to, e.g. "vpc_id" to ["aws_vpc.main"]. Attributes of
+	// nested blocks are keyed by their dotted path, e.g. "logging.target_bucket".
+	// Addresses carry the referring resource's module prefix and keep an
+	// instance key only when the expression names one.
+	References map[string][]string `json:"references,omitempty"`
+
+	// Action is the change the plan makes to the resource. It is empty for
+	// resources parsed from source, which have no plan.
+	Action Action `json:"action,omitempty"`
+
+	// Before is the resource as it exists prior to the plan, set for
+	// resources the plan updates or replaces.
+	Before *TerraformResource `json:"-"`
 }
 
 // Block represents a nested block within a Terraform resource.
@@ -17,6 +51,7 @@ type Block struct {
 	Labels     []string               `json:"labels,omitempty"`
 	Attributes map[string]interface{} `json:"attributes"`
 	Blocks     map[string][]Block     `json:"blocks"`
+	Unknown    map[string]bool        `json:"unknown,omitempty"`
 }
 
 // Address returns the full resource address (e.g., "aws_s3_bucket.my_bucket").
@@ -28,6 +63,16 @@ func (r TerraformResource) Address() string {
 	return r.Type + "." + r.Name
 }
 
+// IsChanged returns true if the plan creates, updates or replaces the resource.
+func (r TerraformResource) IsChanged() bool {
+	switch r.Action {
+	case ActionCreate, ActionUpdate, ActionReplace:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetStringAttr returns a string attribute value, or empty string if not found/not a string.
 func (r TerraformResource) GetStringAttr(key string) (string, bool) {
 	v, ok := r.Attributes[key]
@@ -66,6 +111,11 @@ func (r TerraformResource) GetNumberAttr(key string) (float64, bool) {
 	}
 }
 
+// IsUnknown returns true if the attribute's value cannot be determined statically.
+func (r TerraformResource) IsUnknown(key string) bool {
+	return r.Unknown[key]
+}
+
 // HasBlock returns true if the resource has at least one block of the given type.
 func (r TerraformResource) HasBlock(blockType string) bool {
 	blocks, ok := r.Blocks[blockType]
@@ -96,3 +146,8 @@ func (b Block) GetBoolAttr(key string) (bool, bool) {
 	bv, ok := v.(bool)
 	return bv, ok
 }
+
+// IsUnknown returns true if the block attribute's value cannot be determined statically.
+func (b Block) IsUnknown(key string) bool {
+	return b.Unknown[key]
+}
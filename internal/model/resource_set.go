@@ -0,0 +1,111 @@
+package model
+
+import "sort"
+
+// identityAttributes are the attributes other resources use to refer to a
+// resource by value, e.g. a flow log's vpc_id holding a VPC's id.
+var identityAttributes = []string{"id", "arn", "name", "bucket"}
+
+// ResourceSet is a read-only set of resources indexed by type, address,
+// identity attributes and, through its reference Graph, the references
+// between them. The engine builds it once per analysis and shares it between
+// the cross-resource rules, so finding related resources never needs a scan
+// of the whole set.
+type ResourceSet struct {
+	*Graph
+	byType     map[string][]int
+	byIdentity map[identityKey][]int
+}
+
+type identityKey struct {
+	resourceType string
+	value        string
+}
+
+// NewResourceSet indexes resources. References to resources outside the set
+// are ignored.
+func NewResourceSet(resources []TerraformResource) *ResourceSet {
+	s := &ResourceSet{
+		Graph:      NewGraph(resources),
+		byType:     make(map[string][]int),
+		byIdentity: make(map[identityKey][]int),
+	}
+	for i, res := range resources {
+		s.byType[res.Type] = append(s.byType[res.Type], i)
+		for _, attr := range identityAttributes {
+			if v, ok := res.GetStringAttr(attr); ok && v != "" {
+				key := identityKey{res.Type, v}
+				if ids := s.byIdentity[key]; len(ids) == 0 || ids[len(ids)-1] != i {
+					s.byIdentity[key] = append(ids, i)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// All returns every resource in the set.
+func (s *ResourceSet) All() []TerraformResource {
+	return s.resources
+}
+
+// Len returns the number of resources in the set.
+func (s *ResourceSet) Len() int {
+	return len(s.resources)
+}
+
+// OfType returns the resources of the given types, in the order they were added.
+func (s *ResourceSet) OfType(resourceTypes ...string) []TerraformResource {
+	var indices []int
+	for _, t := range resourceTypes {
+		indices = append(indices, s.byType[t]...)
+	}
+	if len(resourceTypes) > 1 {
+		sort.Ints(indices)
+	}
+	return s.at(indices)
+}
+
+// ByAddress returns the resource with the given address.
+func (s *ResourceSet) ByAddress(addr string) (TerraformResource, bool) {
+	for _, i := range s.byAddress[addr] {
+		if s.resources[i].Address() == addr {
+			return s.resources[i], true
+		}
+	}
+	return TerraformResource{}, false
+}
+
+// Lookup returns the resources of resourceType whose id, arn, name or bucket
+// attribute equals value.
+func (s *ResourceSet) Lookup(resourceType, value string) []TerraformResource {
+	if value == "" {
+		return nil
+	}
+	return s.at(s.byIdentity[identityKey{resourceType, value}])
+}
+
+// Referenced returns the resources of targetType that an attribute of res
+// refers to, either through an expression reference or by holding one of
+// their identity values (a string, or a list of strings).
+func (s *ResourceSet) Referenced(res TerraformResource, attr, targetType string) []TerraformResource {
+	var indices []int
+	for _, ref := range res.References[attr] {
+		for _, i := range s.byAddress[ref] {
+			if s.resources[i].Type == targetType {
+				indices = append(indices, i)
+			}
+		}
+	}
+	switch v := res.Attributes[attr].(type) {
+	case string:
+		indices = append(indices, s.byIdentity[identityKey{targetType, v}]...)
+	case []interface{}:
+		for _, e := range v {
+			if str, ok := e.(string); ok {
+				indices = append(indices, s.byIdentity[identityKey{targetType, str}]...)
+			}
+		}
+	}
+	return s.at(dedupe(indices))
+}
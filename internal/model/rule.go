@@ -20,12 +20,37 @@ type RuleMetadata struct {
 	ResourceTypes        []string            `json:"resource_types"`
 	DocURL               string              `json:"doc_url,omitempty"`
 	ComplianceFrameworks map[string][]string `json:"compliance_frameworks,omitempty"`
+	// Parameters are the rule's configurable values, such as thresholds,
+	// with their defaults. Rules read them through the EvalContext.
+	Parameters []RuleParameter `json:"parameters,omitempty"`
 }
 
 // CrossResourceRule evaluates findings that require awareness of the full resource set.
 // Use this interface when a check cannot be made on a single resource in isolation —
 // for example, verifying that every aws_vpc has a corresponding aws_flow_log.
+// EvaluateAll receives only resources of the types listed in Metadata().ResourceTypes,
+// so every type the rule reads must be declared there. The set is shared between
+// rules and must not be modified.
 type CrossResourceRule interface {
 	Metadata() RuleMetadata
-	EvaluateAll(resources []TerraformResource) []Finding
+	EvaluateAll(resources *ResourceSet) []Finding
+}
+
+// EvalRule is the rule contract that replaces Rule and CrossResourceRule.
+// Check is called once for every resource of the types listed in
+// Metadata().ResourceTypes, with an EvalContext carrying that resource, the
+// full set of resources those types cover, and the rule's configured
+// parameters. Findings are reported through the context.
+type EvalRule interface {
+	Metadata() RuleMetadata
+	Check(ctx *EvalContext)
+}
+
+// ReferencingRule is an EvalRule that also reads resource types it is not
+// checked against, such as those its resources reference. The engine indexes
+// ReferencedTypes in the ResourceSet but calls Check only for resources of
+// the types in Metadata().ResourceTypes.
+type ReferencingRule interface {
+	EvalRule
+	ReferencedTypes() []string
 }
@@ -0,0 +1,28 @@
+package model
+
+import "path"
+
+// RuleOverride remaps the severity and pillar of the rules whose IDs match a
+// glob pattern. An empty Severity or Pillar is left as the rule declares it.
+type RuleOverride struct {
+	RuleID   string
+	Severity Severity
+	Pillar   Pillar
+}
+
+// Matches reports whether the override applies to the rule with the given ID.
+func (o RuleOverride) Matches(id string) bool {
+	ok, _ := path.Match(o.RuleID, id)
+	return ok
+}
+
+// Apply returns meta with the overridden severity and pillar.
+func (o RuleOverride) Apply(meta RuleMetadata) RuleMetadata {
+	if o.Severity != "" {
+		meta.Severity = o.Severity
+	}
+	if o.Pillar != "" {
+		meta.Pillar = o.Pillar
+	}
+	return meta
+}
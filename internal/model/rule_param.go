@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParamType is the type of a rule parameter. Parameter values are held as
+// strings; list values are comma-separated.
+type ParamType string
+
+const (
+	ParamInt        ParamType = "int"
+	ParamNumber     ParamType = "number"
+	ParamBool       ParamType = "bool"
+	ParamString     ParamType = "string"
+	ParamIntList    ParamType = "int_list"
+	ParamStringList ParamType = "string_list"
+)
+
+// RuleParameter declares a named, typed parameter of a rule.
+type RuleParameter struct {
+	Name        string    `json:"name"`
+	Type        ParamType `json:"type"`
+	Default     string    `json:"default"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Param returns the declared parameter with the given name.
+func (m RuleMetadata) Param(name string) (RuleParameter, bool) {
+	for _, p := range m.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return RuleParameter{}, false
+}
+
+// Validate checks that value is a valid value of the type.
+func (t ParamType) Validate(value string) error {
+	var err error
+	switch t {
+	case ParamInt:
+		_, err = strconv.Atoi(strings.TrimSpace(value))
+	case ParamNumber:
+		_, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case ParamBool:
+		_, err = strconv.ParseBool(strings.TrimSpace(value))
+	case ParamString, ParamStringList:
+	case ParamIntList:
+		_, err = parseIntList(value)
+	default:
+		return fmt.Errorf("unknown parameter type %q", t)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, t)
+	}
+	return nil
+}
+
+func parseIntList(value string) ([]int, error) {
+	var list []int
+	for _, s := range splitList(value) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+// splitList splits a comma-separated list value, dropping empty elements.
+func splitList(value string) []string {
+	var list []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// RuleProfile is the time a rule took over a run.
+type RuleProfile struct {
+	RuleID string `json:"rule_id"`
+	// Kind is "single" for single-resource rules, "cross" for cross-resource
+	// rules and "eval" for rules implementing EvalRule directly.
+	Kind        string `json:"kind"`
+	Evaluations int    `json:"evaluations"`
+	Findings    int    `json:"findings"`
+	// WallTime is the total time of the rule's evaluations. Evaluations
+	// running in parallel each count in full.
+	WallTime time.Duration `json:"wall_time_ns"`
+}
@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// variableDecl is a `variable` block declared in a module.
+type variableDecl struct {
+	Default cty.Value
+	Type    cty.Type // cty.NilType when no type constraint is declared
+}
+
+// rootVariables returns the variable values assigned to a root module by its
+// terraform.tfvars and *.auto.tfvars files and, when cli is set, by the
+// -var-file and -var options.
+func (p *Parser) rootVariables(dir string, bodies []*hclsyntax.Body, cli bool) (map[string]cty.Value, error) {
+	decls := declaredVariables(bodies)
+	values := make(map[string]cty.Value)
+
+	// Variable definition files, lowest precedence first.
+	varFiles := autoVarFiles(dir)
+	if cli {
+		varFiles = append(varFiles, p.opts.VarFiles...)
+	}
+	for _, path := range varFiles {
+		assigned, err := readVarFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, v := range assigned {
+			values[name] = v
+		}
+	}
+
+	if cli {
+		for name, raw := range p.opts.Vars {
+			v, err := parseVarFlag(name, raw, decls[name].Type)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = v
+		}
+	}
+
+	return values, nil
+}
+
+// buildEvalContext creates the evaluation context for a module directory.
+// Declared input variables take their value from assigned, falling back to
+// their default. References that cannot be resolved statically (resources,
+// data sources, module outputs) evaluate to unknown values.
+func buildEvalContext(dir, rootDir string, bodies []*hclsyntax.Body, assigned map[string]cty.Value) *hcl.EvalContext {
+	decls := declaredVariables(bodies)
+	values := make(map[string]cty.Value, len(decls))
+	for name, d := range decls {
+		values[name] = d.Default
+		if v, ok := assigned[name]; ok {
+			values[name] = v
+		}
+		if d.Type == cty.NilType || !values[name].IsWhollyKnown() {
+			continue
+		}
+		if converted, err := convert.Convert(values[name], d.Type); err == nil {
+			values[name] = converted
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(values),
+			"path": cty.ObjectVal(map[string]cty.Value{
+				"module": cty.StringVal(dir),
+				"root":   cty.StringVal(rootDir),
+				"cwd":    cty.StringVal(cwd),
+			}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{
+				"workspace": cty.StringVal("default"),
+			}),
+			"data":   cty.DynamicVal,
+			"module": cty.DynamicVal,
+		},
+		Functions: terraformFunctions(),
+	}
+
+	// Resource attributes are only known after apply.
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type == "resource" && len(block.Labels) == 2 {
+				ctx.Variables[block.Labels[0]] = cty.DynamicVal
+			}
+		}
+	}
+
+	ctx.Variables["local"] = evaluateLocals(bodies, ctx)
+	return ctx
+}
+
+// declaredVariables collects `variable` blocks with their defaults and type constraints.
+// Variables without a default evaluate to unknown until a value is assigned.
+func declaredVariables(bodies []*hclsyntax.Body) map[string]variableDecl {
+	decls := make(map[string]variableDecl)
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			d := variableDecl{Default: cty.DynamicVal, Type: cty.NilType}
+			if attr, ok := block.Body.Attributes["type"]; ok {
+				if ty, diags := typeexpr.TypeConstraint(attr.Expr); !diags.HasErrors() {
+					d.Type = ty
+				}
+			}
+			if attr, ok := block.Body.Attributes["default"]; ok {
+				d.Default = evaluate(attr.Expr, nil)
+			}
+			decls[block.Labels[0]] = d
+		}
+	}
+	return decls
+}
+
+// evaluateLocals resolves every `locals` entry. Locals may refer to each other in
+// any order, so they are re-evaluated until no value changes.
+func evaluateLocals(bodies []*hclsyntax.Body, ctx *hcl.EvalContext) cty.Value {
+	exprs := make(map[string]hclsyntax.Expression)
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type != "locals" {
+				continue
+			}
+			for name, attr := range block.Body.Attributes {
+				exprs[name] = attr.Expr
+			}
+		}
+	}
+
+	values := make(map[string]cty.Value, len(exprs))
+	for name := range exprs {
+		values[name] = cty.DynamicVal
+	}
+
+	for pass := 0; pass <= len(exprs); pass++ {
+		ctx.Variables["local"] = cty.ObjectVal(values)
+		changed := false
+		for name, expr := range exprs {
+			if values[name].IsWhollyKnown() {
+				continue
+			}
+			v := evaluate(expr, ctx)
+			if !v.RawEquals(values[name]) {
+				values[name] = v
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return cty.ObjectVal(values)
+}
+
+// evaluate returns the value of an expression, or an unknown value if it
+// cannot be evaluated in the given context.
+func evaluate(expr hcl.Expression, ctx *hcl.EvalContext) cty.Value {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	return val
+}
+
+// autoVarFiles returns the variable definition files Terraform loads automatically
+// from a directory, in the order they take precedence.
+func autoVarFiles(dir string) []string {
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+
+	var auto []string
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(e.Name(), ".auto.tfvars") || strings.HasSuffix(e.Name(), ".auto.tfvars.json") {
+			auto = append(auto, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(auto)
+
+	return append(files, auto...)
+}
+
+// readVarFile parses a .tfvars or .tfvars.json file into variable values.
+func readVarFile(path string) (map[string]cty.Value, error) {
+	src, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument or a file in the analyzed directory
+	if err != nil {
+		return nil, fmt.Errorf("reading variable file: %w", err)
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = hcljson.Parse(src, path)
+	} else {
+		file, diags = hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing variable file %s: %s", path, diags.Error())
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing variable file %s: %s", path, diags.Error())
+	}
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("variable %q in %s: %s", name, path, diags.Error())
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// parseVarFlag interprets a -var value the way Terraform does: as a literal string
+// for primitive or unconstrained variables, and as an HCL expression otherwise.
+func parseVarFlag(name, raw string, ty cty.Type) (cty.Value, error) {
+	if ty == cty.NilType || ty == cty.DynamicPseudoType || ty.IsPrimitiveType() {
+		return cty.StringVal(raw), nil
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), "<var "+name+">", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %q: %s", name, diags.Error())
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %q: %s", name, diags.Error())
+	}
+	return val, nil
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// resourceMetaArguments are resource-level arguments and blocks interpreted by
+// Terraform itself rather than the provider. They never appear in plan values.
+var resourceMetaArguments = map[string]bool{
+	"count":       true,
+	"for_each":    true,
+	"depends_on":  true,
+	"provider":    true,
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+}
+
+// instance is one expanded instance of a resource block.
+type instance struct {
+	key     string // instance key suffix, e.g. `[0]` or `["logs"]`; empty for single resources
+	eachKey string // for_each key, e.g. "logs"
+	ctx     *hcl.EvalContext
+}
+
+// expandInstances expands a resource block's count or for_each into one
+// instance per index or key, each with count.index or each.key/each.value set.
+// When the count or collection cannot be evaluated the block is kept as a
+// single instance whose count/each values are unknown.
+func expandInstances(block *hclsyntax.Block, ctx *hcl.EvalContext) []instance {
+	if attr, ok := block.Body.Attributes["count"]; ok {
+		n, known := evaluateCount(attr.Expr, ctx)
+		if !known {
+			return []instance{{ctx: childContext(ctx, "count", cty.ObjectVal(map[string]cty.Value{
+				"index": cty.UnknownVal(cty.Number),
+			}))}}
+		}
+
+		instances := make([]instance, 0, n)
+		for i := 0; i < n; i++ {
+			instances = append(instances, instance{
+				key: fmt.Sprintf("[%d]", i),
+				ctx: childContext(ctx, "count", cty.ObjectVal(map[string]cty.Value{
+					"index": cty.NumberIntVal(int64(i)),
+				})),
+			})
+		}
+		return instances
+	}
+
+	if attr, ok := block.Body.Attributes["for_each"]; ok {
+		collection := evaluate(attr.Expr, ctx)
+		unknownEach := []instance{{ctx: childContext(ctx, "each", cty.ObjectVal(map[string]cty.Value{
+			"key":   cty.UnknownVal(cty.String),
+			"value": cty.DynamicVal,
+		}))}}
+		if !collection.IsKnown() || collection.IsNull() || !collection.CanIterateElements() {
+			return unknownEach
+		}
+
+		var instances []instance
+		for it := collection.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			if !k.IsKnown() || k.IsNull() || k.Type() != cty.String {
+				return unknownEach
+			}
+			instances = append(instances, instance{
+				key:     fmt.Sprintf("[%q]", k.AsString()),
+				eachKey: k.AsString(),
+				ctx: childContext(ctx, "each", cty.ObjectVal(map[string]cty.Value{
+					"key":   k,
+					"value": v,
+				})),
+			})
+		}
+		return instances
+	}
+
+	return []instance{{ctx: ctx}}
+}
+
+// evaluateCount returns the value of a count expression, if it is known.
+func evaluateCount(expr hcl.Expression, ctx *hcl.EvalContext) (int, bool) {
+	val := evaluate(expr, ctx)
+	if !val.IsKnown() || val.IsNull() {
+		return 0, false
+	}
+	val, err := convert.Convert(val, cty.Number)
+	if err != nil {
+		return 0, false
+	}
+	var n int
+	if err := gocty.FromCtyValue(val, &n); err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// expandDynamicBlock expands a `dynamic` block into one block per element of its
+// for_each collection, evaluating the `content` body with the iterator variable
+// set. It returns false if the collection cannot be evaluated.
+func expandDynamicBlock(block *hclsyntax.Block, ctx *hcl.EvalContext) ([]model.Block, bool) {
+	blockType := block.Labels[0]
+
+	forEach, ok := block.Body.Attributes["for_each"]
+	if !ok {
+		return nil, false
+	}
+
+	iterator := blockType
+	if attr, ok := block.Body.Attributes["iterator"]; ok {
+		traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+		if diags.HasErrors() || len(traversal) != 1 {
+			return nil, false
+		}
+		iterator = traversal.RootName()
+	}
+
+	var content *hclsyntax.Block
+	for _, b := range block.Body.Blocks {
+		if b.Type == "content" {
+			content = b
+			break
+		}
+	}
+	if content == nil {
+		return nil, false
+	}
+
+	collection := evaluate(forEach.Expr, ctx)
+	if !collection.IsKnown() || collection.IsNull() || !collection.CanIterateElements() {
+		return nil, false
+	}
+
+	var blocks []model.Block
+	for it := collection.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		child := childContext(ctx, iterator, cty.ObjectVal(map[string]cty.Value{
+			"key":   k,
+			"value": v,
+		}))
+
+		var labels []string
+		if attr, ok := block.Body.Attributes["labels"]; ok {
+			labels = asStringList(evaluate(attr.Expr, child))
+		}
+
+		attrs, nested, unknown := extractBody(content.Body, child, nil)
+		blocks = append(blocks, model.Block{
+			Type:       blockType,
+			Labels:     labels,
+			Attributes: attrs,
+			Blocks:     nested,
+			Unknown:    unknown,
+		})
+	}
+	return blocks, true
+}
+
+// childContext returns a child evaluation context with one extra variable.
+func childContext(ctx *hcl.EvalContext, name string, val cty.Value) *hcl.EvalContext {
+	child := ctx.NewChild()
+	child.Variables = map[string]cty.Value{name: val}
+	return child
+}
+
+// asStringList converts a known list, tuple or set of strings to a Go slice,
+// skipping elements that are unknown or not strings.
+func asStringList(val cty.Value) []string {
+	if !val.IsKnown() || val.IsNull() || !val.CanIterateElements() {
+		return nil
+	}
+	var out []string
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+			out = append(out, v.AsString())
+		}
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// planConfiguration is the `configuration` section of plan JSON: the module
+// tree and resource declarations the plan was built from.
+type planConfiguration struct {
+	RootModule *configModule `json:"root_module"`
+}
+
+type configModule struct {
+	Resources   []configResource            `json:"resources"`
+	ModuleCalls map[string]configModuleCall `json:"module_calls"`
+}
+
+type configResource struct {
+	Address     string                     `json:"address"`
+	Mode        string                     `json:"mode"`
+	Type        string                     `json:"type"`
+	Name        string                     `json:"name"`
+	Expressions map[string]json.RawMessage `json:"expressions"`
+}
+
+type configModuleCall struct {
+	Source string        `json:"source"`
+	Module *configModule `json:"module"`
+}
+
+// sourceLocation is the file and line of a resource declaration.
+type sourceLocation struct {
+	file string
+	line int
+}
+
+// sourceLocations indexes the declaration of every resource under sourceRoot.
+// The module tree is taken from the plan's configuration section when present,
+// and from the module blocks in the source otherwise.
+func sourceLocations(sourceRoot string, config *planConfiguration) (map[string]sourceLocation, error) {
+	var root *configModule
+	if config != nil {
+		root = config.RootModule
+	}
+
+	l := newModuleLoader(New())
+	locations := make(map[string]sourceLocation)
+	if err := l.indexDeclarations(sourceRoot, sourceRoot, "", "", root, 0, locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// locate sets File and Line on a plan resource to the block that declares it.
+// Resources whose declaration cannot be found keep their existing location.
+func locate(res *model.TerraformResource, locations map[string]sourceLocation) {
+	if loc, ok := locations[stripInstanceKeys(res.Address())]; ok {
+		res.File = loc.file
+		res.Line = loc.line
+	}
+}
+
+// indexDeclarations records the location of every resource and data block in a
+// module directory and, recursively, its child modules. Keys are configuration
+// addresses without instance keys, e.g. "module.vpc.aws_subnet.public".
+func (l *moduleLoader) indexDeclarations(dir, rootDir, prefix, key string, config *configModule, depth int, out map[string]sourceLocation) error {
+	files, err := l.moduleFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	sources := make(map[string]string)
+	children := make(map[string]*configModule)
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			switch {
+			case (block.Type == "resource" || block.Type == "data") && len(block.Labels) == 2:
+				addr := prefix + block.Labels[0] + "." + block.Labels[1]
+				if block.Type == "data" {
+					addr = prefix + "data." + block.Labels[0] + "." + block.Labels[1]
+				}
+				out[addr] = sourceLocation{file: f.path, line: block.DefRange().Start.Line}
+			case block.Type == "module" && len(block.Labels) == 1 && config == nil:
+				if source, ok := literalSource(block); ok {
+					sources[block.Labels[0]] = source
+				}
+			}
+		}
+	}
+	if config != nil {
+		for name, call := range config.ModuleCalls {
+			sources[name] = call.Source
+			children[name] = call.Module
+		}
+	}
+
+	if depth >= maxModuleDepth {
+		return nil
+	}
+	for name, source := range sources {
+		childKey := name
+		if key != "" {
+			childKey = key + "." + name
+		}
+
+		var childDir string
+		if isLocalSource(source) {
+			childDir = filepath.Join(dir, source)
+		} else if d, ok := l.manifest(rootDir)[childKey]; ok {
+			childDir = filepath.Join(rootDir, d)
+		} else {
+			continue
+		}
+
+		if err := l.indexDeclarations(childDir, rootDir, prefix+"module."+name+".", childKey, children[name], depth+1, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripInstanceKeys removes instance keys from a resource address, turning
+// `module.a["x"].aws_s3_bucket.b[0]` into `module.a.aws_s3_bucket.b`.
+func stripInstanceKeys(addr string) string {
+	var sb strings.Builder
+	depth := 0
+	inString := false
+	for i := 0; i < len(addr); i++ {
+		ch := addr[i]
+		switch {
+		case inString:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"' && depth > 0:
+			inString = true
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		case depth == 0:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
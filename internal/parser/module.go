@@ -0,0 +1,333 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// maxModuleDepth bounds module nesting, guarding against cyclic local sources.
+const maxModuleDepth = 16
+
+// moduleMetaArguments are module block arguments interpreted by Terraform itself
+// rather than passed to the module as input variables.
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// sourceFile is a parsed Terraform configuration file.
+type sourceFile struct {
+	path string
+	body *hclsyntax.Body
+}
+
+// moduleCall identifies one instance of a module in the module tree.
+type moduleCall struct {
+	dir     string               // directory containing the module's configuration files
+	rootDir string               // root module directory, for path.root and modules.json
+	prefix  string               // address prefix, e.g. "module.network." ("" for the root module)
+	key     string               // modules.json key, e.g. "network.subnets" ("" for the root module)
+	inputs  map[string]cty.Value // input variable values
+	depth   int
+	parents []string // directories of the calling modules, outermost first
+}
+
+// moduleLoader parses a tree of Terraform modules, following local module
+// sources and registry modules installed by `terraform init`.
+type moduleLoader struct {
+	p         *Parser
+	files     map[string][]sourceFile
+	manifests map[string]map[string]string
+	loaded    map[string]bool // directories loaded as a root or child module
+}
+
+// moduleManifest is the structure of .terraform/modules/modules.json.
+type moduleManifest struct {
+	Modules []struct {
+		Key    string `json:"Key"`
+		Source string `json:"Source"`
+		Dir    string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+func newModuleLoader(p *Parser) *moduleLoader {
+	return &moduleLoader{
+		p:         p,
+		files:     make(map[string][]sourceFile),
+		manifests: make(map[string]map[string]string),
+		loaded:    make(map[string]bool),
+	}
+}
+
+// moduleFiles returns the parsed .tf and .tf.json files of a module directory.
+func (l *moduleLoader) moduleFiles(dir string) ([]sourceFile, error) {
+	if files, ok := l.files[dir]; ok {
+		return files, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	jsonPaths, err := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, jsonPaths...)
+	sort.Strings(paths)
+
+	files := make([]sourceFile, 0, len(paths))
+	for _, path := range paths {
+		body, err := parseConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		files = append(files, sourceFile{path: path, body: body})
+	}
+	l.files[dir] = files
+	return files, nil
+}
+
+// loadRoot parses a root module and every module it calls. When cli is set the
+// -var-file and -var options apply to the root module's variables.
+func (l *moduleLoader) loadRoot(dir string, cli bool) ([]model.TerraformResource, error) {
+	files, err := l.moduleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := l.p.rootVariables(dir, bodiesOf(files), cli)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, _, err := l.load(moduleCall{dir: dir, rootDir: dir, inputs: vars})
+	return resources, err
+}
+
+// load parses one module instance and its child modules, returning their
+// resources and the module's output values.
+func (l *moduleLoader) load(call moduleCall) ([]model.TerraformResource, cty.Value, error) {
+	files, err := l.moduleFiles(call.dir)
+	if err != nil {
+		return nil, cty.DynamicVal, err
+	}
+	l.loaded[call.dir] = true
+	bodies := bodiesOf(files)
+	ctx := buildEvalContext(call.dir, call.rootDir, bodies, call.inputs)
+
+	// Child modules are loaded in declaration order; each one's outputs become
+	// visible to the locals and module blocks that follow.
+	var childResources []model.TerraformResource
+	outputs := make(map[string]cty.Value)
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			if block.Type != "module" || len(block.Labels) != 1 {
+				continue
+			}
+			res, out, err := l.loadModuleBlock(call, block, ctx)
+			if err != nil {
+				return nil, cty.DynamicVal, err
+			}
+			childResources = append(childResources, res...)
+			outputs[block.Labels[0]] = out
+			ctx.Variables["module"] = cty.ObjectVal(outputs)
+			ctx.Variables["local"] = evaluateLocals(bodies, ctx)
+		}
+	}
+
+	var resources []model.TerraformResource
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			if (block.Type != "resource" && block.Type != "data") || len(block.Labels) < 2 {
+				continue
+			}
+			resources = append(resources, extractResources(block, f.path, call.prefix, ctx)...)
+		}
+	}
+
+	return append(resources, childResources...), moduleOutputs(bodies, ctx), nil
+}
+
+// loadModuleBlock loads every instance of a module block. Modules whose source
+// cannot be resolved locally are skipped and their outputs are unknown.
+func (l *moduleLoader) loadModuleBlock(call moduleCall, block *hclsyntax.Block, ctx *hcl.EvalContext) ([]model.TerraformResource, cty.Value, error) {
+	name := block.Labels[0]
+	key := name
+	if call.key != "" {
+		key = call.key + "." + name
+	}
+
+	if call.depth >= maxModuleDepth {
+		return nil, cty.DynamicVal, nil
+	}
+	dir, ok := l.resolveSource(call, block, key)
+	if !ok {
+		return nil, cty.DynamicVal, nil
+	}
+	parents := append(append([]string(nil), call.parents...), call.dir)
+	for i, parent := range parents {
+		if parent == dir {
+			return nil, cty.DynamicVal, fmt.Errorf("module cycle: %s", strings.Join(append(parents[i:], dir), " -> "))
+		}
+	}
+
+	instances := expandInstances(block, ctx)
+	var resources []model.TerraformResource
+	var tuple []cty.Value
+	object := make(map[string]cty.Value)
+	for _, inst := range instances {
+		inputs := make(map[string]cty.Value)
+		for attrName, attr := range block.Body.Attributes {
+			if moduleMetaArguments[attrName] {
+				continue
+			}
+			inputs[attrName] = evaluate(attr.Expr, inst.ctx)
+		}
+
+		res, out, err := l.load(moduleCall{
+			dir:     dir,
+			rootDir: call.rootDir,
+			prefix:  call.prefix + "module." + name + inst.key + ".",
+			key:     key,
+			inputs:  inputs,
+			depth:   call.depth + 1,
+			parents: parents,
+		})
+		if err != nil {
+			return nil, cty.DynamicVal, err
+		}
+		resources = append(resources, res...)
+		tuple = append(tuple, out)
+		object[inst.eachKey] = out
+	}
+
+	switch {
+	case len(instances) == 1 && instances[0].key == "":
+		return resources, tuple[0], nil
+	case block.Body.Attributes["count"] != nil:
+		if len(tuple) == 0 {
+			return resources, cty.EmptyTupleVal, nil
+		}
+		return resources, cty.TupleVal(tuple), nil
+	default:
+		return resources, cty.ObjectVal(object), nil
+	}
+}
+
+// resolveSource returns the directory holding a module block's source code:
+// the path itself for local sources, or the directory recorded for the module
+// in .terraform/modules/modules.json for anything `terraform init` installed.
+func (l *moduleLoader) resolveSource(call moduleCall, block *hclsyntax.Block, key string) (string, bool) {
+	source, ok := literalSource(block)
+	if !ok {
+		return "", false
+	}
+	if isLocalSource(source) {
+		return filepath.Join(call.dir, source), true
+	}
+
+	dir, ok := l.manifest(call.rootDir)[key]
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(call.rootDir, dir), true
+}
+
+// manifest returns the installed module directories recorded for a root module,
+// keyed by module path (e.g. "network.subnets").
+func (l *moduleLoader) manifest(rootDir string) map[string]string {
+	if m, ok := l.manifests[rootDir]; ok {
+		return m
+	}
+
+	dirs := make(map[string]string)
+	l.manifests[rootDir] = dirs
+
+	data, err := os.ReadFile(filepath.Join(rootDir, ".terraform", "modules", "modules.json")) // #nosec G304 -- path is derived from the analyzed directory
+	if err != nil {
+		return dirs
+	}
+	var m moduleManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return dirs
+	}
+	for _, mod := range m.Modules {
+		if mod.Key != "" {
+			dirs[mod.Key] = mod.Dir
+		}
+	}
+	return dirs
+}
+
+// moduleOutputs evaluates a module's `output` blocks into an object value.
+func moduleOutputs(bodies []*hclsyntax.Body, ctx *hcl.EvalContext) cty.Value {
+	outputs := make(map[string]cty.Value)
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type != "output" || len(block.Labels) != 1 {
+				continue
+			}
+			if attr, ok := block.Body.Attributes["value"]; ok {
+				outputs[block.Labels[0]] = evaluate(attr.Expr, ctx)
+			}
+		}
+	}
+	return cty.ObjectVal(outputs)
+}
+
+// localModuleSources returns the local source paths of the module blocks in files.
+func localModuleSources(files []sourceFile) []string {
+	var sources []string
+	for _, f := range files {
+		for _, block := range f.body.Blocks {
+			if block.Type != "module" || len(block.Labels) != 1 {
+				continue
+			}
+			if source, ok := literalSource(block); ok && isLocalSource(source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources
+}
+
+// literalSource returns a module block's source argument, which Terraform
+// requires to be a literal string.
+func literalSource(block *hclsyntax.Block) (string, bool) {
+	attr, ok := block.Body.Attributes["source"]
+	if !ok {
+		return "", false
+	}
+	val := evaluate(attr.Expr, nil)
+	if !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+// isLocalSource reports whether a module source is a local filesystem path.
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+func bodiesOf(files []sourceFile) []*hclsyntax.Body {
+	bodies := make([]*hclsyntax.Body, len(files))
+	for i, f := range files {
+		bodies[i] = f.body
+	}
+	return bodies
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -13,16 +14,38 @@ import (
 )
 
 // Parser reads Terraform HCL files and extracts resource definitions.
-type Parser struct{}
+type Parser struct {
+	opts Options
+}
+
+// Options controls how input variables are resolved when evaluating Terraform source.
+type Options struct {
+	// VarFiles are variable definition files (-var-file), applied after
+	// terraform.tfvars and *.auto.tfvars.
+	VarFiles []string
+	// Vars are individual variable assignments (-var), applied last.
+	Vars map[string]string
+}
 
 // New creates a new Parser.
 func New() *Parser {
 	return &Parser{}
 }
 
-// ParseDirectory walks a directory and parses all .tf files.
+// NewWithOptions creates a Parser that resolves variables using the given options.
+func NewWithOptions(opts Options) *Parser {
+	return &Parser{opts: opts}
+}
+
+// ParseDirectory walks a directory and parses all .tf and .tf.json files.
+// Each directory is evaluated as one Terraform module. Directories that are
+// called as a local module by another directory are parsed through that call,
+// so their resources get module addresses and receive the module's inputs;
+// every other directory, and dir itself, is treated as a root module. Cyclic
+// local module calls are an error.
 func (p *Parser) ParseDirectory(dir string) ([]model.TerraformResource, error) {
-	var resources []model.TerraformResource
+	var dirs []string
+	seen := make(map[string]bool)
 
 	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -34,106 +57,208 @@ func (p *Parser) ParseDirectory(dir string) ([]model.TerraformResource, error) {
 			}
 			return nil
 		}
-		if filepath.Ext(path) != ".tf" {
+		if !isConfigFile(path) {
 			return nil
 		}
 
-		fileResources, parseErr := p.ParseFile(path)
-		if parseErr != nil {
-			return fmt.Errorf("parsing %s: %w", path, parseErr)
+		parent := filepath.Dir(path)
+		if !seen[parent] {
+			seen[parent] = true
+			dirs = append(dirs, parent)
 		}
-		resources = append(resources, fileResources...)
 		return nil
 	})
-
-	return resources, err
-}
-
-// ParseFile parses a single .tf file and extracts resource blocks.
-func (p *Parser) ParseFile(path string) ([]model.TerraformResource, error) {
-	src, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
 	if err != nil {
 		return nil, err
 	}
 
-	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
-	if diags.HasErrors() {
-		return nil, fmt.Errorf("HCL parse error: %s", diags.Error())
-	}
-
-	body, ok := file.Body.(*hclsyntax.Body)
-	if !ok {
-		return nil, fmt.Errorf("unexpected body type in %s", path)
+	l := newModuleLoader(p)
+	called := make(map[string]bool)
+	for _, d := range dirs {
+		files, err := l.moduleFiles(d)
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range localModuleSources(files) {
+			called[filepath.Join(d, src)] = true
+		}
 	}
 
+	root := filepath.Clean(dir)
 	var resources []model.TerraformResource
-
-	for _, block := range body.Blocks {
-		if len(block.Labels) < 2 {
+	for _, d := range dirs {
+		if called[d] && d != root {
 			continue
 		}
-		if block.Type != "resource" && block.Type != "data" {
-			continue
+		rootResources, err := l.loadRoot(d, d == root)
+		if err != nil {
+			return nil, err
 		}
+		resources = append(resources, rootResources...)
+	}
 
-		resourceType := block.Labels[0]
-		if block.Type == "data" {
-			resourceType = "data." + resourceType
+	// A called directory that was never loaded is either called with no
+	// instances or only from within a cycle; loading it reports the cycle.
+	for _, d := range dirs {
+		if !l.loaded[d] {
+			if _, err := l.loadRoot(d, false); err != nil {
+				return nil, err
+			}
 		}
+	}
+	return resources, nil
+}
 
+// ParseFile parses a single .tf or .tf.json file as a root module and extracts resource blocks.
+func (p *Parser) ParseFile(path string) ([]model.TerraformResource, error) {
+	body, err := parseConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	l := newModuleLoader(p)
+	l.files[dir] = []sourceFile{{path: path, body: body}}
+	return l.loadRoot(dir, true)
+}
+
+// extractResources converts a resource or data block into one TerraformResource
+// per expanded instance. Resources inside child modules are addressed with the
+// module's address prefix.
+func extractResources(block *hclsyntax.Block, path, modulePrefix string, ctx *hcl.EvalContext) []model.TerraformResource {
+	resourceType := block.Labels[0]
+	if block.Type == "data" {
+		resourceType = "data." + resourceType
+	}
+
+	var resources []model.TerraformResource
+	refs := bodyReferences(block.Body, modulePrefix, resourceMetaArguments)
+	for _, inst := range expandInstances(block, ctx) {
+		attrs, blocks, unknown := extractBody(block.Body, inst.ctx, resourceMetaArguments)
 		res := model.TerraformResource{
 			Type:       resourceType,
 			Name:       block.Labels[1],
 			File:       path,
 			Line:       block.DefRange().Start.Line,
-			Attributes: extractAttributes(block.Body),
-			Blocks:     extractBlocks(block.Body),
+			Attributes: attrs,
+			Blocks:     blocks,
+			Unknown:    unknown,
+			References: refs,
+		}
+		if modulePrefix != "" || inst.key != "" {
+			res.FullAddress = modulePrefix + resourceType + "." + block.Labels[1] + inst.key
 		}
 		resources = append(resources, res)
 	}
+	return resources
+}
+
+// isConfigFile reports whether a file holds Terraform configuration, in native
+// (.tf) or JSON (.tf.json) syntax.
+func isConfigFile(path string) bool {
+	return strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tf.json")
+}
+
+// HasConfigFiles reports whether a directory contains Terraform configuration files.
+func HasConfigFiles(dir string) bool {
+	for _, pattern := range []string{"*.tf", "*.tf.json"} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
 
-	return resources, nil
+// parseConfigFile parses a Terraform configuration file in either syntax.
+func parseConfigFile(path string) (*hclsyntax.Body, error) {
+	if strings.HasSuffix(path, ".tf.json") {
+		return parseJSONFile(path)
+	}
+	return parseHCLFile(path)
+}
+
+// parseHCLFile reads and parses a single native-syntax Terraform file.
+func parseHCLFile(path string) (*hclsyntax.Body, error) {
+	src, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return nil, err
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("HCL parse error: %s", diags.Error())
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unexpected body type in %s", path)
+	}
+	return body, nil
 }
 
-// extractAttributes extracts literal attribute values from an HCL body.
-// Dynamic expressions (variable references, function calls, etc.) are stored as string representations.
-func extractAttributes(body *hclsyntax.Body) map[string]interface{} {
+// extractBody evaluates the attributes and nested blocks of an HCL body,
+// expanding `dynamic` blocks. Attributes and block types listed in skip are ignored.
+//
+// Attributes whose values cannot be fully determined (references to other
+// resources, variables without a value, unsupported functions) and dynamic
+// blocks whose for_each cannot be evaluated are returned in the unknown set.
+// Known parts of partially-known collections are kept, with unknown elements as nil.
+func extractBody(body *hclsyntax.Body, ctx *hcl.EvalContext, skip map[string]bool) (map[string]interface{}, map[string][]model.Block, map[string]bool) {
 	attrs := make(map[string]interface{})
+	blocks := make(map[string][]model.Block)
+	var unknown map[string]bool
+	markUnknown := func(name string) {
+		if unknown == nil {
+			unknown = make(map[string]bool)
+		}
+		unknown[name] = true
+	}
 
 	for name, attr := range body.Attributes {
-		val, diags := attr.Expr.Value(nil)
-		if diags.HasErrors() {
-			// Expression couldn't be evaluated (likely references a variable).
-			// Store the source expression as a string for informational purposes.
-			attrs[name] = expressionToString(attr.Expr)
+		if skip[name] {
 			continue
 		}
+		val := evaluate(attr.Expr, ctx)
+		if !val.IsWhollyKnown() {
+			markUnknown(name)
+			if !val.IsKnown() {
+				continue
+			}
+		}
 		attrs[name] = ctyToGo(val)
 	}
 
-	return attrs
-}
-
-// extractBlocks extracts nested blocks from an HCL body.
-func extractBlocks(body *hclsyntax.Body) map[string][]model.Block {
-	blocks := make(map[string][]model.Block)
-
 	for _, block := range body.Blocks {
-		b := model.Block{
+		if skip[block.Type] {
+			continue
+		}
+		if block.Type == "dynamic" && len(block.Labels) == 1 {
+			expanded, ok := expandDynamicBlock(block, ctx)
+			if !ok {
+				markUnknown(block.Labels[0])
+				continue
+			}
+			blocks[block.Labels[0]] = append(blocks[block.Labels[0]], expanded...)
+			continue
+		}
+
+		blockAttrs, nested, blockUnknown := extractBody(block.Body, ctx, nil)
+		blocks[block.Type] = append(blocks[block.Type], model.Block{
 			Type:       block.Type,
 			Labels:     block.Labels,
-			Attributes: extractAttributes(block.Body),
-			Blocks:     extractBlocks(block.Body),
-		}
-		blocks[block.Type] = append(blocks[block.Type], b)
+			Attributes: blockAttrs,
+			Blocks:     nested,
+			Unknown:    blockUnknown,
+		})
 	}
 
-	return blocks
+	return attrs, blocks, unknown
 }
 
 // ctyToGo converts a cty.Value to a native Go value.
+// Unknown values convert to nil.
 func ctyToGo(val cty.Value) interface{} {
-	if val.IsNull() {
+	if !val.IsKnown() || val.IsNull() {
 		return nil
 	}
 
@@ -166,9 +291,3 @@ func ctyToGo(val cty.Value) interface{} {
 		return val.GoString()
 	}
 }
-
-// expressionToString extracts the source text of an HCL expression.
-func expressionToString(expr hclsyntax.Expression) string {
-	rng := expr.Range()
-	return fmt.Sprintf("${%s}", rng.SliceBytes(nil))
-}
@@ -1,10 +1,14 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
 func TestParseFile_S3Good(t *testing.T) {
@@ -90,3 +94,395 @@ func TestParseFile_NonExistent(t *testing.T) {
 	_, err := p.ParseFile("nonexistent.tf")
 	assert.Error(t, err)
 }
+
+func TestParseDirectory_ResolvesVariablesAndLocals(t *testing.T) {
+	p := New()
+	resources, err := p.ParseDirectory("../../testdata/variables")
+	require.NoError(t, err)
+	require.Len(t, resources, 4)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "data")
+	require.NotNil(t, bucket)
+	// prod.auto.tfvars takes precedence over terraform.tfvars
+	assert.Equal(t, "acme-prod-data", bucket.Attributes["bucket"])
+	tags, ok := bucket.Attributes["tags"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "prod", tags["Environment"])
+	assert.Equal(t, "platform", tags["Team"])
+	assert.Equal(t, "ACME-PROD-DATA", tags["Name"])
+
+	volume := findPlanResource(resources, "aws_ebs_volume", "data")
+	require.NotNil(t, volume)
+	encrypted, ok := volume.GetBoolAttr("encrypted")
+	assert.True(t, ok)
+	assert.False(t, encrypted)
+	// References to other resources are only known after apply
+	assert.True(t, volume.IsUnknown("kms_key_id"))
+	assert.NotContains(t, volume.Attributes, "kms_key_id")
+
+	logGroup := findPlanResource(resources, "aws_cloudwatch_log_group", "app")
+	require.NotNil(t, logGroup)
+	assert.Equal(t, "/app/prod", logGroup.Attributes["name"])
+	retention, ok := logGroup.GetNumberAttr("retention_in_days")
+	assert.True(t, ok)
+	assert.Equal(t, float64(14), retention)
+	// Variable with no default and no assigned value
+	assert.True(t, logGroup.IsUnknown("kms_key_id"))
+}
+
+func TestParseDirectory_VarFileAndVarFlags(t *testing.T) {
+	p := NewWithOptions(Options{
+		VarFiles: []string{"../../testdata/variables/override.tfvars"},
+		Vars: map[string]string{
+			"environment":    "dev",
+			"retention_days": "30",
+		},
+	})
+	resources, err := p.ParseDirectory("../../testdata/variables")
+	require.NoError(t, err)
+
+	volume := findPlanResource(resources, "aws_ebs_volume", "data")
+	require.NotNil(t, volume)
+	encrypted, ok := volume.GetBoolAttr("encrypted")
+	assert.True(t, ok)
+	assert.True(t, encrypted)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "data")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "acme-dev-data", bucket.Attributes["bucket"])
+
+	logGroup := findPlanResource(resources, "aws_cloudwatch_log_group", "app")
+	require.NotNil(t, logGroup)
+	retention, ok := logGroup.GetNumberAttr("retention_in_days")
+	assert.True(t, ok)
+	assert.Equal(t, float64(30), retention)
+}
+
+func TestParseDirectory_InvalidVarFile(t *testing.T) {
+	p := NewWithOptions(Options{VarFiles: []string{"../../testdata/variables/missing.tfvars"}})
+	_, err := p.ParseDirectory("../../testdata/variables")
+	assert.Error(t, err)
+}
+
+func TestParseFile_ExpandsCountAndForEach(t *testing.T) {
+	p := New()
+	resources, err := p.ParseFile("../../testdata/expansion/main.tf")
+	require.NoError(t, err)
+
+	var addresses []string
+	for _, r := range resources {
+		addresses = append(addresses, r.Address())
+	}
+	assert.Equal(t, []string{
+		"aws_instance.web[0]",
+		"aws_instance.web[1]",
+		`aws_s3_bucket.b["assets"]`,
+		`aws_s3_bucket.b["logs"]`,
+		"aws_security_group.web",
+	}, addresses)
+
+	web1 := resources[1]
+	assert.Equal(t, "web", web1.Name)
+	assert.Equal(t, "web-1", web1.Attributes["tags"].(map[string]interface{})["Name"])
+	assert.NotContains(t, web1.Attributes, "count")
+
+	logs := resources[3]
+	assert.Equal(t, "acme-logs", logs.Attributes["bucket"])
+	assert.Equal(t, "logs", logs.Attributes["tags"].(map[string]interface{})["Purpose"])
+}
+
+func TestParseFile_ExpandsDynamicBlocks(t *testing.T) {
+	p := New()
+	resources, err := p.ParseFile("../../testdata/expansion/main.tf")
+	require.NoError(t, err)
+
+	sg := findPlanResource(resources, "aws_security_group", "web")
+	require.NotNil(t, sg)
+
+	ingress := sg.GetBlocks("ingress")
+	require.Len(t, ingress, 2)
+	assert.Equal(t, float64(22), ingress[0].Attributes["from_port"])
+	assert.Equal(t, float64(443), ingress[1].Attributes["to_port"])
+	assert.Contains(t, ingress[0].Attributes["cidr_blocks"], "0.0.0.0/0")
+
+	// for_each over another resource cannot be evaluated statically
+	assert.False(t, sg.HasBlock("egress"))
+	assert.True(t, sg.IsUnknown("egress"))
+
+	// Resource meta-arguments are not provider attributes
+	assert.False(t, sg.HasBlock("lifecycle"))
+}
+
+func TestParseFile_UnknownCountKeepsSingleInstance(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	require.NoError(t, os.WriteFile(path, []byte(`
+resource "aws_instance" "web" {
+  count = length(aws_subnet.private)
+  ami   = "ami-${count.index}"
+}
+`), 0o600))
+
+	resources, err := New().ParseFile(path)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "aws_instance.web", resources[0].Address())
+	assert.True(t, resources[0].IsUnknown("ami"))
+}
+
+func TestParseDirectory_FollowsModules(t *testing.T) {
+	p := New()
+	resources, err := p.ParseDirectory("../../testdata/modules")
+	require.NoError(t, err)
+
+	byAddress := make(map[string]model.TerraformResource)
+	for _, r := range resources {
+		byAddress[r.Address()] = r
+	}
+	assert.Len(t, byAddress, 5)
+
+	// Module directories are not parsed a second time as root modules
+	assert.NotContains(t, byAddress, "aws_vpc.main")
+	assert.NotContains(t, byAddress, "aws_s3_bucket.this")
+
+	vpc, ok := byAddress["module.network.aws_vpc.main"]
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.0/16", vpc.Attributes["cidr_block"])
+	assert.Equal(t, "core", vpc.Attributes["tags"].(map[string]interface{})["Name"])
+	assert.Contains(t, vpc.File, filepath.Join("modules", "network", "main.tf"))
+
+	logs, ok := byAddress[`module.buckets["logs"].aws_s3_bucket.this`]
+	require.True(t, ok)
+	assert.Equal(t, "acme-logs", logs.Attributes["bucket"])
+	assert.Contains(t, byAddress, `module.buckets["assets"].aws_s3_bucket.this`)
+
+	// Registry module resolved through .terraform/modules/modules.json
+	registryVPC, ok := byAddress["module.registry_vpc.aws_vpc.this"]
+	require.True(t, ok)
+	assert.Equal(t, "10.1.0.0/16", registryVPC.Attributes["cidr_block"])
+
+	// Module outputs are visible to the calling module
+	flowLog, ok := byAddress["aws_flow_log.network"]
+	require.True(t, ok)
+	assert.True(t, flowLog.IsUnknown("vpc_id"))
+	assert.Equal(t, "core", flowLog.Attributes["tags"].(map[string]interface{})["Network"])
+}
+
+func TestParseDirectory_ModuleCycle(t *testing.T) {
+	write := func(dir, path, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o600))
+	}
+
+	// The directory being analyzed is called back by its own module
+	dir := t.TempDir()
+	write(dir, "main.tf", "module \"mod\" {\n  source = \"./mod\"\n}\n")
+	write(dir, "mod/main.tf", "module \"root\" {\n  source = \"../\"\n}\n")
+	_, err := New().ParseDirectory(dir)
+	assert.EqualError(t, err, "module cycle: "+dir+" -> "+filepath.Join(dir, "mod")+" -> "+dir)
+
+	// Subdirectories that only call each other
+	dir = t.TempDir()
+	write(dir, "a/main.tf", "module \"b\" {\n  source = \"../b\"\n}\n")
+	write(dir, "b/main.tf", "module \"a\" {\n  source = \"../a\"\n}\n")
+	_, err = New().ParseDirectory(dir)
+	assert.ErrorContains(t, err, "module cycle: ")
+}
+
+func TestParseDirectory_TerraformJSON(t *testing.T) {
+	p := New()
+	resources, err := p.ParseDirectory("../../testdata/tfjson")
+	require.NoError(t, err)
+	require.Len(t, resources, 4)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "logs")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "../../testdata/tfjson/main.tf.json", bucket.File)
+	assert.Equal(t, 14, bucket.Line)
+	// Templates resolve locals and variables declared in native syntax
+	assert.Equal(t, "acme-prod-logs", bucket.Attributes["bucket"])
+	// An object whose keys are not argument names is a map attribute
+	assert.Equal(t, map[string]interface{}{"Environment": "prod"}, bucket.Attributes["tags"])
+	assert.False(t, bucket.HasBlock("tags"))
+	versioning := bucket.GetBlocks("versioning")
+	require.Len(t, versioning, 1)
+	assert.Equal(t, true, versioning[0].Attributes["enabled"])
+	assert.NotContains(t, bucket.Attributes, "versioning")
+
+	sg := findPlanResource(resources, "aws_security_group", "web")
+	require.NotNil(t, sg)
+	ingress := sg.GetBlocks("ingress")
+	require.Len(t, ingress, 2)
+	assert.Equal(t, float64(443), ingress[1].Attributes["to_port"])
+	assert.Len(t, sg.GetBlocks("egress"), 1)
+	assert.False(t, sg.HasBlock("lifecycle"))
+
+	var instances []string
+	for _, r := range resources {
+		if r.Type == "aws_instance" {
+			instances = append(instances, r.Address())
+			assert.NotContains(t, r.Attributes, "depends_on")
+		}
+	}
+	assert.Equal(t, []string{"aws_instance.web[0]", "aws_instance.web[1]"}, instances)
+}
+
+func TestParseFile_TerraformJSONBlocksAndMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.tf.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "resource": {
+    "aws_s3_bucket": {
+      "data": {
+        "tags": {"Team": "data", "Logging": "on"},
+        "tags_all": {"owner": "team", "environment": "prod"},
+        "labels": {},
+        "logging": {"target_bucket": "logs", "target_prefix": "data/"},
+        "lifecycle_rule": [{"id": "expire"}, {"id": "archive"}],
+        "website": {"index_document": "index.html"},
+        "permissions": ["READ", "WRITE"]
+      }
+    }
+  }
+}`), 0o600))
+
+	resources, err := New().ParseFile(path)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	bucket := resources[0]
+
+	// Map attributes stay attributes, whatever their keys
+	assert.Equal(t, map[string]interface{}{"Team": "data", "Logging": "on"}, bucket.Attributes["tags"])
+	assert.False(t, bucket.HasBlock("tags"))
+	assert.Equal(t, map[string]interface{}{"owner": "team", "environment": "prod"}, bucket.Attributes["tags_all"])
+	assert.Equal(t, map[string]interface{}{}, bucket.Attributes["labels"])
+
+	// A single object with argument keys is one block
+	logging := bucket.GetBlocks("logging")
+	require.Len(t, logging, 1)
+	assert.Equal(t, "logs", logging[0].Attributes["target_bucket"])
+	assert.NotContains(t, bucket.Attributes, "logging")
+
+	// An array of such objects is one block per object
+	rules := bucket.GetBlocks("lifecycle_rule")
+	require.Len(t, rules, 2)
+	assert.Equal(t, "archive", rules[1].Attributes["id"])
+
+	// Block names need not be known in advance
+	website := bucket.GetBlocks("website")
+	require.Len(t, website, 1)
+	assert.Equal(t, "index.html", website[0].Attributes["index_document"])
+
+	// Values of any other shape are attributes
+	assert.Equal(t, []interface{}{"READ", "WRITE"}, bucket.Attributes["permissions"])
+}
+
+func TestParseDirectory_TerraformJSONMatchesNative(t *testing.T) {
+	native := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(native, "main.tf"), []byte(`
+resource "aws_kms_key" "logs" {}
+
+resource "aws_s3_bucket" "data" {
+  bucket = "data"
+  tags = {
+    owner       = "team"
+    environment = "prod"
+  }
+  tags_all = {
+    Name        = "data"
+    cost-center = "42"
+  }
+  logging {
+    target_bucket = "logs"
+  }
+  website {
+    index_document = "index.html"
+  }
+  lifecycle_rule {
+    id      = "expire"
+    enabled = true
+  }
+  lifecycle_rule {
+    id      = "archive"
+    enabled = false
+  }
+  server_side_encryption_configuration {
+    rule {
+      apply_server_side_encryption_by_default {
+        kms_master_key_id = aws_kms_key.logs.arn
+      }
+    }
+  }
+}
+`), 0o600))
+
+	json := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(json, "main.tf.json"), []byte(`{
+  "resource": {
+    "aws_kms_key": {"logs": {}},
+    "aws_s3_bucket": {
+      "data": {
+        "bucket": "data",
+        "tags": {"owner": "team", "environment": "prod"},
+        "tags_all": {"Name": "data", "cost-center": "42"},
+        "logging": {"target_bucket": "logs"},
+        "website": {"index_document": "index.html"},
+        "lifecycle_rule": [{"id": "expire", "enabled": true}, {"id": "archive", "enabled": false}],
+        "server_side_encryption_configuration": {
+          "rule": {
+            "apply_server_side_encryption_by_default": {"kms_master_key_id": "${aws_kms_key.logs.arn}"}
+          }
+        }
+      }
+    }
+  }
+}`), 0o600))
+
+	fromNative, err := New().ParseDirectory(native)
+	require.NoError(t, err)
+	fromJSON, err := New().ParseDirectory(json)
+	require.NoError(t, err)
+
+	want := findPlanResource(fromNative, "aws_s3_bucket", "data")
+	got := findPlanResource(fromJSON, "aws_s3_bucket", "data")
+	require.NotNil(t, want)
+	require.NotNil(t, got)
+	assert.Equal(t, want.Attributes, got.Attributes)
+	assert.Equal(t, want.Blocks, got.Blocks)
+	assert.Equal(t, want.Unknown, got.Unknown)
+	assert.True(t, got.HasBlock("website"))
+	assert.False(t, got.HasBlock("tags"))
+	assert.False(t, got.HasBlock("tags_all"))
+}
+
+func TestParseFile_TerraformJSONInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.tf.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"resource": {"aws_s3_bucket": {"a": {"bucket": "${"}}}}`), 0o600))
+
+	_, err := New().ParseFile(path)
+	assert.Error(t, err)
+}
+
+func TestParseFile_References(t *testing.T) {
+	resources, err := New().ParseFile("../../testdata/references/main.tf")
+	require.NoError(t, err)
+
+	byAddress := make(map[string]model.TerraformResource)
+	for _, r := range resources {
+		byAddress[r.Address()] = r
+	}
+
+	// An instance key in the expression is kept; count.index is not static
+	assert.Equal(t, map[string][]string{"vpc_id": {"aws_vpc.main[0]"}}, byAddress["aws_flow_log.main"].References)
+	assert.Equal(t, []string{"aws_vpc.main"}, byAddress["aws_subnet.private[1]"].References["vpc_id"])
+
+	// Nested block attributes are keyed by path; variables are not resources
+	assert.Equal(t, map[string][]string{"logging.target_bucket": {"aws_s3_bucket.logs"}}, byAddress["aws_s3_bucket.data"].References)
+
+	policy := byAddress["aws_s3_bucket_policy.data"]
+	assert.Equal(t, []string{"aws_s3_bucket.data"}, policy.References["bucket"])
+	assert.Equal(t, []string{"data.aws_iam_policy_document.data"}, policy.References["policy"])
+	assert.Equal(t, []string{"aws_s3_bucket.data"}, byAddress["data.aws_iam_policy_document.data"].References["statement.resources"])
+
+	assert.Nil(t, byAddress["aws_s3_bucket.logs"].References)
+}
@@ -2,19 +2,11 @@
 package parser
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
+	"context"
 
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
-// planJSON represents the top-level structure of `terraform show -json plan.bin`.
-type planJSON struct {
-	PlannedValues   *plannedValues   `json:"planned_values"`
-	ResourceChanges []resourceChange `json:"resource_changes"`
-}
-
 // resourceChange describes the planned action for a resource.
 type resourceChange struct {
 	Address string       `json:"address"`
@@ -23,8 +15,13 @@ type resourceChange struct {
 
 // changeDetail holds the list of actions for a resource change.
 // Common values: ["create"], ["update"], ["delete"], ["no-op"], ["create", "delete"].
+// Before holds the resource's values prior to the change. AfterUnknown mirrors
+// the structure of the planned values, with true at every path whose value is
+// only known after apply.
 type changeDetail struct {
-	Actions []string `json:"actions"`
+	Actions      []string               `json:"actions"`
+	Before       map[string]interface{} `json:"before"`
+	AfterUnknown interface{}            `json:"after_unknown"`
 }
 
 type plannedValues struct {
@@ -44,90 +41,167 @@ type planResource struct {
 	Values  map[string]interface{} `json:"values"`
 }
 
-// ParsePlanFile parses a Terraform plan JSON file and returns resources.
-func ParsePlanFile(path string) ([]model.TerraformResource, error) {
-	data, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
-	if err != nil {
-		return nil, fmt.Errorf("reading plan file: %w", err)
-	}
-
-	var plan planJSON
-	if err := json.Unmarshal(data, &plan); err != nil {
-		return nil, fmt.Errorf("parsing plan JSON: %w", err)
-	}
-
-	if plan.PlannedValues == nil || plan.PlannedValues.RootModule == nil {
-		return nil, nil
-	}
+// PlanOptions controls optional plan parsing behaviour.
+type PlanOptions struct {
+	// SourceRoot is the root module directory the plan was created from. When set,
+	// resources get the file and line of the block that declares them instead of
+	// the "tfplan" placeholder.
+	SourceRoot string
+	// LocateFailed, when set, receives the error of a failed SourceRoot
+	// lookup, and resources keep the placeholder location instead of the plan
+	// failing to parse.
+	LocateFailed func(error)
+}
 
-	// Build a set of resource addresses whose only planned action is "delete".
-	// These resources are being destroyed and should not be analyzed.
-	destroyOnly := buildDestroySet(plan.ResourceChanges)
+// ParsePlanFile parses a Terraform plan file and returns resources.
+// Both plan JSON and the binary file written by `terraform plan -out` are accepted.
+func ParsePlanFile(ctx context.Context, path string) ([]model.TerraformResource, error) {
+	return ParsePlanFileWithOptions(ctx, path, PlanOptions{})
+}
 
+// ParsePlanFileWithOptions parses a Terraform plan file using the given options.
+func ParsePlanFileWithOptions(ctx context.Context, path string, opts PlanOptions) ([]model.TerraformResource, error) {
 	var resources []model.TerraformResource
-	collectResources(plan.PlannedValues.RootModule, destroyOnly, &resources)
+	err := StreamPlanFile(ctx, path, opts, func(res model.TerraformResource) error {
+		resources = append(resources, res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return resources, nil
 }
 
-// buildDestroySet returns a set of addresses where the only planned action is "delete".
-func buildDestroySet(changes []resourceChange) map[string]bool {
-	set := make(map[string]bool, len(changes))
-	for _, c := range changes {
-		if len(c.Change.Actions) == 1 && c.Change.Actions[0] == "delete" {
-			set[c.Address] = true
+// planAction maps a plan's action list to a single action. Terraform plans a
+// replacement as ["delete", "create"] or ["create", "delete"].
+func planAction(actions []string) model.Action {
+	switch len(actions) {
+	case 1:
+		return model.Action(actions[0])
+	case 2:
+		if (actions[0] == "delete" && actions[1] == "create") || (actions[0] == "create" && actions[1] == "delete") {
+			return model.ActionReplace
 		}
 	}
-	return set
+	return ""
 }
 
-// collectResources walks the module tree depth-first, collecting all resources.
-// Resources whose address appears in destroyOnly are skipped.
-func collectResources(mod *planModule, destroyOnly map[string]bool, out *[]model.TerraformResource) {
+// collectResources walks the module tree depth-first, collecting all resources
+// together with their planned change.
+func collectResources(mod *planModule, changes map[string]changeDetail, out *[]model.TerraformResource) {
 	for _, r := range mod.Resources {
-		if destroyOnly[r.Address] {
-			continue
+		if res, ok := convertPlannedResource(r, changes[r.Address]); ok {
+			*out = append(*out, res)
 		}
-		*out = append(*out, convertPlanResource(r))
 	}
 	for i := range mod.ChildModules {
-		collectResources(&mod.ChildModules[i], destroyOnly, out)
+		collectResources(&mod.ChildModules[i], changes, out)
 	}
 }
 
+// convertPlannedResource converts a planned resource and its change. It
+// returns false for resources whose only planned action is "delete": they are
+// being destroyed and are not analyzed.
+func convertPlannedResource(r planResource, change changeDetail) (model.TerraformResource, bool) {
+	action := planAction(change.Actions)
+	if action == model.ActionDelete {
+		return model.TerraformResource{}, false
+	}
+
+	afterUnknown, _ := change.AfterUnknown.(map[string]interface{})
+	res := convertPlanResource(r, afterUnknown)
+	res.Action = action
+	if (action == model.ActionUpdate || action == model.ActionReplace) && change.Before != nil {
+		prior := r
+		prior.Values = change.Before
+		before := convertPlanResource(prior, nil)
+		res.Before = &before
+	}
+	return res, true
+}
+
 // convertPlanResource converts a plan JSON resource to a TerraformResource.
-func convertPlanResource(r planResource) model.TerraformResource {
+// afterUnknown is the resource's after_unknown object, which may be nil.
+func convertPlanResource(r planResource, afterUnknown map[string]interface{}) model.TerraformResource {
 	resType := r.Type
 	if r.Mode == "data" {
 		resType = "data." + r.Type
 	}
 
+	attrs, blocks, unknown := convertValues(r.Values, afterUnknown)
+
+	return model.TerraformResource{
+		Type:        resType,
+		Name:        r.Name,
+		File:        "tfplan",
+		Line:        0,
+		FullAddress: r.Address,
+		Attributes:  attrs,
+		Blocks:      blocks,
+		Unknown:     unknown,
+	}
+}
+
+// convertValues splits plan values into attributes and nested blocks, and
+// returns the set of keys that after_unknown marks as wholly or partly
+// known only after apply.
+func convertValues(values, afterUnknown map[string]interface{}) (map[string]interface{}, map[string][]model.Block, map[string]bool) {
 	attrs := make(map[string]interface{})
 	blocks := make(map[string][]model.Block)
+	var unknown map[string]bool
 
-	for key, val := range r.Values {
-		// Skip null values: these represent "(known after apply)" attributes in the plan.
-		// Rules must treat a missing attribute as "not configured", not as a misconfiguration.
+	for key, u := range afterUnknown {
+		if containsUnknown(u) {
+			if unknown == nil {
+				unknown = make(map[string]bool)
+			}
+			unknown[key] = true
+		}
+	}
+
+	for key, val := range values {
+		// Skip null values: an attribute that is null and not in after_unknown is
+		// simply not set, and rules treat a missing attribute as "not configured".
 		if val == nil {
 			continue
 		}
 		if isBlockValue(val) {
 			if arr, ok := val.([]interface{}); ok {
-				blocks[key] = convertBlocks(key, arr)
+				itemsUnknown, _ := afterUnknown[key].([]interface{})
+				blocks[key] = convertBlocks(key, arr, itemsUnknown)
+				// Unknown attributes inside a block are recorded on the block itself.
+				delete(unknown, key)
 			}
 		} else {
 			attrs[key] = val
 		}
 	}
+	if len(unknown) == 0 {
+		unknown = nil
+	}
 
-	return model.TerraformResource{
-		Type:        resType,
-		Name:        r.Name,
-		File:        "tfplan",
-		Line:        0,
-		FullAddress: r.Address,
-		Attributes:  attrs,
-		Blocks:      blocks,
+	return attrs, blocks, unknown
+}
+
+// containsUnknown reports whether an after_unknown value marks anything as unknown.
+func containsUnknown(u interface{}) bool {
+	switch v := u.(type) {
+	case bool:
+		return v
+	case []interface{}:
+		for _, item := range v {
+			if containsUnknown(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsUnknown(item) {
+				return true
+			}
+		}
 	}
+	return false
 }
 
 // isBlockValue returns true if the value looks like a Terraform block:
@@ -142,31 +216,26 @@ func isBlockValue(v interface{}) bool {
 }
 
 // convertBlocks converts an array of maps into model.Block slices.
-func convertBlocks(blockType string, items []interface{}) []model.Block {
+// itemsUnknown holds the after_unknown object of each item, if any.
+func convertBlocks(blockType string, items []interface{}, itemsUnknown []interface{}) []model.Block {
 	var blocks []model.Block
-	for _, item := range items {
+	for i, item := range items {
 		m, ok := item.(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		attrs := make(map[string]interface{})
-		nested := make(map[string][]model.Block)
-
-		for key, val := range m {
-			if isBlockValue(val) {
-				if arr, ok := val.([]interface{}); ok {
-					nested[key] = convertBlocks(key, arr)
-				}
-			} else {
-				attrs[key] = val
-			}
+		var afterUnknown map[string]interface{}
+		if i < len(itemsUnknown) {
+			afterUnknown, _ = itemsUnknown[i].(map[string]interface{})
 		}
+		attrs, nested, unknown := convertValues(m, afterUnknown)
 
 		blocks = append(blocks, model.Block{
 			Type:       blockType,
 			Attributes: attrs,
 			Blocks:     nested,
+			Unknown:    unknown,
 		})
 	}
 	return blocks
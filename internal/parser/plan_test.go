@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,7 +13,7 @@ import (
 )
 
 func TestParsePlanFile_SamplePlan(t *testing.T) {
-	resources, err := ParsePlanFile("../../testdata/plan/sample.json")
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/sample.json")
 	require.NoError(t, err)
 
 	// Root: 3 resources (s3_bucket, instance, data source)
@@ -75,17 +78,17 @@ func TestParsePlanFile_SamplePlan(t *testing.T) {
 }
 
 func TestParsePlanFile_NotFound(t *testing.T) {
-	_, err := ParsePlanFile("nonexistent.json")
+	_, err := ParsePlanFile(context.Background(), "nonexistent.json")
 	assert.Error(t, err)
 }
 
 func TestParsePlanFile_InvalidJSON(t *testing.T) {
-	_, err := ParsePlanFile("../../go.mod")
+	_, err := ParsePlanFile(context.Background(), "../../go.mod")
 	assert.Error(t, err)
 }
 
 func TestParsePlanFile_EmptyPlan(t *testing.T) {
-	resources, err := ParsePlanFile("../../testdata/plan/empty.json")
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/empty.json")
 	require.NoError(t, err)
 	assert.Len(t, resources, 0)
 }
@@ -98,3 +101,180 @@ func findPlanResource(resources []model.TerraformResource, resType, name string)
 	}
 	return nil
 }
+
+func TestParsePlanFile_AfterUnknown(t *testing.T) {
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/after_unknown.json")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	// Computed attributes are unknown; attributes that are null but not in
+	// after_unknown are simply not set.
+	bucket := findPlanResource(resources, "aws_s3_bucket", "logs")
+	require.NotNil(t, bucket)
+	assert.True(t, bucket.IsUnknown("arn"))
+	assert.True(t, bucket.IsUnknown("id"))
+	assert.False(t, bucket.IsUnknown("object_lock_enabled"))
+	assert.False(t, bucket.IsUnknown("bucket"))
+
+	// Unknown attributes inside blocks are recorded on the block.
+	enc := findPlanResource(resources, "aws_s3_bucket_server_side_encryption_configuration", "logs")
+	require.NotNil(t, enc)
+	assert.True(t, enc.IsUnknown("bucket"))
+	assert.False(t, enc.IsUnknown("rule"))
+	rules := enc.GetBlocks("rule")
+	require.Len(t, rules, 1)
+	byDefault := rules[0].Blocks["apply_server_side_encryption_by_default"]
+	require.Len(t, byDefault, 1)
+	assert.True(t, byDefault[0].IsUnknown("kms_master_key_id"))
+	assert.Equal(t, "aws:kms", byDefault[0].Attributes["sse_algorithm"])
+
+	// Partially unknown collections are kept and marked unknown.
+	db := findPlanResource(resources, "aws_db_instance", "main")
+	require.NotNil(t, db)
+	assert.True(t, db.IsUnknown("storage_encrypted"))
+	assert.NotContains(t, db.Attributes, "storage_encrypted")
+	assert.True(t, db.IsUnknown("vpc_security_group_ids"))
+	assert.Equal(t, []interface{}{"sg-123", nil}, db.Attributes["vpc_security_group_ids"])
+	assert.False(t, db.IsUnknown("engine"))
+}
+
+func TestParsePlanFile_Actions(t *testing.T) {
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/actions.json")
+	require.NoError(t, err)
+
+	// Pure deletes are dropped.
+	assert.Len(t, resources, 4)
+	assert.Nil(t, findPlanResource(resources, "aws_s3_bucket", "destroyed"))
+
+	for name, action := range map[string]model.Action{
+		"created":   model.ActionCreate,
+		"updated":   model.ActionUpdate,
+		"replaced":  model.ActionReplace,
+		"unchanged": model.ActionNoOp,
+	} {
+		r := findPlanResource(resources, "aws_s3_bucket", name)
+		require.NotNil(t, r, name)
+		assert.Equal(t, action, r.Action, name)
+		assert.Equal(t, action != model.ActionNoOp, r.IsChanged(), name)
+	}
+}
+
+func TestParsePlanFile_Before(t *testing.T) {
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/regression.json")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	db := findPlanResource(resources, "aws_db_instance", "main")
+	require.NotNil(t, db)
+	require.NotNil(t, db.Before)
+	assert.Equal(t, "aws_db_instance.main", db.Before.Address())
+	assert.Equal(t, true, db.Before.Attributes["deletion_protection"])
+	assert.Equal(t, false, db.Attributes["deletion_protection"])
+
+	sg := findPlanResource(resources, "aws_security_group", "web")
+	require.NotNil(t, sg)
+	require.NotNil(t, sg.Before)
+	require.Len(t, sg.Before.GetBlocks("ingress"), 1)
+
+	// Created resources have no prior version.
+	bucket := findPlanResource(resources, "aws_s3_bucket", "new")
+	require.NotNil(t, bucket)
+	assert.Nil(t, bucket.Before)
+}
+
+func TestParsePlanFileWithOptions_LocatesSources(t *testing.T) {
+	resources, err := ParsePlanFileWithOptions(context.Background(), "../../testdata/plan/with_config.json", PlanOptions{
+		SourceRoot: "../../testdata/plan/source",
+	})
+	require.NoError(t, err)
+	require.Len(t, resources, 7)
+
+	tests := []struct {
+		resType, name string
+		file          string
+		line          int
+	}{
+		{"aws_s3_bucket", "root_bucket", "main.tf", 1},
+		{"data.aws_iam_policy_document", "example", "main.tf", 10},
+		{"aws_subnet", "public", filepath.Join("modules", "vpc", "main.tf"), 5},
+		{"aws_security_group", "default", filepath.Join("modules", "security", "main.tf"), 1},
+		{"aws_eks_cluster", "this", filepath.Join(".terraform", "modules", "eks", "main.tf"), 1},
+	}
+	for _, tt := range tests {
+		res := findPlanResource(resources, tt.resType, tt.name)
+		require.NotNil(t, res, tt.resType)
+		assert.Equal(t, filepath.Join("../../testdata/plan/source", tt.file), res.File, tt.resType)
+		assert.Equal(t, tt.line, res.Line, tt.resType)
+	}
+}
+
+func TestParsePlanFileWithOptions_LocatesSourcesWithoutConfiguration(t *testing.T) {
+	resources, err := ParsePlanFileWithOptions(context.Background(), "../../testdata/plan/sample.json", PlanOptions{
+		SourceRoot: "../../testdata/plan/source",
+	})
+	require.NoError(t, err)
+
+	sg := findPlanResource(resources, "aws_security_group", "default")
+	require.NotNil(t, sg)
+	assert.Equal(t, filepath.Join("../../testdata/plan/source", "modules", "security", "main.tf"), sg.File)
+	assert.Equal(t, 1, sg.Line)
+}
+
+func TestParsePlanFileWithOptions_UnmatchedKeepsPlaceholder(t *testing.T) {
+	resources, err := ParsePlanFileWithOptions(context.Background(), "../../testdata/plan/sample.json", PlanOptions{
+		SourceRoot: "../../testdata/s3",
+	})
+	require.NoError(t, err)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "root_bucket")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "tfplan", bucket.File)
+	assert.Equal(t, 0, bucket.Line)
+}
+
+func TestParsePlanFileWithOptions_BrokenSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.tf"), []byte("resource \"aws_s3_bucket\" \"x\" {\n"), 0o600))
+
+	_, err := ParsePlanFileWithOptions(context.Background(), "../../testdata/plan/sample.json", PlanOptions{SourceRoot: dir})
+	assert.ErrorContains(t, err, "locating resources in "+dir+": parsing ")
+
+	// With LocateFailed the failure is passed on and the plan still parses
+	var locateErr error
+	resources, err := ParsePlanFileWithOptions(context.Background(), "../../testdata/plan/sample.json", PlanOptions{
+		SourceRoot:   dir,
+		LocateFailed: func(err error) { locateErr = err },
+	})
+	require.NoError(t, err)
+	assert.ErrorContains(t, locateErr, "locating resources in "+dir)
+	bucket := findPlanResource(resources, "aws_s3_bucket", "root_bucket")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "tfplan", bucket.File)
+	assert.Equal(t, 0, bucket.Line)
+}
+
+func TestStripInstanceKeys(t *testing.T) {
+	assert.Equal(t, "aws_s3_bucket.b", stripInstanceKeys("aws_s3_bucket.b"))
+	assert.Equal(t, "aws_instance.web", stripInstanceKeys("aws_instance.web[0]"))
+	assert.Equal(t, "module.a.aws_s3_bucket.b", stripInstanceKeys(`module.a["x.y]"].aws_s3_bucket.b[1]`))
+}
+
+func TestParsePlanFile_References(t *testing.T) {
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/with_config.json")
+	require.NoError(t, err)
+
+	// References inside a module are addressed from the root module
+	subnet := findPlanResource(resources, "aws_subnet", "public")
+	require.NotNil(t, subnet)
+	assert.Equal(t, map[string][]string{"vpc_id": {"module.vpc.aws_vpc.this"}}, subnet.References)
+
+	// Nested block attributes are keyed by path
+	web := findPlanResource(resources, "aws_instance", "web")
+	require.NotNil(t, web)
+	assert.Equal(t, map[string][]string{"network_interface.network_interface_id": {"aws_network_interface.web"}}, web.References)
+
+	// Constant values are not references
+	bucket := findPlanResource(resources, "aws_s3_bucket", "root_bucket")
+	require.NotNil(t, bucket)
+	assert.Nil(t, bucket.References)
+}
@@ -0,0 +1,238 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// nonResourceRoots are the root names of references that do not name a
+// resource, e.g. var.region or each.value.
+var nonResourceRoots = map[string]bool{
+	"var":       true,
+	"local":     true,
+	"module":    true,
+	"each":      true,
+	"count":     true,
+	"path":      true,
+	"terraform": true,
+	"self":      true,
+}
+
+// configReferences returns the resource references of every resource in a
+// plan's configuration, keyed by configuration address without instance keys
+// (e.g. "module.vpc.aws_subnet.public") and then by attribute path. The
+// referenced addresses are relative to the resource's module.
+func configReferences(config *planConfiguration) map[string]map[string][]string {
+	refs := make(map[string]map[string][]string)
+	if config != nil {
+		collectConfigReferences(config.RootModule, "", refs)
+	}
+	return refs
+}
+
+func collectConfigReferences(mod *configModule, prefix string, out map[string]map[string][]string) {
+	if mod == nil {
+		return
+	}
+	for _, r := range mod.Resources {
+		attrs := make(map[string][]string)
+		for name, raw := range r.Expressions {
+			expressionReferences(raw, name, attrs)
+		}
+		if len(attrs) > 0 {
+			out[prefix+r.Address] = attrs
+		}
+	}
+	for name, call := range mod.ModuleCalls {
+		collectConfigReferences(call.Module, prefix+"module."+name+".", out)
+	}
+}
+
+// expressionReferences records the resource references of one entry of a
+// configuration's expressions object: an expression with a references list,
+// or a nested block type whose blocks are expressions objects themselves.
+func expressionReferences(raw json.RawMessage, path string, out map[string][]string) {
+	var expr struct {
+		References []string `json:"references"`
+	}
+	if err := json.Unmarshal(raw, &expr); err == nil {
+		for _, ref := range expr.References {
+			if addr, ok := resourceReference(ref); ok {
+				addReference(out, path, addr)
+			}
+		}
+		return
+	}
+
+	var blocks []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return
+	}
+	for _, block := range blocks {
+		for name, nested := range block {
+			expressionReferences(nested, path+"."+name, out)
+		}
+	}
+}
+
+// planReferences sets the references of a plan resource from its
+// configuration, prefixed with the resource's module instance address.
+func planReferences(res *model.TerraformResource, refs map[string]map[string][]string) {
+	attrs, ok := refs[stripInstanceKeys(res.Address())]
+	if !ok {
+		return
+	}
+	prefix := modulePrefix(res.Address())
+	res.References = make(map[string][]string, len(attrs))
+	for attr, addrs := range attrs {
+		for _, addr := range addrs {
+			res.References[attr] = append(res.References[attr], prefix+addr)
+		}
+	}
+	if res.Before != nil {
+		res.Before.References = res.References
+	}
+}
+
+// bodyReferences returns the resource references of the attributes of an HCL
+// body and its nested blocks, prefixed with the module address prefix.
+// Attributes and block types listed in skip are ignored.
+func bodyReferences(body *hclsyntax.Body, prefix string, skip map[string]bool) map[string][]string {
+	refs := make(map[string][]string)
+	collectBodyReferences(body, "", prefix, skip, refs)
+	if len(refs) == 0 {
+		return nil
+	}
+	return refs
+}
+
+func collectBodyReferences(body *hclsyntax.Body, path, prefix string, skip map[string]bool, out map[string][]string) {
+	for name, attr := range body.Attributes {
+		if skip[name] {
+			continue
+		}
+		for _, traversal := range attr.Expr.Variables() {
+			if addr, ok := resourceReference(traversalString(traversal)); ok {
+				addReference(out, path+name, prefix+addr)
+			}
+		}
+	}
+	for _, block := range body.Blocks {
+		if skip[block.Type] {
+			continue
+		}
+		if block.Type == "dynamic" && len(block.Labels) == 1 {
+			for _, b := range block.Body.Blocks {
+				if b.Type == "content" {
+					collectBodyReferences(b.Body, path+block.Labels[0]+".", prefix, nil, out)
+				}
+			}
+			continue
+		}
+		collectBodyReferences(block.Body, path+block.Type+".", prefix, nil, out)
+	}
+}
+
+// resourceReference returns the address of the resource a reference names,
+// e.g. "aws_vpc.main" for "aws_vpc.main.id". The instance key is kept when the
+// reference names one. It returns false for references to anything else.
+func resourceReference(ref string) (string, bool) {
+	parts := splitAddress(ref)
+	switch {
+	case len(parts) >= 3 && parts[0] == "data":
+		return strings.Join(parts[:3], "."), true
+	case len(parts) >= 2 && !nonResourceRoots[parts[0]] && parts[0] != "data":
+		return parts[0] + "." + parts[1], true
+	default:
+		return "", false
+	}
+}
+
+// traversalString renders a traversal in reference syntax, e.g.
+// `aws_s3_bucket.logs["a"].id`. Rendering stops at the first step that has no
+// static form, such as a splat.
+func traversalString(traversal hcl.Traversal) string {
+	var sb strings.Builder
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			sb.WriteString(s.Name)
+		case hcl.TraverseAttr:
+			sb.WriteString("." + s.Name)
+		case hcl.TraverseIndex:
+			switch {
+			case !s.Key.IsKnown() || s.Key.IsNull():
+				return sb.String()
+			case s.Key.Type() == cty.String:
+				fmt.Fprintf(&sb, "[%q]", s.Key.AsString())
+			case s.Key.Type() == cty.Number:
+				sb.WriteString("[" + s.Key.AsBigFloat().Text('f', -1) + "]")
+			default:
+				return sb.String()
+			}
+		default:
+			return sb.String()
+		}
+	}
+	return sb.String()
+}
+
+// modulePrefix returns the module instance part of a resource address with a
+// trailing dot, e.g. `module.a["x"].` for `module.a["x"].aws_vpc.main`.
+func modulePrefix(addr string) string {
+	parts := splitAddress(addr)
+	n := 0
+	for n+1 < len(parts) && parts[n] == "module" {
+		n += 2
+	}
+	if n == 0 {
+		return ""
+	}
+	return strings.Join(parts[:n], ".") + "."
+}
+
+// splitAddress splits an address or reference on the dots outside instance
+// keys, so `module.a["x.y"].aws_vpc.main` has four parts.
+func splitAddress(addr string) []string {
+	var parts []string
+	start, depth := 0, 0
+	inString := false
+	for i := 0; i < len(addr); i++ {
+		ch := addr[i]
+		switch {
+		case inString:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"' && depth > 0:
+			inString = true
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		case ch == '.' && depth == 0:
+			parts = append(parts, addr[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, addr[start:])
+}
+
+// addReference adds addr to the references of attr unless already present.
+func addReference(refs map[string][]string, attr, addr string) {
+	for _, existing := range refs[attr] {
+		if existing == addr {
+			return
+		}
+	}
+	refs[attr] = append(refs[attr], addr)
+}
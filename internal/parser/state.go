@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// Format identifies the kind of Terraform JSON document in a file.
+type Format string
+
+const (
+	FormatPlan  Format = "plan"  // `terraform show -json plan.bin`
+	FormatState Format = "state" // `terraform show -json` or a raw terraform.tfstate
+)
+
+// stateJSON represents the output of `terraform show -json` for a state.
+type stateJSON struct {
+	Values *struct {
+		RootModule *stateModule `json:"root_module"`
+	} `json:"values"`
+}
+
+// tfstateV4 represents a raw terraform.tfstate file (state format version 4).
+type tfstateV4 struct {
+	Version   int               `json:"version"`
+	Resources []tfstateResource `json:"resources"`
+}
+
+type tfstateResource struct {
+	Module    string            `json:"module"`
+	Mode      string            `json:"mode"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Instances []tfstateInstance `json:"instances"`
+}
+
+type tfstateInstance struct {
+	IndexKey   interface{}            `json:"index_key"`
+	Status     string                 `json:"status"`
+	Deposed    string                 `json:"deposed"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// stateResource is a resource of `terraform show -json` state output, with
+// the fields marking objects that are not the live instance.
+type stateResource struct {
+	planResource
+	Tainted    bool   `json:"tainted"`
+	DeposedKey string `json:"deposed_key"`
+}
+
+// stateModule is a module of `terraform show -json` state output.
+type stateModule struct {
+	Resources    []stateResource `json:"resources"`
+	ChildModules []stateModule   `json:"child_modules"`
+}
+
+// DetectFormat reports whether a file holds a plan or a state. Plans are
+// binary plan files or JSON with planned_values or resource_changes; state is
+// recognised by the values of `terraform show -json` or the version and
+// resources of a raw .tfstate.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	return detectFormat(bufio.NewReader(f))
+}
+
+// detectFormat reads only the top-level keys of a JSON document, skipping
+// their values, so large plans are not loaded to detect their format.
+func detectFormat(r *bufio.Reader) (Format, error) {
+	if magic, _ := r.Peek(len(zipMagic)); isBinaryPlan(magic) {
+		return FormatPlan, nil
+	}
+
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return "", fmt.Errorf("not a Terraform JSON document")
+	}
+
+	var hasValues, hasVersion, hasResources bool
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("parsing JSON: %w", err)
+		}
+		switch tok {
+		case "planned_values", "resource_changes":
+			return FormatPlan, nil
+		case "values":
+			hasValues = true
+		case "version":
+			hasVersion = true
+		case "resources":
+			hasResources = true
+		}
+		if err := skipValue(dec); err != nil {
+			return "", fmt.Errorf("parsing JSON: %w", err)
+		}
+	}
+
+	if hasValues || (hasVersion && hasResources) {
+		return FormatState, nil
+	}
+	return FormatPlan, nil
+}
+
+// ParseStateFile parses Terraform state, either the JSON printed by
+// `terraform show -json` or a raw version 4 .tfstate file, and returns the
+// deployed resources. Deposed and tainted objects are skipped: the next apply
+// destroys them, and a deposed object shares its address with the live one.
+func ParseStateFile(path string) ([]model.TerraformResource, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+
+	var raw tfstateV4
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing state JSON: %w", err)
+	}
+	if raw.Version != 0 {
+		if raw.Version != 4 {
+			return nil, fmt.Errorf("unsupported state file version %d (expected 4)", raw.Version)
+		}
+		return convertStateV4(raw.Resources), nil
+	}
+
+	var state stateJSON
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing state JSON: %w", err)
+	}
+	if state.Values == nil || state.Values.RootModule == nil {
+		return nil, nil
+	}
+
+	var resources []model.TerraformResource
+	collectStateResources(state.Values.RootModule, &resources)
+	return resources, nil
+}
+
+// collectStateResources converts the live resources of a state module and its
+// children.
+func collectStateResources(mod *stateModule, out *[]model.TerraformResource) {
+	for _, r := range mod.Resources {
+		if r.Tainted || r.DeposedKey != "" {
+			continue
+		}
+		res := convertPlanResource(r.planResource, nil)
+		res.File = "tfstate"
+		*out = append(*out, res)
+	}
+	for i := range mod.ChildModules {
+		collectStateResources(&mod.ChildModules[i], out)
+	}
+}
+
+// convertStateV4 converts the resources of a raw .tfstate file, one
+// TerraformResource per instance.
+func convertStateV4(stateResources []tfstateResource) []model.TerraformResource {
+	var resources []model.TerraformResource
+	for _, r := range stateResources {
+		prefix := ""
+		if r.Module != "" {
+			prefix = r.Module + "."
+		}
+		if r.Mode == "data" {
+			prefix += "data."
+		}
+
+		for _, inst := range r.Instances {
+			if inst.Status == "tainted" || inst.Deposed != "" {
+				continue
+			}
+			res := convertPlanResource(planResource{
+				Address: prefix + r.Type + "." + r.Name + instanceKey(inst.IndexKey),
+				Mode:    r.Mode,
+				Type:    r.Type,
+				Name:    r.Name,
+				Values:  inst.Attributes,
+			}, nil)
+			res.File = "tfstate"
+			resources = append(resources, res)
+		}
+	}
+	return resources
+}
+
+// instanceKey formats a state instance's index_key as an address suffix:
+// `[0]` for count, `["key"]` for for_each, and nothing for single instances.
+func instanceKey(key interface{}) string {
+	switch k := key.(type) {
+	case float64:
+		return fmt.Sprintf("[%d]", int(k))
+	case string:
+		return fmt.Sprintf("[%q]", k)
+	default:
+		return ""
+	}
+}
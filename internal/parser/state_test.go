@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateFile_ShowJSON(t *testing.T) {
+	resources, err := ParseStateFile("../../testdata/state/show.json")
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "logs")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "aws_s3_bucket.logs", bucket.Address())
+	assert.Equal(t, "tfstate", bucket.File)
+	assert.Equal(t, "my-logs-bucket", bucket.Attributes["bucket"])
+	assert.Empty(t, bucket.Action)
+
+	db := findPlanResource(resources, "aws_db_instance", "main")
+	require.NotNil(t, db)
+	assert.Equal(t, "module.db.aws_db_instance.main", db.Address())
+	assert.Equal(t, false, db.Attributes["storage_encrypted"])
+
+	assert.NotNil(t, findPlanResource(resources, "data.aws_caller_identity", "current"))
+}
+
+func TestParseStateFile_V4(t *testing.T) {
+	resources, err := ParseStateFile("../../testdata/state/terraform.tfstate")
+	require.NoError(t, err)
+	assert.Len(t, resources, 5)
+
+	var addrs []string
+	for _, r := range resources {
+		addrs = append(addrs, r.Address())
+		assert.Equal(t, "tfstate", r.File)
+	}
+	assert.ElementsMatch(t, []string{
+		"aws_s3_bucket.logs",
+		"aws_subnet.private[0]",
+		"aws_subnet.private[1]",
+		`module.db.aws_db_instance.main["primary"]`,
+		"data.aws_caller_identity.current",
+	}, addrs)
+
+	// Nested blocks use the same layout as plan values.
+	bucket := findPlanResource(resources, "aws_s3_bucket", "logs")
+	require.NotNil(t, bucket)
+	assert.True(t, bucket.HasBlock("server_side_encryption_configuration"))
+}
+
+func TestParseStateFile_SkipsDeposedAndTainted(t *testing.T) {
+	for _, path := range []string{"../../testdata/state/replacing.tfstate", "../../testdata/state/replacing.json"} {
+		resources, err := ParseStateFile(path)
+		require.NoError(t, err)
+
+		var addrs []string
+		for _, r := range resources {
+			addrs = append(addrs, r.Address())
+		}
+		assert.Equal(t, []string{"aws_db_instance.main", "aws_instance.web[0]"}, addrs, path)
+
+		db := findPlanResource(resources, "aws_db_instance", "main")
+		require.NotNil(t, db)
+		assert.Equal(t, "main-new", db.Attributes["identifier"], path)
+	}
+}
+
+func TestParseStateFile_UnsupportedVersion(t *testing.T) {
+	_, err := ParseStateFile("../../testdata/state/v3.tfstate")
+	assert.ErrorContains(t, err, "unsupported state file version 3")
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]Format{
+		"../../testdata/plan/sample.json":        FormatPlan,
+		"../../testdata/plan/empty.json":         FormatPlan,
+		"../../testdata/state/show.json":         FormatState,
+		"../../testdata/state/terraform.tfstate": FormatState,
+	}
+	for path, want := range tests {
+		got, err := DetectFormat(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	_, err := DetectFormat("../../testdata/plan/nonexistent.json")
+	assert.Error(t, err)
+}
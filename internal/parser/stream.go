@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// StreamPlanFile parses a Terraform plan file and calls fn with each resource
+// as it is decoded, so the full resource set is never held in memory. The
+// resources and their order are the same as ParsePlanFileWithOptions returns.
+//
+// Plan JSON is read in two passes with a token-level decoder. The first
+// collects each resource's change (action, unknown values, prior values for
+// updates) and the configuration, which gives each resource its references;
+// the second decodes planned_values one resource at a time. Binary plans are compressed and are decoded whole.
+// Parsing stops with ctx's error once ctx is done.
+func StreamPlanFile(ctx context.Context, path string, opts PlanOptions, fn func(model.TerraformResource) error) error {
+	f, err := os.Open(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return fmt.Errorf("reading plan file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewReader(f)
+	if magic, _ := r.Peek(len(zipMagic)); isBinaryPlan(magic) {
+		return streamBinaryPlan(ctx, r, opts, fn)
+	}
+
+	changes, config, err := readPlanChanges(ctx, r)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return fmt.Errorf("parsing plan JSON: %w", err)
+	}
+	refs := configReferences(config)
+
+	locations, err := planLocations(opts, config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading plan file: %w", err)
+	}
+	r.Reset(f)
+
+	var fnErr error
+	err = readPlannedValues(r, func(pr planResource) bool {
+		res, ok := convertPlannedResource(pr, changes[pr.Address])
+		if !ok {
+			return true
+		}
+		planReferences(&res, refs)
+		locate(&res, locations)
+		if fnErr = ctx.Err(); fnErr == nil {
+			fnErr = fn(res)
+		}
+		return fnErr == nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("parsing plan JSON: %w", err)
+	}
+	return nil
+}
+
+// streamBinaryPlan decodes a binary plan and passes its resources to fn.
+func streamBinaryPlan(ctx context.Context, r io.Reader, opts PlanOptions, fn func(model.TerraformResource) error) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading plan file: %w", err)
+	}
+	resources, err := parseBinaryPlan(data)
+	if err != nil {
+		return fmt.Errorf("parsing binary plan: %w", err)
+	}
+
+	locations, err := planLocations(opts, nil)
+	if err != nil {
+		return err
+	}
+	for i := range resources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		locate(&resources[i], locations)
+		if err := fn(resources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planLocations indexes the declarations under opts.SourceRoot, if set. A
+// failed lookup is an error unless opts.LocateFailed takes it.
+func planLocations(opts PlanOptions, config *planConfiguration) (map[string]sourceLocation, error) {
+	if opts.SourceRoot == "" {
+		return nil, nil
+	}
+	locations, err := sourceLocations(opts.SourceRoot, config)
+	if err != nil {
+		err = fmt.Errorf("locating resources in %s: %w", opts.SourceRoot, err)
+		if opts.LocateFailed == nil {
+			return nil, err
+		}
+		opts.LocateFailed(err)
+		return nil, nil
+	}
+	return locations, nil
+}
+
+// readPlanChanges reads the resource_changes and configuration sections of
+// plan JSON. Only what resources need from their change is kept: prior values
+// are dropped except for updates and replacements. Reading stops once ctx is
+// done.
+func readPlanChanges(ctx context.Context, r io.Reader) (map[string]changeDetail, *planConfiguration, error) {
+	dec := json.NewDecoder(r)
+	changes := make(map[string]changeDetail)
+	var config *planConfiguration
+
+	err := forEachKey(dec, func(key string) error {
+		switch {
+		case key == "resource_changes":
+			return forEachElement(dec, func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				var c resourceChange
+				if err := dec.Decode(&c); err != nil {
+					return err
+				}
+				if a := planAction(c.Change.Actions); a != model.ActionUpdate && a != model.ActionReplace {
+					c.Change.Before = nil
+				}
+				changes[c.Address] = c.Change
+				return nil
+			})
+		case key == "configuration":
+			return dec.Decode(&config)
+		default:
+			return skipValue(dec)
+		}
+	})
+	return changes, config, err
+}
+
+// errStop ends a walk early without reporting an error.
+var errStop = errors.New("stop")
+
+// readPlannedValues walks planned_values depth-first, calling fn with each
+// resource until fn returns false.
+func readPlannedValues(r io.Reader, fn func(planResource) bool) error {
+	dec := json.NewDecoder(r)
+	err := forEachKey(dec, func(key string) error {
+		if key != "planned_values" {
+			return skipValue(dec)
+		}
+		return forEachKey(dec, func(key string) error {
+			if key != "root_module" {
+				return skipValue(dec)
+			}
+			return readPlanModule(dec, fn)
+		})
+	})
+	if errors.Is(err, errStop) {
+		return nil
+	}
+	return err
+}
+
+// readPlanModule reads one module object of planned_values.
+func readPlanModule(dec *json.Decoder, fn func(planResource) bool) error {
+	return forEachKey(dec, func(key string) error {
+		switch key {
+		case "resources":
+			return forEachElement(dec, func() error {
+				var pr planResource
+				if err := dec.Decode(&pr); err != nil {
+					return err
+				}
+				if !fn(pr) {
+					return errStop
+				}
+				return nil
+			})
+		case "child_modules":
+			return forEachElement(dec, func() error {
+				return readPlanModule(dec, fn)
+			})
+		default:
+			return skipValue(dec)
+		}
+	})
+}
+
+// forEachKey reads a JSON object, calling fn with each key. fn must consume
+// the key's value. A null value is treated as an empty object.
+func forEachKey(dec *json.Decoder, fn func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected object, found %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, found %v", tok)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // closing brace
+	return err
+}
+
+// forEachElement reads a JSON array, calling fn to consume each element.
+// A null value is treated as an empty array.
+func forEachElement(dec *json.Decoder, fn func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected array, found %v", tok)
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // closing bracket
+	return err
+}
+
+// skipValue consumes the next JSON value token by token, without building it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+func TestStreamPlanFile_KeyOrder(t *testing.T) {
+	// resource_changes may follow planned_values; both passes read the whole file.
+	plan := `{
+  "format_version": "1.2",
+  "planned_values": {"root_module": {
+    "resources": [{"address": "aws_s3_bucket.a", "mode": "managed", "type": "aws_s3_bucket", "name": "a", "values": {"bucket": "a"}}],
+    "child_modules": [{"resources": [
+      {"address": "module.m.aws_s3_bucket.b", "mode": "managed", "type": "aws_s3_bucket", "name": "b", "values": {"bucket": null}}
+    ]}]
+  }},
+  "resource_changes": [
+    {"address": "aws_s3_bucket.a", "change": {"actions": ["update"], "before": {"bucket": "old"}, "after_unknown": {}}},
+    {"address": "module.m.aws_s3_bucket.b", "change": {"actions": ["create"], "after_unknown": {"bucket": true}}}
+  ]
+}`
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(plan), 0o600))
+
+	var resources []model.TerraformResource
+	err := StreamPlanFile(context.Background(), path, PlanOptions{}, func(res model.TerraformResource) error {
+		resources = append(resources, res)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	assert.Equal(t, model.ActionUpdate, resources[0].Action)
+	require.NotNil(t, resources[0].Before)
+	assert.Equal(t, "old", resources[0].Before.Attributes["bucket"])
+
+	assert.Equal(t, "module.m.aws_s3_bucket.b", resources[1].Address())
+	assert.Equal(t, model.ActionCreate, resources[1].Action)
+	assert.True(t, resources[1].Unknown["bucket"])
+}
+
+func TestStreamPlanFile_CallbackError(t *testing.T) {
+	stop := errors.New("stop here")
+	calls := 0
+	err := StreamPlanFile(context.Background(), "../../testdata/plan/sample.json", PlanOptions{}, func(model.TerraformResource) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamPlanFile_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"planned_values": {"root_module": {"resources": [`), 0o600))
+
+	err := StreamPlanFile(context.Background(), path, PlanOptions{}, func(model.TerraformResource) error { return nil })
+	assert.ErrorContains(t, err, "parsing plan JSON")
+}
+
+func TestStreamPlanFile_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := StreamPlanFile(ctx, "../../testdata/plan/sample.json", PlanOptions{}, func(model.TerraformResource) error {
+		calls++
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+
+	_, err = ParsePlanFile(ctx, "../../testdata/plan/sample.json")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = ParsePlanFile(ctx, "../../testdata/plan/binary.tfplan")
+	assert.ErrorIs(t, err, context.Canceled)
+}
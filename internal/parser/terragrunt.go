@@ -0,0 +1,386 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// terragruntFile is the configuration file of a Terragrunt unit.
+const terragruntFile = "terragrunt.hcl"
+
+// ErrSourceNotDownloaded is returned for a Terragrunt unit whose remote module
+// source is not in its .terragrunt-cache.
+var ErrSourceNotDownloaded = errors.New("module source is not available locally (run `terragrunt init` to download it)")
+
+// terragruntConfig is what a Terragrunt configuration contributes to a unit.
+type terragruntConfig struct {
+	source string // terraform.source, "" if unset
+	inputs map[string]cty.Value
+}
+
+// terragruntScope holds the directories Terragrunt's path functions refer to.
+type terragruntScope struct {
+	unitDir    string // directory of the unit's terragrunt.hcl
+	includeDir string // directory of the included configuration, "" if none
+}
+
+// terragruntInclude is an `include` block of a unit.
+type terragruntInclude struct {
+	path          string
+	mergeStrategy string
+}
+
+// FindTerragruntUnits returns the directories under root that hold a Terragrunt
+// unit. Configurations that other units include, such as a root
+// terragrunt.hcl, are parents rather than units and are left out.
+func FindTerragruntUnits(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".terragrunt-cache", ".terraform", ".git":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == terragruntFile {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]bool)
+	for _, dir := range dirs {
+		body, err := parseHCLFile(filepath.Join(dir, terragruntFile))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", filepath.Join(dir, terragruntFile), err)
+		}
+		includes, err := terragruntIncludes(body, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, inc := range includes {
+			included[inc.path] = true
+		}
+	}
+
+	var units []string
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(filepath.Join(dir, terragruntFile))
+		if err != nil {
+			return nil, err
+		}
+		if !included[abs] {
+			units = append(units, dir)
+		}
+	}
+	return units, nil
+}
+
+// ParseTerragruntUnit parses the Terraform module a Terragrunt unit deploys.
+// The unit's inputs, merged with those of the configurations it includes,
+// become the module's variable values. They take precedence below .tfvars
+// files, as Terragrunt passes them as TF_VAR_ environment variables.
+// Dependency outputs are unknown.
+func (p *Parser) ParseTerragruntUnit(dir string) ([]model.TerraformResource, error) {
+	cfg, err := loadTerragruntUnit(dir)
+	if err != nil {
+		return nil, err
+	}
+	moduleDir, err := resolveTerragruntSource(dir, cfg.source)
+	if err != nil {
+		return nil, err
+	}
+
+	l := newModuleLoader(p)
+	files, err := l.moduleFiles(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := p.rootVariables(moduleDir, bodiesOf(files), true)
+	if err != nil {
+		return nil, err
+	}
+	for name, v := range cfg.inputs {
+		if _, ok := vars[name]; !ok {
+			vars[name] = v
+		}
+	}
+
+	resources, _, err := l.load(moduleCall{dir: moduleDir, rootDir: moduleDir, inputs: vars})
+	return resources, err
+}
+
+// loadTerragruntUnit evaluates a unit's terragrunt.hcl and the configurations
+// it includes. Included configurations are merged shallowly, as Terragrunt does
+// by default: the unit's own source and inputs take precedence.
+func loadTerragruntUnit(dir string) (terragruntConfig, error) {
+	unitDir, err := filepath.Abs(dir)
+	if err != nil {
+		return terragruntConfig{}, err
+	}
+	path := filepath.Join(unitDir, terragruntFile)
+	body, err := parseHCLFile(path)
+	if err != nil {
+		return terragruntConfig{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	includes, err := terragruntIncludes(body, unitDir)
+	if err != nil {
+		return terragruntConfig{}, err
+	}
+
+	cfg := terragruntConfig{inputs: make(map[string]cty.Value)}
+	scope := terragruntScope{unitDir: unitDir}
+	for _, inc := range includes {
+		if scope.includeDir == "" {
+			scope.includeDir = filepath.Dir(inc.path)
+		}
+		if inc.mergeStrategy == "no_merge" {
+			continue
+		}
+		parentBody, err := parseHCLFile(inc.path)
+		if err != nil {
+			return terragruntConfig{}, fmt.Errorf("parsing %s: %w", inc.path, err)
+		}
+		mergeTerragruntConfig(&cfg, evalTerragruntConfig(parentBody, terragruntScope{unitDir: unitDir, includeDir: filepath.Dir(inc.path)}))
+	}
+	mergeTerragruntConfig(&cfg, evalTerragruntConfig(body, scope))
+	return cfg, nil
+}
+
+func mergeTerragruntConfig(dst *terragruntConfig, src terragruntConfig) {
+	if src.source != "" {
+		dst.source = src.source
+	}
+	for name, v := range src.inputs {
+		dst.inputs[name] = v
+	}
+}
+
+// terragruntIncludes returns the absolute paths of the configurations a unit
+// includes. Include paths may only use Terragrunt's functions.
+func terragruntIncludes(body *hclsyntax.Body, unitDir string) ([]terragruntInclude, error) {
+	unitDir, err := filepath.Abs(unitDir)
+	if err != nil {
+		return nil, err
+	}
+	ctx := terragruntContext(terragruntScope{unitDir: unitDir})
+
+	var includes []terragruntInclude
+	for _, block := range body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+		attr, ok := block.Body.Attributes["path"]
+		if !ok {
+			continue
+		}
+		val := evaluate(attr.Expr, ctx)
+		if !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+			return nil, fmt.Errorf("%s: include path cannot be evaluated", block.DefRange())
+		}
+		inc := terragruntInclude{path: val.AsString()}
+		if !filepath.IsAbs(inc.path) {
+			inc.path = filepath.Join(unitDir, inc.path)
+		}
+		if attr, ok := block.Body.Attributes["merge_strategy"]; ok {
+			if v := evaluate(attr.Expr, ctx); v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				inc.mergeStrategy = v.AsString()
+			}
+		}
+		includes = append(includes, inc)
+	}
+	return includes, nil
+}
+
+// evalTerragruntConfig evaluates the locals, terraform.source and inputs of
+// one configuration file.
+func evalTerragruntConfig(body *hclsyntax.Body, scope terragruntScope) terragruntConfig {
+	ctx := terragruntContext(scope)
+	ctx.Variables["local"] = evaluateLocals([]*hclsyntax.Body{body}, ctx)
+
+	cfg := terragruntConfig{inputs: make(map[string]cty.Value)}
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["source"]; ok {
+			if v := evaluate(attr.Expr, ctx); v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				cfg.source = v.AsString()
+			}
+		}
+	}
+
+	attr, ok := body.Attributes["inputs"]
+	if !ok {
+		return cfg
+	}
+	// Inputs are evaluated one by one, so one input that cannot be evaluated
+	// does not make the others unknown.
+	if obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr); ok {
+		for _, item := range obj.Items {
+			key := evaluate(item.KeyExpr, ctx)
+			if !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+				continue
+			}
+			cfg.inputs[key.AsString()] = evaluate(item.ValueExpr, ctx)
+		}
+		return cfg
+	}
+	if v := evaluate(attr.Expr, ctx); v.IsKnown() && !v.IsNull() && v.CanIterateElements() {
+		for it := v.ElementIterator(); it.Next(); {
+			k, val := it.Element()
+			if k.Type() == cty.String {
+				cfg.inputs[k.AsString()] = val
+			}
+		}
+	}
+	return cfg
+}
+
+// terragruntContext returns the evaluation context of a Terragrunt
+// configuration: Terraform's functions, Terragrunt's path and environment
+// functions, and unknown dependency outputs.
+func terragruntContext(scope terragruntScope) *hcl.EvalContext {
+	includeDir := scope.includeDir
+	if includeDir == "" {
+		includeDir = scope.unitDir
+	}
+	relTo, _ := filepath.Rel(includeDir, scope.unitDir)
+	relFrom, _ := filepath.Rel(scope.unitDir, includeDir)
+
+	funcs := terraformFunctions()
+	funcs["get_terragrunt_dir"] = stringFunc(scope.unitDir)
+	funcs["get_original_terragrunt_dir"] = stringFunc(scope.unitDir)
+	funcs["get_parent_terragrunt_dir"] = stringFunc(includeDir)
+	funcs["path_relative_to_include"] = stringFunc(filepath.ToSlash(relTo))
+	funcs["path_relative_from_include"] = stringFunc(filepath.ToSlash(relFrom))
+	funcs["find_in_parent_folders"] = findInParentFoldersFunc(scope.unitDir)
+	funcs["get_env"] = getEnvFunc
+
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"dependency": cty.DynamicVal,
+			"include":    cty.DynamicVal,
+		},
+		Functions: funcs,
+	}
+}
+
+// stringFunc returns a function that returns s. Optional arguments, such as
+// the include name some Terragrunt functions accept, are ignored.
+func stringFunc(s string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.DynamicPseudoType},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func([]cty.Value, cty.Type) (cty.Value, error) {
+			return cty.StringVal(s), nil
+		},
+	})
+}
+
+// findInParentFoldersFunc implements find_in_parent_folders([name, [fallback]]),
+// searching upwards from the directory above the unit.
+func findInParentFoldersFunc(unitDir string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			name := terragruntFile
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			for dir := filepath.Dir(unitDir); ; dir = filepath.Dir(dir) {
+				path := filepath.Join(dir, name)
+				if _, err := os.Stat(path); err == nil {
+					return cty.StringVal(path), nil
+				}
+				if dir == filepath.Dir(dir) {
+					break
+				}
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("no %s found in parent folders of %s", name, unitDir)
+		},
+	})
+}
+
+// getEnvFunc implements get_env(name, [default]).
+var getEnvFunc = function.New(&function.Spec{
+	Params:   []function.Parameter{{Name: "name", Type: cty.String}},
+	VarParam: &function.Parameter{Name: "default", Type: cty.String},
+	Type:     function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+		if v, ok := os.LookupEnv(args[0].AsString()); ok {
+			return cty.StringVal(v), nil
+		}
+		if len(args) > 1 {
+			return args[1], nil
+		}
+		return cty.StringVal(""), nil
+	},
+})
+
+// resolveTerragruntSource returns the directory of a unit's module: the unit
+// itself when it has no terraform.source, the referenced directory for local
+// sources, and the unit's .terragrunt-cache copy for remote ones. A "//" in the
+// source separates the repository or directory from the module's subdirectory.
+func resolveTerragruntSource(unitDir, source string) (string, error) {
+	if source == "" {
+		return unitDir, nil
+	}
+
+	src := source
+	if i := strings.Index(src, "?"); i >= 0 {
+		src = src[:i]
+	}
+	scheme := ""
+	if i := strings.Index(src, "://"); i >= 0 {
+		scheme, src = src[:i+3], src[i+3:]
+	}
+	base, sub := src, ""
+	if i := strings.Index(src, "//"); i >= 0 {
+		base, sub = src[:i], src[i+2:]
+	}
+
+	local := scheme == "" && !strings.Contains(base, "::") &&
+		(filepath.IsAbs(base) || base == "." || base == ".." || strings.HasPrefix(base, "./") || strings.HasPrefix(base, "../"))
+	if local {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(unitDir, base)
+		}
+		return filepath.Join(base, sub), nil
+	}
+
+	// Terragrunt downloads remote sources to .terragrunt-cache/<hash>/<hash>.
+	cache := filepath.Join(unitDir, ".terragrunt-cache")
+	outer, _ := os.ReadDir(cache)
+	for _, o := range outer {
+		inner, _ := os.ReadDir(filepath.Join(cache, o.Name()))
+		for _, in := range inner {
+			dir := filepath.Join(cache, o.Name(), in.Name(), sub)
+			if in.IsDir() && HasConfigFiles(dir) {
+				return dir, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s: %w: %s", unitDir, ErrSourceNotDownloaded, source)
+}
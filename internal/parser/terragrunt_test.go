@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTerragruntUnits(t *testing.T) {
+	units, err := FindTerragruntUnits("../../testdata/terragrunt/live")
+	require.NoError(t, err)
+	// The root terragrunt.hcl is included by the units, so it is not a unit itself
+	assert.Equal(t, []string{
+		"../../testdata/terragrunt/live/prod/kms",
+		"../../testdata/terragrunt/live/prod/logs",
+		"../../testdata/terragrunt/live/prod/remote",
+	}, units)
+}
+
+func TestParseTerragruntUnit_LocalSource(t *testing.T) {
+	resources, err := New().ParseTerragruntUnit("../../testdata/terragrunt/live/prod/logs")
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "this")
+	require.NotNil(t, bucket)
+	assert.Equal(t, "../../testdata/terragrunt/modules/bucket/main.tf", bucket.File)
+	// Locals and path functions of the unit, inputs of the included root config
+	assert.Equal(t, "acme-prod-logs", bucket.Attributes["bucket"])
+	assert.Equal(t, map[string]interface{}{"Environment": "prod"}, bucket.Attributes["tags"])
+
+	// The unit's inputs override the root config's
+	versioning := findPlanResource(resources, "aws_s3_bucket_versioning", "this")
+	require.NotNil(t, versioning)
+	assert.Equal(t, "Enabled", versioning.GetBlocks("versioning_configuration")[0].Attributes["status"])
+
+	// Dependency outputs are only known after the dependency is applied
+	sse := findPlanResource(resources, "aws_s3_bucket_server_side_encryption_configuration", "this")
+	require.NotNil(t, sse)
+	def := sse.GetBlocks("rule")[0].Blocks["apply_server_side_encryption_by_default"][0]
+	assert.True(t, def.Unknown["kms_master_key_id"])
+}
+
+func TestParseTerragruntUnit_NoSource(t *testing.T) {
+	resources, err := New().ParseTerragruntUnit("../../testdata/terragrunt/live/prod/kms")
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "aws_kms_key", resources[0].Type)
+}
+
+func TestParseTerragruntUnit_RemoteSource(t *testing.T) {
+	_, err := New().ParseTerragruntUnit("../../testdata/terragrunt/live/prod/remote")
+	assert.ErrorIs(t, err, ErrSourceNotDownloaded)
+}
+
+func TestResolveTerragruntSource_Cache(t *testing.T) {
+	unit := t.TempDir()
+	module := filepath.Join(unit, ".terragrunt-cache", "abc", "def", "bucket")
+	require.NoError(t, os.MkdirAll(module, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(module, "main.tf"), nil, 0o600))
+
+	dir, err := resolveTerragruntSource(unit, "git::https://example.com/acme/modules.git//bucket?ref=v1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, module, dir)
+
+	dir, err = resolveTerragruntSource(unit, "../modules//vpc")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(unit), "modules", "vpc"), dir)
+}
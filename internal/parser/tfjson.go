@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jsonFileSchema is the top-level block types of Terraform's JSON
+// configuration syntax that are analyzed. Other top-level keys are ignored.
+var jsonFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "provider", LabelNames: []string{"name"}},
+		{Type: "locals"},
+		{Type: "terraform"},
+	},
+}
+
+// jsonMapArguments are AWS provider arguments whose values are maps. Their
+// keys are often lowercase snake_case too, so they are never read as nested
+// blocks whatever their shape.
+var jsonMapArguments = map[string]bool{
+	"default_arguments":         true,
+	"environment_variables":     true,
+	"labels":                    true,
+	"non_overridable_arguments": true,
+	"parameters":                true,
+	"request_parameters":        true,
+	"request_templates":         true,
+	"response_parameters":       true,
+	"response_templates":        true,
+	"stage_variables":           true,
+	"tags":                      true,
+	"tags_all":                  true,
+	"variables":                 true,
+}
+
+// jsonArgumentName matches the names of block arguments and nested blocks.
+var jsonArgumentName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// parseJSONFile reads a file in Terraform's JSON configuration syntax
+// (.tf.json) and converts it to a native syntax body, so it is evaluated and
+// extracted like a .tf file. Within resources, a member is a nested block if
+// its value has the shape of one (see isJSONBlockValue) and it is not a known
+// map argument; anything else is an attribute. Strings are templates, except in variable defaults.
+func parseJSONFile(path string) (*hclsyntax.Body, error) {
+	src, err := os.ReadFile(path) // #nosec G304 -- path is a CLI argument supplied by the operator
+	if err != nil {
+		return nil, err
+	}
+
+	file, diags := hcljson.Parse(src, path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(jsonFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+
+	body := &hclsyntax.Body{Attributes: make(hclsyntax.Attributes)}
+	for _, block := range content.Blocks {
+		var converted *hclsyntax.Body
+		switch block.Type {
+		case "resource", "data":
+			converted, err = jsonResourceBody(block.Body)
+		default:
+			converted, err = jsonAttributes(block.Body, block.Type != "variable")
+		}
+		if err != nil {
+			return nil, err
+		}
+		body.Blocks = append(body.Blocks, jsonBlock(block, converted))
+	}
+	return body, nil
+}
+
+// jsonResourceBody converts the body of a resource, a nested block or a
+// dynamic block's content.
+func jsonResourceBody(b hcl.Body) (*hclsyntax.Body, error) {
+	attrs, diags := b.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+	schema := &hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "dynamic", LabelNames: []string{"type"}}}}
+	for name, attr := range attrs {
+		if name != "dynamic" && !jsonMapArguments[name] && isJSONBlockValue(attr.Expr) {
+			schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: name})
+		}
+	}
+
+	content, remain, diags := b.PartialContent(schema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+	body, err := jsonAttributes(remain, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range content.Blocks {
+		var nested *hclsyntax.Body
+		if block.Type == "dynamic" {
+			nested, err = jsonDynamicBody(block.Body)
+		} else {
+			nested, err = jsonResourceBody(block.Body)
+		}
+		if err != nil {
+			return nil, err
+		}
+		body.Blocks = append(body.Blocks, jsonBlock(block, nested))
+	}
+	return body, nil
+}
+
+// jsonDynamicBody converts the body of a dynamic block: its for_each,
+// iterator and labels arguments and its content block.
+func jsonDynamicBody(b hcl.Body) (*hclsyntax.Body, error) {
+	content, remain, diags := b.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "content"}}})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+	body, err := jsonAttributes(remain, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range content.Blocks {
+		nested, err := jsonResourceBody(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		body.Blocks = append(body.Blocks, jsonBlock(block, nested))
+	}
+	return body, nil
+}
+
+// jsonAttributes converts every member of a body to an attribute. Strings are
+// checked as templates when templates is set.
+func jsonAttributes(b hcl.Body, templates bool) (*hclsyntax.Body, error) {
+	attrs, diags := b.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("JSON parse error: %s", diags.Error())
+	}
+	body := &hclsyntax.Body{Attributes: make(hclsyntax.Attributes, len(attrs))}
+	for name, attr := range attrs {
+		if templates {
+			if err := checkTemplates(attr); err != nil {
+				return nil, err
+			}
+		}
+		body.Attributes[name] = &hclsyntax.Attribute{
+			Name:      name,
+			Expr:      jsonExpression{LiteralValueExpr: &hclsyntax.LiteralValueExpr{}, expr: attr.Expr},
+			SrcRange:  attr.Range,
+			NameRange: attr.NameRange,
+		}
+	}
+	return body, nil
+}
+
+// jsonBlock builds a native syntax block, reported at the opening brace of
+// its body. Unlabeled blocks have nil labels, as native syntax leaves them.
+func jsonBlock(block *hcl.Block, body *hclsyntax.Body) *hclsyntax.Block {
+	var labels []string
+	if len(block.Labels) > 0 {
+		labels = block.Labels
+	}
+	return &hclsyntax.Block{
+		Type:      block.Type,
+		Labels:    labels,
+		Body:      body,
+		TypeRange: block.DefRange,
+	}
+}
+
+// isJSONBlockValue reports whether expr is an object or a non-empty array of
+// objects whose keys all read as argument names. JSON syntax cannot tell a
+// nested block from a map attribute without provider schemas, so the keys
+// decide: arguments are lowercase snake_case, while the keys of most maps
+// ("Environment", "cost-center") are not. Maps known to take snake_case keys
+// are excluded by name beforehand. An empty object is a block, as in
+// "versioning": {}.
+func isJSONBlockValue(expr hcl.Expression) bool {
+	val, _ := expr.Value(nil)
+	ty := val.Type()
+	if ty.IsObjectType() {
+		return hasArgumentKeys(ty)
+	}
+	if !ty.IsTupleType() || len(ty.TupleElementTypes()) == 0 {
+		return false
+	}
+	for _, elem := range ty.TupleElementTypes() {
+		if !elem.IsObjectType() || !hasArgumentKeys(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasArgumentKeys reports whether every attribute name of an object type is a
+// lowercase snake_case identifier.
+func hasArgumentKeys(ty cty.Type) bool {
+	for name := range ty.AttributeTypes() {
+		if !jsonArgumentName.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTemplates parses every string of an attribute as a template, as the
+// JSON syntax only parses templates once they are evaluated and a malformed
+// one would otherwise read as an unknown value.
+func checkTemplates(attr *hcl.Attribute) error {
+	val, _ := attr.Expr.Value(nil)
+	return cty.Walk(val, func(_ cty.Path, v cty.Value) (bool, error) {
+		if v.Type() != cty.String || !v.IsKnown() || v.IsNull() {
+			return true, nil
+		}
+		if _, diags := hclsyntax.ParseTemplate([]byte(v.AsString()), attr.Range.Filename, attr.Expr.StartRange().Start); diags.HasErrors() {
+			return false, fmt.Errorf("HCL parse error: %s", diags.Error())
+		}
+		return true, nil
+	})
+}
+
+// jsonExpression lets an expression in JSON syntax stand in for a native
+// syntax one. The embedded literal only supplies the node walking hclsyntax
+// requires; hcl's static analysis functions see the JSON expression through
+// UnwrapExpression.
+type jsonExpression struct {
+	*hclsyntax.LiteralValueExpr
+	expr hcl.Expression
+}
+
+func (e jsonExpression) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	return e.expr.Value(ctx)
+}
+
+func (e jsonExpression) Variables() []hcl.Traversal {
+	return e.expr.Variables()
+}
+
+func (e jsonExpression) Range() hcl.Range {
+	return e.expr.Range()
+}
+
+func (e jsonExpression) StartRange() hcl.Range {
+	return e.expr.StartRange()
+}
+
+func (e jsonExpression) UnwrapExpression() hcl.Expression {
+	return e.expr
+}
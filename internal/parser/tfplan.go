@@ -0,0 +1,475 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// zipMagic is the signature at the start of a zip archive, which is how
+// `terraform plan -out` stores plans.
+var zipMagic = []byte("PK\x03\x04")
+
+// isBinaryPlan reports whether data is a binary plan file rather than plan JSON.
+func isBinaryPlan(data []byte) bool {
+	return bytes.HasPrefix(data, zipMagic)
+}
+
+// Field numbers and enum values from Terraform's planfile.proto
+// (internal/plans/planproto), which has been stable since plan format version 3.
+const (
+	planFieldResourceChanges = 3
+
+	changeFieldAddr       = 13
+	changeFieldDeposedKey = 7
+	changeFieldChange     = 9
+
+	changeDetailFieldAction = 1
+	changeDetailFieldValues = 2
+
+	dynamicValueFieldMsgpack = 1
+)
+
+const (
+	protoActionNoOp             = 0
+	protoActionCreate           = 1
+	protoActionRead             = 2
+	protoActionUpdate           = 3
+	protoActionDelete           = 5
+	protoActionDeleteThenCreate = 6
+	protoActionCreateThenDelete = 7
+	protoActionForget           = 8
+)
+
+// parseBinaryPlan reads the planned resource values from a binary plan file.
+// Values are msgpack-encoded against provider schemas the analyzer does not
+// have, so they are decoded by their wire types: objects become maps, lists
+// and sets become slices, and unknown values are recorded as known after apply.
+func parseBinaryPlan(data []byte) ([]model.TerraformResource, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("opening plan archive: %w", err)
+	}
+
+	var planData []byte
+	for _, f := range zr.File {
+		if f.Name != "tfplan" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening tfplan: %w", err)
+		}
+		planData, err = io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading tfplan: %w", err)
+		}
+	}
+	if planData == nil {
+		return nil, errors.New("plan archive has no tfplan entry")
+	}
+
+	fields, err := protoFields(planData)
+	if err != nil {
+		return nil, fmt.Errorf("decoding tfplan: %w", err)
+	}
+
+	var resources []model.TerraformResource
+	for _, f := range fields {
+		if f.num != planFieldResourceChanges {
+			continue
+		}
+		res, ok, err := convertProtoChange(f.bytes)
+		if err != nil {
+			return nil, fmt.Errorf("decoding resource change: %w", err)
+		}
+		if ok {
+			resources = append(resources, res)
+		}
+	}
+	return resources, nil
+}
+
+// convertProtoChange converts one ResourceInstanceChange message. It returns
+// false for changes that are not analyzed: deletions and deposed objects.
+func convertProtoChange(msg []byte) (model.TerraformResource, bool, error) {
+	fields, err := protoFields(msg)
+	if err != nil {
+		return model.TerraformResource{}, false, err
+	}
+
+	var addr string
+	var change []byte
+	for _, f := range fields {
+		switch f.num {
+		case changeFieldAddr:
+			addr = string(f.bytes)
+		case changeFieldDeposedKey:
+			if len(f.bytes) > 0 {
+				return model.TerraformResource{}, false, nil
+			}
+		case changeFieldChange:
+			change = f.bytes
+		}
+	}
+	if addr == "" || change == nil {
+		return model.TerraformResource{}, false, nil
+	}
+
+	fields, err = protoFields(change)
+	if err != nil {
+		return model.TerraformResource{}, false, err
+	}
+	var protoAction uint64
+	var values []interface{}
+	for _, f := range fields {
+		switch f.num {
+		case changeDetailFieldAction:
+			protoAction = f.varint
+		case changeDetailFieldValues:
+			v, err := decodeDynamicValue(f.bytes)
+			if err != nil {
+				return model.TerraformResource{}, false, fmt.Errorf("%s: %w", addr, err)
+			}
+			values = append(values, v)
+		}
+	}
+
+	// Creates carry only the new value and no-ops only the current one;
+	// every other action carries [before, after].
+	var action model.Action
+	var before, after interface{}
+	switch protoAction {
+	case protoActionNoOp:
+		action = model.ActionNoOp
+	case protoActionCreate:
+		action = model.ActionCreate
+	case protoActionRead:
+		action = model.ActionRead
+	case protoActionUpdate:
+		action = model.ActionUpdate
+	case protoActionDeleteThenCreate, protoActionCreateThenDelete:
+		action = model.ActionReplace
+	case protoActionDelete, protoActionForget:
+		return model.TerraformResource{}, false, nil
+	default:
+		return model.TerraformResource{}, false, fmt.Errorf("%s: unknown action %d", addr, protoAction)
+	}
+	if len(values) > 0 {
+		after = values[len(values)-1]
+	}
+	if len(values) == 2 {
+		before = values[0]
+	}
+
+	afterValues, _ := after.(map[string]interface{})
+	if afterValues == nil {
+		return model.TerraformResource{}, false, nil
+	}
+
+	mode, resType, name := splitResourceAddress(addr)
+	r := planResource{Address: addr, Mode: mode, Type: resType, Name: name}
+
+	vals, unknown := splitUnknown(afterValues)
+	r.Values, _ = vals.(map[string]interface{})
+	afterUnknown, _ := unknown.(map[string]interface{})
+	res := convertPlanResource(r, afterUnknown)
+	res.Action = action
+
+	if beforeValues, ok := before.(map[string]interface{}); ok && (action == model.ActionUpdate || action == model.ActionReplace) {
+		vals, _ := splitUnknown(beforeValues)
+		prior := r
+		prior.Values, _ = vals.(map[string]interface{})
+		priorRes := convertPlanResource(prior, nil)
+		res.Before = &priorRes
+	}
+	return res, true, nil
+}
+
+// splitResourceAddress returns the mode, type and name of a resource instance
+// address such as `module.a["x"].data.aws_ami.ubuntu[0]`.
+func splitResourceAddress(addr string) (mode, resType, name string) {
+	parts := strings.Split(stripInstanceKeys(addr), ".")
+	for len(parts) >= 2 && parts[0] == "module" {
+		parts = parts[2:]
+	}
+	mode = "managed"
+	if len(parts) == 3 && parts[0] == "data" {
+		mode = "data"
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return mode, "", ""
+	}
+	return mode, parts[0], parts[1]
+}
+
+// decodeDynamicValue decodes a DynamicValue message, whose msgpack field
+// holds a cty value.
+func decodeDynamicValue(msg []byte) (interface{}, error) {
+	fields, err := protoFields(msg)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.num == dynamicValueFieldMsgpack {
+			d := msgpackDecoder{data: f.bytes}
+			return d.decode()
+		}
+	}
+	return nil, nil
+}
+
+// unknownValue stands for a cty value that is only known after apply.
+type unknownValue struct{}
+
+// splitUnknown separates decoded values from unknown markers, returning the
+// values with unknowns replaced by nil and an after_unknown style structure
+// with true at every unknown path.
+func splitUnknown(v interface{}) (interface{}, interface{}) {
+	switch val := v.(type) {
+	case unknownValue:
+		return nil, true
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(val))
+		unknown := make(map[string]interface{})
+		for k, item := range val {
+			values[k], unknown[k] = splitUnknown(item)
+			if unknown[k] == false {
+				delete(unknown, k)
+			}
+		}
+		return values, unknown
+	case []interface{}:
+		values := make([]interface{}, len(val))
+		unknown := make([]interface{}, len(val))
+		for i, item := range val {
+			values[i], unknown[i] = splitUnknown(item)
+		}
+		return values, unknown
+	default:
+		return v, false
+	}
+}
+
+// protoField is one field of a protobuf message. Varint fields set varint;
+// length-delimited fields set bytes.
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+// protoFields splits a protobuf message into its fields.
+func protoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("malformed field key")
+		}
+		b = b[n:]
+
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0: // varint
+			f.varint, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("malformed varint")
+			}
+			b = b[n:]
+		case 1: // 64-bit
+			if len(b) < 8 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			b = b[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, io.ErrUnexpectedEOF
+			}
+			f.bytes = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case 5: // 32-bit
+			if len(b) < 4 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			b = b[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// msgpackDecoder decodes msgpack into the same Go types encoding/json
+// produces: maps, slices, strings, float64 numbers, bools and nil. Extension
+// values, which cty uses for unknown values, decode to unknownValue.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) decode() (interface{}, error) {
+	c, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return float64(c), nil
+	case c >= 0xe0:
+		return float64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.decodeMap(int(c & 0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.decodeArray(int(c & 0x0f))
+	case c >= 0xa0 && c <= 0xbf:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(int(n))
+		return string(b), err
+	case 0xc7, 0xc8, 0xc9: // ext
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		_, err = d.bytes(int(n) + 1)
+		return unknownValue{}, err
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		return float64(n), err
+	case 0xd0:
+		n, err := d.uint(1)
+		return float64(int8(n)), err
+	case 0xd1:
+		n, err := d.uint(2)
+		return float64(int16(n)), err
+	case 0xd2:
+		n, err := d.uint(4)
+		return float64(int32(n)), err
+	case 0xd3:
+		n, err := d.uint(8)
+		return float64(int64(n)), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext
+		_, err := d.bytes(1 + 1<<(c-0xd4))
+		return unknownValue{}, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+	return nil, fmt.Errorf("unsupported msgpack type 0x%02x", c)
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, errors.New("msgpack map key is not a string")
+		}
+		if m[key], err = d.decode(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	b, err := d.bytes(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	c := d.data[d.pos]
+	d.pos++
+	return c, nil
+}
+
+func (d *msgpackDecoder) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.bytes(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+func TestParsePlanFile_Binary(t *testing.T) {
+	resources, err := ParsePlanFile(context.Background(), "../../testdata/plan/binary.tfplan")
+	require.NoError(t, err)
+
+	// Deletes and deposed objects are dropped.
+	var addrs []string
+	for _, r := range resources {
+		addrs = append(addrs, r.Address())
+	}
+	assert.ElementsMatch(t, []string{
+		"aws_s3_bucket.logs",
+		"aws_db_instance.main",
+		"module.network.aws_subnet.private[0]",
+		"aws_instance.web",
+		"data.aws_caller_identity.current",
+	}, addrs)
+
+	bucket := findPlanResource(resources, "aws_s3_bucket", "logs")
+	require.NotNil(t, bucket)
+	assert.Equal(t, model.ActionCreate, bucket.Action)
+	assert.Equal(t, "tfplan", bucket.File)
+	assert.Equal(t, "my-logs-bucket", bucket.Attributes["bucket"])
+	assert.Equal(t, false, bucket.Attributes["force_destroy"])
+	assert.Equal(t, map[string]interface{}{"Environment": "prod"}, bucket.Attributes["tags"])
+	assert.True(t, bucket.IsUnknown("arn"))
+	require.True(t, bucket.HasBlock("server_side_encryption_configuration"))
+	rule := bucket.GetBlocks("server_side_encryption_configuration")[0].Blocks["rule"][0]
+	sse, _ := rule.Blocks["apply_server_side_encryption_by_default"][0].GetStringAttr("sse_algorithm")
+	assert.Equal(t, "AES256", sse)
+
+	db := findPlanResource(resources, "aws_db_instance", "main")
+	require.NotNil(t, db)
+	assert.Equal(t, model.ActionUpdate, db.Action)
+	assert.Equal(t, float64(20), db.Attributes["allocated_storage"])
+	assert.Equal(t, false, db.Attributes["deletion_protection"])
+	require.NotNil(t, db.Before)
+	assert.Equal(t, true, db.Before.Attributes["deletion_protection"])
+
+	subnet := findPlanResource(resources, "aws_subnet", "private")
+	require.NotNil(t, subnet)
+	assert.Equal(t, model.ActionNoOp, subnet.Action)
+	assert.Equal(t, "10.0.1.0/24", subnet.Attributes["cidr_block"])
+
+	web := findPlanResource(resources, "aws_instance", "web")
+	require.NotNil(t, web)
+	assert.Equal(t, model.ActionReplace, web.Action)
+	assert.True(t, web.IsUnknown("id"))
+	require.NotNil(t, web.Before)
+	assert.Equal(t, "i-0abc", web.Before.Attributes["id"])
+
+	caller := findPlanResource(resources, "data.aws_caller_identity", "current")
+	require.NotNil(t, caller)
+	assert.Equal(t, model.ActionRead, caller.Action)
+	assert.True(t, caller.IsUnknown("account_id"))
+}
+
+func TestDetectFormat_Binary(t *testing.T) {
+	format, err := DetectFormat("../../testdata/plan/binary.tfplan")
+	require.NoError(t, err)
+	assert.Equal(t, FormatPlan, format)
+}
+
+func TestSplitResourceAddress(t *testing.T) {
+	tests := []struct {
+		addr, mode, resType, name string
+	}{
+		{"aws_s3_bucket.logs", "managed", "aws_s3_bucket", "logs"},
+		{"aws_subnet.private[0]", "managed", "aws_subnet", "private"},
+		{`module.a["x.y"].module.b.aws_vpc.main`, "managed", "aws_vpc", "main"},
+		{"data.aws_ami.ubuntu", "data", "aws_ami", "ubuntu"},
+		{"module.a.data.aws_ami.ubuntu[0]", "data", "aws_ami", "ubuntu"},
+	}
+	for _, tt := range tests {
+		mode, resType, name := splitResourceAddress(tt.addr)
+		assert.Equal(t, tt.mode, mode, tt.addr)
+		assert.Equal(t, tt.resType, resType, tt.addr)
+		assert.Equal(t, tt.name, name, tt.addr)
+	}
+}
+
+func TestMsgpackDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want interface{}
+	}{
+		{"positive fixint", []byte{0x05}, float64(5)},
+		{"negative fixint", []byte{0xff}, float64(-1)},
+		{"uint16", []byte{0xcd, 0x01, 0x00}, float64(256)},
+		{"int32", []byte{0xd2, 0xff, 0xff, 0xff, 0xfe}, float64(-2)},
+		{"float64", []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, 1.5},
+		{"str8", append([]byte{0xd9, 0x03}, "abc"...), "abc"},
+		{"array16", []byte{0xdc, 0x00, 0x02, 0xc3, 0xc0}, []interface{}{true, nil}},
+		{"fixmap", []byte{0x81, 0xa1, 'k', 0xc2}, map[string]interface{}{"k": false}},
+		{"unknown", []byte{0xd4, 0x00, 0x00}, unknownValue{}},
+	}
+	for _, tt := range tests {
+		d := msgpackDecoder{data: tt.data}
+		got, err := d.decode()
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+
+	d := msgpackDecoder{data: []byte{0x92, 0x01}}
+	_, err := d.decode()
+	assert.Error(t, err, "truncated array")
+}
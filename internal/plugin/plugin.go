@@ -0,0 +1,379 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// startTimeout bounds how long a plugin may take to start and list its rules.
+const startTimeout = 30 * time.Second
+
+// checkTimeout bounds how long a plugin may take to answer one check request,
+// whatever the rule time budget. A variable so tests can shorten it.
+var checkTimeout = 30 * time.Second
+
+// closeTimeout is how long a plugin may take to exit once its stdin is closed
+// before it is killed.
+const closeTimeout = 2 * time.Second
+
+// Discover returns the executables in dir, in name order. Hidden files and
+// subdirectories are skipped.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading plugins directory: %w", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path) // follows symlinks
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Plugin is a running plugin executable.
+type Plugin struct {
+	path  string
+	name  string
+	rules []model.RuleMetadata
+
+	// lock holds a slot while a request is in flight; requests are sent one
+	// at a time.
+	lock chan struct{}
+	proc *process // nil until started, and after a crash or timeout
+}
+
+// Start runs the executable at path and asks it for its rules. The process
+// keeps running to check resources until Close.
+func Start(ctx context.Context, path string) (*Plugin, error) {
+	p := &Plugin{path: path, name: filepath.Base(path), lock: make(chan struct{}, 1)}
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	proc, err := p.start()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := proc.roundTrip(ctx, Request{Version: ProtocolVersion, Type: RequestMetadata})
+	if err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("plugin %s: listing rules: %w", p.name, err)
+	}
+	if resp.Error != "" {
+		proc.kill()
+		return nil, fmt.Errorf("plugin %s: listing rules: %s", p.name, resp.Error)
+	}
+	for i, meta := range resp.Rules {
+		if err := validateMetadata(meta); err != nil {
+			proc.kill()
+			return nil, fmt.Errorf("plugin %s: rule[%d]: %w", p.name, i, err)
+		}
+	}
+	p.rules = resp.Rules
+	p.proc = proc
+	return p, nil
+}
+
+func validateMetadata(meta model.RuleMetadata) error {
+	if meta.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if model.SeverityRank(meta.Severity) == 0 {
+		return fmt.Errorf("%s: invalid severity %q", meta.ID, meta.Severity)
+	}
+	valid := false
+	for _, p := range model.AllPillars() {
+		valid = valid || meta.Pillar == p
+	}
+	if !valid {
+		return fmt.Errorf("%s: invalid pillar %q", meta.ID, meta.Pillar)
+	}
+	if len(meta.ResourceTypes) == 0 {
+		return fmt.Errorf("%s: resource_types is required", meta.ID)
+	}
+	return nil
+}
+
+// Name returns the plugin's file name.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Rules returns the plugin's rules, which check resources by sending them to
+// the plugin. A plugin only sees the resource it checks, so its rules are
+// evaluated as each resource streams in.
+func (p *Plugin) Rules() []model.EvalRule {
+	rules := make([]model.EvalRule, len(p.rules))
+	for i, meta := range p.rules {
+		rules[i] = engine.ResourceOnly(&rule{plugin: p, meta: meta})
+	}
+	return rules
+}
+
+// Close stops the plugin. It is safe to call more than once.
+func (p *Plugin) Close() {
+	p.lock <- struct{}{}
+	defer func() { <-p.lock }()
+	if p.proc != nil {
+		p.proc.close()
+		p.proc = nil
+	}
+}
+
+// check sends a resource to the plugin and returns the findings of the rule.
+// A plugin that crashes, or does not answer within checkTimeout or before ctx
+// is done, is stopped, and restarted by the next check.
+func (p *Plugin) check(ctx context.Context, ruleID string, res model.TerraformResource, params map[string]string) ([]model.Finding, error) {
+	select {
+	case p.lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.lock }()
+
+	reqCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	if p.proc == nil {
+		proc, err := p.restart(reqCtx)
+		if err != nil {
+			return nil, err
+		}
+		p.proc = proc
+	}
+	resp, err := p.proc.roundTrip(reqCtx, Request{
+		Version:  ProtocolVersion,
+		Type:     RequestCheck,
+		RuleID:   ruleID,
+		Resource: &res,
+		Params:   params,
+	})
+	if err != nil {
+		p.proc.kill()
+		p.proc = nil
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no response within %s", checkTimeout)
+		}
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.name, resp.Error)
+	}
+	return resp.Findings, nil
+}
+
+// restart starts the plugin again after a crash or timeout, checking that it
+// still speaks the protocol.
+func (p *Plugin) restart(ctx context.Context) (*process, error) {
+	proc, err := p.start()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := proc.roundTrip(ctx, Request{Version: ProtocolVersion, Type: RequestMetadata})
+	if err == nil && resp.Error != "" {
+		err = errors.New(resp.Error)
+	}
+	if err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("plugin %s: restarting: %w", p.name, err)
+	}
+	return proc, nil
+}
+
+func (p *Plugin) start() (*process, error) {
+	cmd := exec.Command(p.path) // #nosec G204 -- plugins are executables in a directory supplied by the operator
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	// Unlike StdoutPipe, a pipe of our own is not closed when the process
+	// exits, so a response written just before exiting is still read.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	cmd.Stdout = w
+	stderr := &tail{}
+	cmd.Stderr = stderr
+	err = cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		_ = stdout.Close()
+		return nil, fmt.Errorf("plugin %s: starting: %w", p.name, err)
+	}
+
+	pr := &process{cmd: cmd, stdin: stdin, stdoutFile: stdout, stdout: bufio.NewReader(stdout), stderr: stderr, waited: make(chan struct{})}
+	go func() {
+		pr.waitErr = cmd.Wait()
+		close(pr.waited)
+	}()
+	return pr, nil
+}
+
+// rule is a rule of a plugin.
+type rule struct {
+	plugin *Plugin
+	meta   model.RuleMetadata
+}
+
+func (r *rule) Metadata() model.RuleMetadata {
+	return r.meta
+}
+
+func (r *rule) Check(ctx *model.EvalContext) {
+	findings, err := r.plugin.check(ctx.Context(), r.meta.ID, ctx.Resource, ctx.Params)
+	if err != nil {
+		ctx.Fail(err)
+		return
+	}
+	for _, f := range findings {
+		finding := ctx.Finding(ctx.Resource, f.Description, f.Remediation)
+		if f.DocURL != "" {
+			finding.DocURL = f.DocURL
+		}
+		finding.Undetermined = f.Undetermined
+		ctx.Report(finding)
+	}
+}
+
+// process is a running plugin process.
+type process struct {
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stdoutFile *os.File
+	stdout     *bufio.Reader
+	stderr     *tail
+	// waited is closed once the process has exited and its stderr has been
+	// read, with waitErr holding how it exited.
+	waited  chan struct{}
+	waitErr error
+}
+
+// roundTrip sends a request and reads the response. It gives up when ctx is
+// done, leaving the process to be killed.
+func (pr *process) roundTrip(ctx context.Context, req Request) (Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+	type result struct {
+		resp Response
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if _, err := pr.stdin.Write(append(data, '\n')); err != nil {
+			done <- result{err: pr.exited(err)}
+			return
+		}
+		line, err := pr.stdout.ReadBytes('\n')
+		if err != nil {
+			done <- result{err: pr.exited(err)}
+			return
+		}
+		var resp Response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			done <- result{err: fmt.Errorf("invalid response: %w", err)}
+			return
+		}
+		if resp.Version != ProtocolVersion {
+			done <- result{err: fmt.Errorf("speaks protocol version %d, want %d", resp.Version, ProtocolVersion)}
+			return
+		}
+		done <- result{resp: resp}
+	}()
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+}
+
+// exited describes a process whose pipes failed, with how it exited and the
+// last line it wrote to stderr.
+func (pr *process) exited(err error) error {
+	msg := "exited unexpectedly"
+	select {
+	case <-pr.waited:
+		if pr.waitErr != nil {
+			msg += " (" + pr.waitErr.Error() + ")"
+		}
+	case <-time.After(closeTimeout):
+		if !errors.Is(err, io.EOF) {
+			msg += " (" + err.Error() + ")"
+		}
+	}
+	if last := pr.stderr.lastLine(); last != "" {
+		msg += ": " + last
+	}
+	return errors.New(msg)
+}
+
+// close asks the process to exit by closing its stdin, and kills it if it
+// does not.
+func (pr *process) close() {
+	_ = pr.stdin.Close()
+	select {
+	case <-pr.waited:
+	case <-time.After(closeTimeout):
+		_ = pr.cmd.Process.Kill()
+		<-pr.waited
+	}
+	_ = pr.stdoutFile.Close()
+}
+
+func (pr *process) kill() {
+	_ = pr.stdin.Close()
+	_ = pr.cmd.Process.Kill()
+	<-pr.waited
+	_ = pr.stdoutFile.Close()
+}
+
+// tail keeps the end of what a plugin writes to stderr.
+type tail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailSize = 4096
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > tailSize {
+		t.buf = t.buf[len(t.buf)-tailSize:]
+	}
+	return len(p), nil
+}
+
+func (t *tail) lastLine() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := bytes.Split(bytes.TrimSpace(t.buf), []byte("\n"))
+	return string(lines[len(lines)-1])
+}
@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// TestMain runs the test binary as a plugin when WAT_TEST_PLUGIN names a
+// plugin behaviour.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("WAT_TEST_PLUGIN"); mode != "" {
+		servePlugin(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// servePlugin answers requests on stdin. Its rule reports public buckets;
+// buckets named crash, hang and error make it exit, stop answering and
+// answer with an error.
+func servePlugin(mode string) {
+	version := ProtocolVersion
+	if mode == "version" {
+		version = 99
+	}
+	rules := []model.RuleMetadata{{
+		ID:            "PLG-001",
+		Name:          "Public Bucket",
+		Severity:      model.SeverityHigh,
+		Pillar:        model.PillarSecurity,
+		ResourceTypes: []string{"aws_s3_bucket"},
+		DocURL:        "https://example.com/plg-001",
+	}}
+	if mode == "invalid" {
+		rules[0].ResourceTypes = nil
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "bad request:", err)
+			os.Exit(2)
+		}
+		resp := Response{Version: version}
+		switch req.Type {
+		case RequestMetadata:
+			resp.Rules = rules
+		case RequestCheck:
+			switch req.Resource.Name {
+			case "crash":
+				fmt.Fprintln(os.Stderr, "panic: boom")
+				os.Exit(3)
+			case "hang":
+				time.Sleep(time.Hour)
+			case "error":
+				resp.Error = "cannot read tags"
+			}
+			if acl, _ := req.Resource.GetStringAttr("acl"); acl == "public-read" {
+				resp.Findings = []model.Finding{{
+					Description: "Bucket is public",
+					Remediation: "Set acl = \"private\"",
+					RuleID:      "ignored",
+					Severity:    model.SeverityInfo,
+				}}
+			}
+		}
+		_ = out.Encode(resp)
+	}
+}
+
+// writePlugin writes an executable that runs the test binary as a plugin in
+// the given mode.
+func writePlugin(t *testing.T, dir, name, mode string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin tests use a shell script")
+	}
+	path := filepath.Join(dir, name)
+	script := fmt.Sprintf("#!/bin/sh\nWAT_TEST_PLUGIN=%s exec '%s' -test.run='^$'\n", mode, os.Args[0])
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o700)) // #nosec G306 -- the plugin must be executable
+	return path
+}
+
+func startPlugin(t *testing.T, mode string) *Plugin {
+	t.Helper()
+	p, err := Start(context.Background(), writePlugin(t, t.TempDir(), "wat-plugin", mode))
+	require.NoError(t, err)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func bucket(name, acl string) model.TerraformResource {
+	return model.TerraformResource{Type: "aws_s3_bucket", Name: name, File: "main.tf", Line: 1, Attributes: map[string]interface{}{"acl": acl}}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "b-plugin", "ok")
+	writePlugin(t, dir, "a-plugin", "ok")
+	writePlugin(t, dir, ".hidden", "ok")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("docs"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o750))
+
+	paths, err := Discover(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a-plugin"), filepath.Join(dir, "b-plugin")}, paths)
+
+	_, err = Discover(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestPlugin_Check(t *testing.T) {
+	p := startPlugin(t, "ok")
+	rules := p.Rules()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "PLG-001", rules[0].Metadata().ID)
+
+	findings := engine.NewWithRules(rules...).Analyze(context.Background(), []model.TerraformResource{
+		bucket("private", "private"),
+		bucket("public", "public-read"),
+		{Type: "aws_instance", Name: "web"},
+	})
+	require.Len(t, findings, 1)
+	assert.Equal(t, model.Finding{
+		RuleID:      "PLG-001",
+		RuleName:    "Public Bucket",
+		Severity:    model.SeverityHigh,
+		Pillar:      model.PillarSecurity,
+		Resource:    "aws_s3_bucket.public",
+		File:        "main.tf",
+		Line:        1,
+		Description: "Bucket is public",
+		Remediation: "Set acl = \"private\"",
+		DocURL:      "https://example.com/plg-001",
+	}, findings[0])
+}
+
+func TestPlugin_RulesStream(t *testing.T) {
+	p := startPlugin(t, "ok")
+	p.Close()
+
+	// Resources are sent to the plugin as they are added, not held until the
+	// stream is finished.
+	s := engine.NewWithRules(p.Rules()...).NewStream(context.Background())
+	s.Add(bucket("public", "public-read"))
+	assert.NotNil(t, p.proc)
+	assert.Len(t, s.Finish(), 1)
+}
+
+func TestPlugin_Failures(t *testing.T) {
+	p := startPlugin(t, "ok")
+	eng := engine.New(engine.Config{ExtraRules: p.Rules(), RuleTimeout: 500 * time.Millisecond, Parallelism: 1})
+
+	s := eng.NewStream(context.Background())
+	for _, res := range []model.TerraformResource{
+		bucket("crash", "private"),
+		bucket("hang", "private"),
+		bucket("error", "private"),
+		bucket("public", "public-read"),
+	} {
+		s.Add(res)
+	}
+	findings := s.Finish()
+
+	// The plugin is restarted after crashing and after timing out.
+	require.Len(t, findings, 1)
+	assert.Equal(t, "aws_s3_bucket.public", findings[0].Resource)
+
+	errs := s.Errors()
+	require.Len(t, errs, 3)
+	assert.Equal(t, "aws_s3_bucket.crash", errs[0].Resource)
+	assert.Contains(t, errs[0].Message, "plugin wat-plugin: exited unexpectedly (exit status 3): panic: boom")
+	assert.Equal(t, "aws_s3_bucket.error", errs[1].Resource)
+	assert.Equal(t, "plugin wat-plugin: cannot read tags", errs[1].Message)
+	assert.Equal(t, "aws_s3_bucket.hang", errs[2].Resource)
+	assert.True(t, errs[2].TimedOut)
+}
+
+func TestPlugin_NoReply(t *testing.T) {
+	defer func(d time.Duration) { checkTimeout = d }(checkTimeout)
+	checkTimeout = 200 * time.Millisecond
+
+	// Without a rule time budget, the plugin's own deadline still applies.
+	p := startPlugin(t, "ok")
+	eng := engine.New(engine.Config{ExtraRules: p.Rules(), Parallelism: 1})
+	s := eng.NewStream(context.Background())
+	s.Add(bucket("hang", "private"))
+	s.Add(bucket("public", "public-read"))
+
+	findings := s.Finish()
+	require.Len(t, findings, 1)
+	assert.Equal(t, "aws_s3_bucket.public", findings[0].Resource)
+	errs := s.Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, "aws_s3_bucket.hang", errs[0].Resource)
+	assert.Equal(t, "plugin wat-plugin: no response within 200ms", errs[0].Message)
+}
+
+func TestStart_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Start(context.Background(), writePlugin(t, dir, "version", "version"))
+	assert.ErrorContains(t, err, "plugin version: listing rules: speaks protocol version 99, want 1")
+
+	_, err = Start(context.Background(), writePlugin(t, dir, "invalid", "invalid"))
+	assert.ErrorContains(t, err, "plugin invalid: rule[0]: PLG-001: resource_types is required")
+
+	_, err = Start(context.Background(), filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "plugin missing: starting")
+}
+
+func TestPlugin_Close(t *testing.T) {
+	p := startPlugin(t, "ok")
+	p.Close()
+	p.Close()
+
+	// A closed plugin is started again if it is used.
+	findings := engine.NewWithRules(p.Rules()...).Analyze(context.Background(), []model.TerraformResource{bucket("public", "public-read")})
+	assert.Len(t, findings, 1)
+}
@@ -0,0 +1,44 @@
+// Package plugin runs rules implemented by external executables. wat talks to
+// each plugin over its stdin and stdout, one JSON message per line: it asks
+// for the plugin's rules once, then sends every resource of their types to
+// be checked.
+package plugin
+
+import "github.com/ilijad1/well-architected-terraform/internal/model"
+
+// ProtocolVersion is the version of the protocol this build speaks. Every
+// message carries it, and a plugin answering with another version is
+// rejected.
+const ProtocolVersion = 1
+
+// Request types.
+const (
+	// RequestMetadata asks for the plugin's rules. It is the first request a
+	// plugin process receives.
+	RequestMetadata = "metadata"
+	// RequestCheck asks a rule to check one resource.
+	RequestCheck = "check"
+)
+
+// Request is a message wat writes to a plugin's stdin.
+type Request struct {
+	Version  int                      `json:"version"`
+	Type     string                   `json:"type"`
+	RuleID   string                   `json:"rule_id,omitempty"`
+	Resource *model.TerraformResource `json:"resource,omitempty"`
+	Params   map[string]string        `json:"params,omitempty"`
+}
+
+// Response is a plugin's answer to a request, written to its stdout.
+type Response struct {
+	Version int `json:"version"`
+	// Rules answers a metadata request.
+	Rules []model.RuleMetadata `json:"rules,omitempty"`
+	// Findings answers a check request. Only their description, remediation,
+	// documentation URL and undetermined flag are used: the rule and
+	// resource details are those of the rule and resource checked.
+	Findings []model.Finding `json:"findings,omitempty"`
+	// Error reports that the plugin could not handle the request. A failed
+	// check is reported as an engine error.
+	Error string `json:"error,omitempty"`
+}
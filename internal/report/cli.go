@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -12,12 +13,18 @@ import (
 // CLIReporter outputs findings as a colored terminal table.
 type CLIReporter struct{}
 
-func (r *CLIReporter) Generate(w io.Writer, summary Summary) error {
+func (r *CLIReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
 	if summary.TotalFindings == 0 {
 		green := color.New(color.FgGreen, color.Bold)
 		_, _ = green.Fprintln(w, "No findings! Your Terraform configuration looks good.")
-		_, _ = fmt.Fprintf(w, "Scanned %d resources.\n", summary.TotalResources)
-		return nil
+		if len(summary.Stacks) > 0 {
+			_, _ = fmt.Fprintf(w, "Scanned %d resources in %d stacks.\n", summary.TotalResources, len(summary.Stacks))
+		} else {
+			_, _ = fmt.Fprintf(w, "Scanned %d resources.\n", summary.TotalResources)
+		}
+		writeCLIEngineErrors(w, summary.EngineErrors)
+		return ctx.Err()
 	}
 
 	// Header
@@ -31,6 +38,15 @@ func (r *CLIReporter) Generate(w io.Writer, summary Summary) error {
 	if summary.SuppressedFindings > 0 {
 		_, _ = fmt.Fprintf(w, "Suppressed:        %d\n", summary.SuppressedFindings)
 	}
+	if summary.UndeterminedFindings > 0 {
+		_, _ = fmt.Fprintf(w, "Undetermined:      %d\n", summary.UndeterminedFindings)
+	}
+	if summary.Regressions > 0 {
+		_, _ = color.New(color.FgRed, color.Bold).Fprintf(w, "Regressions:       %d\n", summary.Regressions)
+	}
+	if len(summary.EngineErrors) > 0 {
+		_, _ = color.New(color.FgRed, color.Bold).Fprintf(w, "Engine errors:     %d\n", len(summary.EngineErrors))
+	}
 	_, _ = fmt.Fprintln(w)
 
 	// Severity breakdown
@@ -60,12 +76,28 @@ func (r *CLIReporter) Generate(w io.Writer, summary Summary) error {
 	}
 	_, _ = fmt.Fprintln(w)
 
+	// Stack breakdown
+	if len(summary.Stacks) > 0 {
+		_, _ = bold.Fprintln(w, "By Stack:")
+		for _, st := range summary.Stacks {
+			_, _ = fmt.Fprintf(w, "  %-40s %d resources, %d findings", st.Stack, st.TotalResources, st.TotalFindings)
+			if st.Regressions > 0 {
+				_, _ = fmt.Fprintf(w, ", %d regressions", st.Regressions)
+			}
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
 	// Findings detail
 	_, _ = bold.Fprintln(w, "Findings:")
 	_, _ = fmt.Fprintf(w, "%s\n", strings.Repeat("-", 80))
 
 	for i, f := range summary.Findings {
-		_, _ = fmt.Fprintf(w, "\n%s [%s] %s\n", severityLabel(f.Severity), f.RuleID, f.RuleName)
+		_, _ = fmt.Fprintf(w, "\n%s%s [%s] %s%s\n", regressionLabel(f), severityLabel(f.Severity), f.RuleID, f.RuleName, undeterminedLabel(f))
+		if f.Stack != "" {
+			_, _ = fmt.Fprintf(w, "  Stack:       %s\n", f.Stack)
+		}
 		_, _ = fmt.Fprintf(w, "  Resource:    %s\n", f.Resource)
 		_, _ = fmt.Fprintf(w, "  Location:    %s:%d\n", f.File, f.Line)
 		_, _ = fmt.Fprintf(w, "  Description: %s\n", f.Description)
@@ -79,7 +111,22 @@ func (r *CLIReporter) Generate(w io.Writer, summary Summary) error {
 	}
 
 	_, _ = fmt.Fprintln(w)
-	return nil
+	writeCLIEngineErrors(w, summary.EngineErrors)
+	return ctx.Err()
+}
+
+// writeCLIEngineErrors lists the rule evaluations that failed. Stack traces
+// are left to the JSON and SARIF reports.
+func writeCLIEngineErrors(w io.Writer, errs []model.EngineError) {
+	if len(errs) == 0 {
+		return
+	}
+	_, _ = color.New(color.FgRed, color.Bold).Fprintln(w, "\nEngine Errors (these rules did not complete; results may be incomplete):")
+	for _, e := range errs {
+		_, _ = fmt.Fprintf(w, "  [%s] %s\n", e.RuleID, engineErrorTarget(e))
+		_, _ = fmt.Fprintf(w, "    %s\n", e.Message)
+	}
+	_, _ = fmt.Fprintln(w)
 }
 
 func severityLabel(s model.Severity) string {
@@ -98,3 +145,31 @@ func severityLabel(s model.Severity) string {
 		return string(s)
 	}
 }
+
+// undeterminedLabel marks findings whose outcome depends on values known only after apply.
+func undeterminedLabel(f model.Finding) string {
+	if !f.Undetermined {
+		return ""
+	}
+	return color.New(color.FgWhite).Sprint(" (cannot determine)")
+}
+
+// regressionLabel marks findings introduced by the plan.
+func regressionLabel(f model.Finding) string {
+	if !f.Regression {
+		return ""
+	}
+	return color.New(color.FgRed, color.Bold).Sprint("REGRESSION ")
+}
+
+// engineErrorTarget describes what a failed rule evaluation was checking.
+func engineErrorTarget(e model.EngineError) string {
+	target := e.Resource
+	if target == "" {
+		target = "all resources"
+	}
+	if e.Stack != "" {
+		target = e.Stack + ": " + target
+	}
+	return target
+}
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -8,17 +9,19 @@ import (
 	"strings"
 )
 
-// CSVReporter outputs findings as CSV.
+// CSVReporter outputs findings as CSV. Rule evaluations that failed follow
+// the findings as rows whose Severity is "ENGINE ERROR".
 type CSVReporter struct{}
 
-func (r *CSVReporter) Generate(w io.Writer, summary Summary) error {
+func (r *CSVReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
 	header := []string{
 		"RuleID", "RuleName", "Severity", "Pillar",
 		"Resource", "File", "Line",
-		"Description", "Remediation", "DocURL",
+		"Description", "Remediation", "DocURL", "Regression", "Undetermined", "Stack",
 	}
 	if err := writer.Write(header); err != nil {
 		return err
@@ -36,13 +39,38 @@ func (r *CSVReporter) Generate(w io.Writer, summary Summary) error {
 			f.Description,
 			f.Remediation,
 			f.DocURL,
+			fmt.Sprintf("%t", f.Regression),
+			fmt.Sprintf("%t", f.Undetermined),
+			f.Stack,
 		}
 		if err := writer.Write(row); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	for _, e := range summary.EngineErrors {
+		row := []string{
+			e.RuleID,
+			"",
+			"ENGINE ERROR",
+			"",
+			e.Resource,
+			"",
+			"",
+			e.Message,
+			"",
+			"",
+			"",
+			"",
+			e.Stack,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
 }
 
 // formatComplianceFrameworks serializes a map to "CIS:2.1.1;PCI:10.5.2" format.
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 )
@@ -8,7 +9,8 @@ import (
 // JSONReporter outputs findings as JSON.
 type JSONReporter struct{}
 
-func (r *JSONReporter) Generate(w io.Writer, summary Summary) error {
+func (r *JSONReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(summary)
@@ -1,9 +1,11 @@
 package report
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"sort"
 )
 
 // JUnit XML output structs.
@@ -13,6 +15,8 @@ type junitTestSuites struct {
 	Name     string           `xml:"name,attr"`
 	Tests    int              `xml:"tests,attr"`
 	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr,omitempty"`
+	Skipped  int              `xml:"skipped,attr,omitempty"`
 	Suites   []junitTestSuite `xml:"testsuite"`
 }
 
@@ -20,6 +24,8 @@ type junitTestSuite struct {
 	Name     string          `xml:"name,attr"`
 	Tests    int             `xml:"tests,attr"`
 	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr,omitempty"`
+	Skipped  int             `xml:"skipped,attr,omitempty"`
 	Cases    []junitTestCase `xml:"testcase"`
 }
 
@@ -27,6 +33,8 @@ type junitTestCase struct {
 	Name      string        `xml:"name,attr"`
 	ClassName string        `xml:"classname,attr"`
 	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
 }
 
 type junitFailure struct {
@@ -35,50 +43,103 @@ type junitFailure struct {
 	Text    string `xml:",chardata"`
 }
 
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
 // JUnitReporter outputs findings as JUnit XML.
 type JUnitReporter struct{}
 
-func (r *JUnitReporter) Generate(w io.Writer, summary Summary) error {
-	// Group findings by resource type
-	byType := make(map[string][]int) // resource type -> indices into summary.Findings
+func (r *JUnitReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
+	// Group findings by resource type, and by stack in multi-stack runs
+	byType := make(map[string][]int) // suite name -> indices into summary.Findings
 	for i, f := range summary.Findings {
 		// Extract resource type from the resource address (e.g. "aws_s3_bucket.foo" -> "aws_s3_bucket")
 		rt := resourceTypeFromAddress(f.Resource)
+		if f.Stack != "" {
+			rt = f.Stack + ": " + rt
+		}
 		byType[rt] = append(byType[rt], i)
 	}
 
+	// Emit suites in a stable order so reports can be diffed between runs
+	types := make([]string, 0, len(byType))
+	for rt := range byType {
+		types = append(types, rt)
+	}
+	sort.Strings(types)
+
 	var suites []junitTestSuite
 	totalTests := 0
 	totalFailures := 0
+	totalSkipped := 0
 
-	for rt, indices := range byType {
+	for _, rt := range types {
+		indices := byType[rt]
 		suite := junitTestSuite{
-			Name:     rt,
-			Tests:    len(indices),
-			Failures: len(indices),
+			Name:  rt,
+			Tests: len(indices),
 		}
 		for _, idx := range indices {
 			f := summary.Findings[idx]
+			name := fmt.Sprintf("[%s] %s", f.RuleID, f.RuleName)
+			if f.Regression {
+				name = "REGRESSION " + name
+			}
 			tc := junitTestCase{
-				Name:      fmt.Sprintf("[%s] %s", f.RuleID, f.RuleName),
+				Name:      name,
 				ClassName: f.Resource,
-				Failure: &junitFailure{
+			}
+			// A result that depends on values known only after apply is
+			// not a confirmed failure. Its description already says so.
+			if f.Undetermined {
+				tc.Skipped = &junitSkipped{Message: f.Description}
+				suite.Skipped++
+			} else {
+				tc.Failure = &junitFailure{
 					Message: f.Description,
 					Type:    string(f.Severity),
 					Text:    fmt.Sprintf("Remediation: %s", f.Remediation),
-				},
+				}
+				suite.Failures++
 			}
 			suite.Cases = append(suite.Cases, tc)
 		}
 		suites = append(suites, suite)
 		totalTests += suite.Tests
 		totalFailures += suite.Failures
+		totalSkipped += suite.Skipped
+	}
+
+	// Rule evaluations that failed are errored test cases of their own suite
+	if len(summary.EngineErrors) > 0 {
+		suite := junitTestSuite{
+			Name:   "engine errors",
+			Tests:  len(summary.EngineErrors),
+			Errors: len(summary.EngineErrors),
+		}
+		for _, e := range summary.EngineErrors {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fmt.Sprintf("[%s] engine error", e.RuleID),
+				ClassName: engineErrorTarget(e),
+				Error: &junitFailure{
+					Message: e.Message,
+					Type:    "EngineError",
+					Text:    e.Trace,
+				},
+			})
+		}
+		suites = append(suites, suite)
+		totalTests += suite.Tests
 	}
 
 	ts := junitTestSuites{
 		Name:     "WAT Well-Architected Analysis",
 		Tests:    totalTests,
 		Failures: totalFailures,
+		Errors:   len(summary.EngineErrors),
+		Skipped:  totalSkipped,
 		Suites:   suites,
 	}
 
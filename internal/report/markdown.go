@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -10,7 +11,8 @@ import (
 // MarkdownReporter outputs findings as a Markdown document.
 type MarkdownReporter struct{}
 
-func (r *MarkdownReporter) Generate(w io.Writer, summary Summary) error {
+func (r *MarkdownReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
 	_, _ = fmt.Fprintln(w, "# AWS Well-Architected Analysis Report")
 	_, _ = fmt.Fprintln(w)
 
@@ -19,16 +21,56 @@ func (r *MarkdownReporter) Generate(w io.Writer, summary Summary) error {
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintf(w, "| Metric | Value |\n")
 	_, _ = fmt.Fprintf(w, "|--------|-------|\n")
+	if len(summary.Stacks) > 0 {
+		_, _ = fmt.Fprintf(w, "| Stacks | %d |\n", len(summary.Stacks))
+	}
 	_, _ = fmt.Fprintf(w, "| Resources Scanned | %d |\n", summary.TotalResources)
 	_, _ = fmt.Fprintf(w, "| Total Findings | %d |\n", summary.TotalFindings)
 	if summary.SuppressedFindings > 0 {
 		_, _ = fmt.Fprintf(w, "| Suppressed Findings | %d |\n", summary.SuppressedFindings)
 	}
+	if summary.UndeterminedFindings > 0 {
+		_, _ = fmt.Fprintf(w, "| Undetermined Findings | %d |\n", summary.UndeterminedFindings)
+	}
+	if summary.Regressions > 0 {
+		_, _ = fmt.Fprintf(w, "| **Regressions** | **%d** |\n", summary.Regressions)
+	}
+	if len(summary.EngineErrors) > 0 {
+		_, _ = fmt.Fprintf(w, "| **Engine Errors** | **%d** |\n", len(summary.EngineErrors))
+	}
 	_, _ = fmt.Fprintln(w)
 
+	// Engine errors
+	if len(summary.EngineErrors) > 0 {
+		_, _ = fmt.Fprintln(w, "## Engine Errors")
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "These rules did not complete, so results may be incomplete.")
+		_, _ = fmt.Fprintln(w)
+		for _, e := range summary.EngineErrors {
+			_, _ = fmt.Fprintf(w, "- **[%s]** `%s`: %s\n", e.RuleID, engineErrorTarget(e), e.Message)
+			if e.Trace != "" {
+				_, _ = fmt.Fprintf(w, "\n<details><summary>Stack trace</summary>\n\n```\n%s```\n\n</details>\n\n", e.Trace)
+			}
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	// Stack breakdown
+	if len(summary.Stacks) > 0 {
+		_, _ = fmt.Fprintln(w, "## Stacks")
+		_, _ = fmt.Fprintln(w)
+		_, _ = fmt.Fprintln(w, "| Stack | Resources | Findings | Critical | High | Suppressed | Regressions |")
+		_, _ = fmt.Fprintln(w, "|-------|-----------|----------|----------|------|------------|-------------|")
+		for _, st := range summary.Stacks {
+			_, _ = fmt.Fprintf(w, "| `%s` | %d | %d | %d | %d | %d | %d |\n", st.Stack, st.TotalResources, st.TotalFindings,
+				st.BySeverity[model.SeverityCritical], st.BySeverity[model.SeverityHigh], st.SuppressedFindings, st.Regressions)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
 	if summary.TotalFindings == 0 {
 		_, _ = fmt.Fprintln(w, "No findings. Your Terraform configuration looks good!")
-		return nil
+		return ctx.Err()
 	}
 
 	// Severity breakdown
@@ -60,7 +102,14 @@ func (r *MarkdownReporter) Generate(w io.Writer, summary Summary) error {
 	_, _ = fmt.Fprintln(w)
 
 	for i, f := range summary.Findings {
-		_, _ = fmt.Fprintf(w, "### %d. [%s] %s — %s\n\n", i+1, f.RuleID, f.RuleName, f.Severity)
+		regression := ""
+		if f.Regression {
+			regression = " — **REGRESSION**"
+		}
+		_, _ = fmt.Fprintf(w, "### %d. [%s] %s — %s%s\n\n", i+1, f.RuleID, f.RuleName, f.Severity, regression)
+		if f.Stack != "" {
+			_, _ = fmt.Fprintf(w, "- **Stack:** `%s`\n", f.Stack)
+		}
 		_, _ = fmt.Fprintf(w, "- **Resource:** `%s`\n", f.Resource)
 		_, _ = fmt.Fprintf(w, "- **Location:** `%s:%d`\n", f.File, f.Line)
 		_, _ = fmt.Fprintf(w, "- **Pillar:** %s\n", f.Pillar)
@@ -72,5 +121,5 @@ func (r *MarkdownReporter) Generate(w io.Writer, summary Summary) error {
 		_, _ = fmt.Fprintln(w)
 	}
 
-	return nil
+	return ctx.Err()
 }
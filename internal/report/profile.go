@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ilijad1/well-architected-terraform/internal/model"
+)
+
+// Profile holds where the time of a run went.
+type Profile struct {
+	// Total is the wall time of the whole run.
+	Total time.Duration `json:"total_ns"`
+	// Parse is the time spent reading and parsing input, summed across stacks.
+	Parse time.Duration `json:"parse_ns"`
+	// Report is the time spent generating the report.
+	Report time.Duration `json:"report_ns"`
+	// Rules lists each rule's evaluations, slowest first.
+	Rules []model.RuleProfile `json:"rules"`
+}
+
+// WriteProfileTable writes the profile as a table of phases followed by a
+// table of rules.
+func WriteProfileTable(w io.Writer, p Profile) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "PHASE\tWALL TIME\n")
+	_, _ = fmt.Fprintf(tw, "-----\t---------\n")
+	_, _ = fmt.Fprintf(tw, "parse\t%s\n", roundDuration(p.Parse))
+	_, _ = fmt.Fprintf(tw, "rules\t%s\n", roundDuration(rulesWallTime(p.Rules)))
+	_, _ = fmt.Fprintf(tw, "report\t%s\n", roundDuration(p.Report))
+	_, _ = fmt.Fprintf(tw, "total\t%s\n", roundDuration(p.Total))
+	_, _ = fmt.Fprintln(tw)
+
+	_, _ = fmt.Fprintf(tw, "RULE\tKIND\tEVALUATIONS\tFINDINGS\tWALL TIME\tAVERAGE\n")
+	_, _ = fmt.Fprintf(tw, "----\t----\t-----------\t--------\t---------\t-------\n")
+	for _, r := range p.Rules {
+		var avg time.Duration
+		if r.Evaluations > 0 {
+			avg = r.WallTime / time.Duration(r.Evaluations)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n",
+			r.RuleID, r.Kind, r.Evaluations, r.Findings, roundDuration(r.WallTime), roundDuration(avg))
+	}
+	return tw.Flush()
+}
+
+// WriteProfileJSON writes the profile as JSON.
+func WriteProfileJSON(w io.Writer, p Profile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// rulesWallTime is the summed wall time of every rule. Rules evaluated in
+// parallel make it larger than the run's elapsed time.
+func rulesWallTime(rules []model.RuleProfile) time.Duration {
+	var total time.Duration
+	for _, r := range rules {
+		total += r.WallTime
+	}
+	return total
+}
+
+// roundDuration rounds d to a precision that keeps the table readable.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
@@ -2,10 +2,13 @@ package report
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,12 +106,155 @@ func TestBuildSummary(t *testing.T) {
 	assert.Equal(t, "S3-001", summary.Findings[1].RuleID)
 }
 
+func TestBuildSummary_CountsUndetermined(t *testing.T) {
+	findings := []model.Finding{
+		{RuleID: "RDS-001", Severity: model.SeverityHigh, Pillar: model.PillarSecurity, Undetermined: true},
+		{RuleID: "EC2-001", Severity: model.SeverityHigh, Pillar: model.PillarReliability},
+	}
+
+	summary := BuildSummary(nil, findings)
+	assert.Equal(t, 2, summary.TotalFindings)
+	assert.Equal(t, 1, summary.UndeterminedFindings)
+}
+
+func TestBuildSummary_RegressionsFirst(t *testing.T) {
+	findings := []model.Finding{
+		{RuleID: "EC2-001", Severity: model.SeverityCritical, Pillar: model.PillarSecurity},
+		{RuleID: "RDS-013", Severity: model.SeverityMedium, Pillar: model.PillarReliability, Regression: true},
+	}
+
+	summary := BuildSummary(nil, findings)
+	assert.Equal(t, 1, summary.Regressions)
+	assert.Equal(t, "RDS-013", summary.Findings[0].RuleID)
+}
+
+func TestNewStackSummary(t *testing.T) {
+	findings := []model.Finding{
+		{RuleID: "EC2-001", Severity: model.SeverityCritical, Stack: "network"},
+		{RuleID: "RDS-013", Severity: model.SeverityMedium, Stack: "network", Regression: true},
+	}
+
+	ss := NewStackSummary("network", 4, findings)
+	assert.Equal(t, "network", ss.Stack)
+	assert.Equal(t, 4, ss.TotalResources)
+	assert.Equal(t, 2, ss.TotalFindings)
+	assert.Equal(t, 1, ss.Regressions)
+	assert.Equal(t, 1, ss.BySeverity[model.SeverityCritical])
+}
+
+func stackedSummary() Summary {
+	summary := testSummary()
+	for i := range summary.Findings {
+		summary.Findings[i].Stack = "network"
+	}
+	summary.Stacks = []StackSummary{
+		NewStackSummary("network", 3, summary.Findings),
+		NewStackSummary("app", 2, nil),
+	}
+	return summary
+}
+
+func TestReporters_Stacks(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, (&MarkdownReporter{}).Generate(context.Background(), &buf, stackedSummary()))
+	assert.Contains(t, buf.String(), "| `network` | 3 | 2 |")
+	assert.Contains(t, buf.String(), "| `app` | 2 | 0 |")
+	assert.Contains(t, buf.String(), "- **Stack:** `network`")
+
+	buf.Reset()
+	require.NoError(t, (&SARIFReporter{}).Generate(context.Background(), &buf, stackedSummary()))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "network", log.Runs[0].Results[0].Properties["stack"])
+	assert.Len(t, log.Runs[0].Properties["stacks"], 2)
+
+	buf.Reset()
+	require.NoError(t, (&CSVReporter{}).Generate(context.Background(), &buf, stackedSummary()))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.True(t, strings.HasSuffix(lines[0], ",Stack"))
+	assert.True(t, strings.HasSuffix(lines[1], ",network"))
+
+	buf.Reset()
+	require.NoError(t, (&JUnitReporter{}).Generate(context.Background(), &buf, stackedSummary()))
+	assert.Contains(t, buf.String(), `<testsuite name="network: aws_s3_bucket"`)
+}
+
+func engineErrorSummary() Summary {
+	summary := testSummary()
+	summary.EngineErrors = []model.EngineError{
+		{RuleID: "S3-009", Message: "panic: index out of range", Trace: "goroutine 7 [running]:\nmain.go:1\n"},
+		{RuleID: "EC2-002", Resource: "aws_instance.web", Message: "root_block_device is not a list", Stack: "app"},
+	}
+	return summary
+}
+
+func TestReporters_EngineErrors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, (&CLIReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	assert.Contains(t, buf.String(), "Engine errors:     2")
+	assert.Contains(t, buf.String(), "[S3-009] all resources")
+	assert.Contains(t, buf.String(), "[EC2-002] app: aws_instance.web\n    root_block_device is not a list")
+
+	buf.Reset()
+	noFindings := Summary{TotalResources: 1, EngineErrors: engineErrorSummary().EngineErrors}
+	require.NoError(t, (&CLIReporter{}).Generate(context.Background(), &buf, noFindings))
+	assert.Contains(t, buf.String(), "[S3-009] all resources")
+
+	buf.Reset()
+	require.NoError(t, (&MarkdownReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	assert.Contains(t, buf.String(), "| **Engine Errors** | **2** |")
+	assert.Contains(t, buf.String(), "- **[EC2-002]** `app: aws_instance.web`: root_block_device is not a list")
+	assert.Contains(t, buf.String(), "```\ngoroutine 7 [running]:\nmain.go:1\n```")
+
+	buf.Reset()
+	require.NoError(t, (&JSONReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	var decoded Summary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, engineErrorSummary().EngineErrors, decoded.EngineErrors)
+
+	buf.Reset()
+	require.NoError(t, (&SARIFReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs[0].Invocations, 1)
+	inv := log.Runs[0].Invocations[0]
+	assert.False(t, inv.ExecutionSuccessful)
+	require.Len(t, inv.ToolExecutionNotifications, 2)
+	assert.Equal(t, "S3-009", inv.ToolExecutionNotifications[0].AssociatedRule.ID)
+	assert.Equal(t, "goroutine 7 [running]:\nmain.go:1\n", inv.ToolExecutionNotifications[0].Properties["trace"])
+	assert.Equal(t, "aws_instance.web", inv.ToolExecutionNotifications[1].Properties["resource"])
+
+	buf.Reset()
+	require.NoError(t, (&JUnitReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	var ts junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &ts))
+	assert.Equal(t, 4, ts.Tests)
+	assert.Equal(t, 2, ts.Failures)
+	assert.Equal(t, 2, ts.Errors)
+	assert.Contains(t, buf.String(), `<error message="panic: index out of range" type="EngineError">`)
+
+	buf.Reset()
+	require.NoError(t, (&CSVReporter{}).Generate(context.Background(), &buf, engineErrorSummary()))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, []string{"S3-009", "", "ENGINE ERROR", "", "", "", "", "panic: index out of range", "", "", "", "", ""}, rows[3])
+	assert.Equal(t, "aws_instance.web", rows[4][4])
+	assert.Equal(t, "app", rows[4][len(rows[4])-1])
+}
+
+func TestSARIFReporter_NoInvocationsWithoutEngineErrors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, (&SARIFReporter{}).Generate(context.Background(), &buf, testSummary()))
+	assert.NotContains(t, buf.String(), "invocations")
+}
+
 // --- SARIF tests ---
 
 func TestSARIFReporter_ValidJSON(t *testing.T) {
 	var buf bytes.Buffer
 	r := &SARIFReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	var log sarifLog
@@ -140,7 +286,7 @@ func TestSARIFReporter_SeverityMapping(t *testing.T) {
 func TestSARIFReporter_ComplianceFrameworksInProperties(t *testing.T) {
 	var buf bytes.Buffer
 	r := &SARIFReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	var log sarifLog
@@ -161,7 +307,7 @@ func TestSARIFReporter_ComplianceFrameworksInProperties(t *testing.T) {
 func TestSARIFReporter_Locations(t *testing.T) {
 	var buf bytes.Buffer
 	r := &SARIFReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	var log sarifLog
@@ -178,7 +324,7 @@ func TestSARIFReporter_Locations(t *testing.T) {
 func TestJUnitReporter_ValidXML(t *testing.T) {
 	var buf bytes.Buffer
 	r := &JUnitReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	var ts junitTestSuites
@@ -193,7 +339,7 @@ func TestJUnitReporter_ValidXML(t *testing.T) {
 func TestJUnitReporter_FailureDetails(t *testing.T) {
 	var buf bytes.Buffer
 	r := &JUnitReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	output := buf.String()
@@ -206,7 +352,7 @@ func TestJUnitReporter_EmptyFindings(t *testing.T) {
 	var buf bytes.Buffer
 	r := &JUnitReporter{}
 	summary := Summary{TotalResources: 5, TotalFindings: 0}
-	err := r.Generate(&buf, summary)
+	err := r.Generate(context.Background(), &buf, summary)
 	require.NoError(t, err)
 
 	var ts junitTestSuites
@@ -214,12 +360,39 @@ func TestJUnitReporter_EmptyFindings(t *testing.T) {
 	assert.Equal(t, 0, ts.Tests)
 }
 
+func TestJUnitReporter_Undetermined(t *testing.T) {
+	summary := testSummary()
+	summary.Findings[1] = summary.Findings[1].AsUndetermined("storage_encrypted is known only after apply")
+
+	var buf bytes.Buffer
+	require.NoError(t, (&JUnitReporter{}).Generate(context.Background(), &buf, summary))
+	var ts junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &ts))
+	assert.Equal(t, 2, ts.Tests)
+	assert.Equal(t, 1, ts.Failures)
+	assert.Equal(t, 1, ts.Skipped)
+	assert.Contains(t, buf.String(), `<skipped message="Cannot determine: storage_encrypted is known only after apply"></skipped>`)
+	assert.Equal(t, 1, strings.Count(buf.String(), "Cannot determine:"))
+}
+
+func TestJUnitReporter_SuiteOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		require.NoError(t, (&JUnitReporter{}).Generate(context.Background(), &buf, testSummary()))
+		var ts junitTestSuites
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &ts))
+		require.Len(t, ts.Suites, 2)
+		assert.Equal(t, "aws_db_instance", ts.Suites[0].Name)
+		assert.Equal(t, "aws_s3_bucket", ts.Suites[1].Name)
+	}
+}
+
 // --- CSV tests ---
 
 func TestCSVReporter_Header(t *testing.T) {
 	var buf bytes.Buffer
 	r := &CSVReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
@@ -232,7 +405,7 @@ func TestCSVReporter_Header(t *testing.T) {
 func TestCSVReporter_RowCount(t *testing.T) {
 	var buf bytes.Buffer
 	r := &CSVReporter{}
-	err := r.Generate(&buf, testSummary())
+	err := r.Generate(context.Background(), &buf, testSummary())
 	require.NoError(t, err)
 
 	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
@@ -240,11 +413,31 @@ func TestCSVReporter_RowCount(t *testing.T) {
 	assert.Len(t, lines, 3)
 }
 
+func TestCSVReporter_Undetermined(t *testing.T) {
+	summary := testSummary()
+	summary.Findings[1].Undetermined = true
+
+	var buf bytes.Buffer
+	require.NoError(t, (&CSVReporter{}).Generate(context.Background(), &buf, summary))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	col := -1
+	for i, name := range rows[0] {
+		if name == "Undetermined" {
+			col = i
+		}
+	}
+	require.NotEqual(t, -1, col)
+	assert.Equal(t, "false", rows[1][col])
+	assert.Equal(t, "true", rows[2][col])
+}
+
 func TestCSVReporter_EmptyFindings(t *testing.T) {
 	var buf bytes.Buffer
 	r := &CSVReporter{}
 	summary := Summary{TotalResources: 5, TotalFindings: 0}
-	err := r.Generate(&buf, summary)
+	err := r.Generate(context.Background(), &buf, summary)
 	require.NoError(t, err)
 
 	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
@@ -281,3 +474,48 @@ func TestFormatComplianceFrameworks(t *testing.T) {
 		assert.Equal(t, tt.want, formatComplianceFrameworks(tt.input))
 	}
 }
+
+func TestReporters_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, format := range []Format{FormatCLI, FormatJSON, FormatMarkdown, FormatSARIF, FormatJUnit, FormatCSV} {
+		var buf bytes.Buffer
+		err := NewReporter(format).Generate(ctx, &buf, testSummary())
+		assert.ErrorIs(t, err, context.Canceled, format)
+		assert.Empty(t, buf.String(), format)
+	}
+}
+
+// --- Profile tests ---
+
+func testProfile() Profile {
+	return Profile{
+		Total:  1500 * time.Millisecond,
+		Parse:  300 * time.Millisecond,
+		Report: 2 * time.Millisecond,
+		Rules: []model.RuleProfile{
+			{RuleID: "S3-CROSS", Kind: "cross", Evaluations: 1, Findings: 4, WallTime: 1234567891 * time.Nanosecond},
+			{RuleID: "S3-001", Kind: "single", Evaluations: 4, Findings: 0, WallTime: 8 * time.Microsecond},
+		},
+	}
+}
+
+func TestWriteProfileTable(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteProfileTable(&buf, testProfile()))
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "parse   300ms", lines[2])
+	assert.Equal(t, "rules   1.235s", lines[3])
+	assert.Equal(t, "total   1.5s", lines[5])
+	assert.Equal(t, "S3-CROSS  cross   1            4         1.235s     1.235s", lines[9])
+	assert.Equal(t, "S3-001    single  4            0         8µs        2µs", lines[10])
+}
+
+func TestWriteProfileJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteProfileJSON(&buf, testProfile()))
+	var decoded Profile
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, testProfile(), decoded)
+	assert.Contains(t, buf.String(), `"wall_time_ns": 1234567891`)
+}
@@ -2,6 +2,7 @@
 package report
 
 import (
+	"context"
 	"io"
 	"sort"
 
@@ -22,19 +23,49 @@ const (
 
 // Summary holds the analysis results for report generation.
 type Summary struct {
-	TotalResources      int                    `json:"total_resources"`
-	TotalFindings       int                    `json:"total_findings"`
-	SuppressedFindings  int                    `json:"suppressed_findings"`
-	ExpiredSuppressions []string               `json:"expired_suppressions,omitempty"`
-	BySeverity          map[model.Severity]int `json:"by_severity"`
-	ByPillar            map[model.Pillar]int   `json:"by_pillar"`
-	Findings            []model.Finding        `json:"findings"`
-	RuleMetadata        []model.RuleMetadata   `json:"rule_metadata,omitempty"`
+	TotalResources       int                    `json:"total_resources"`
+	TotalFindings        int                    `json:"total_findings"`
+	SuppressedFindings   int                    `json:"suppressed_findings"`
+	UndeterminedFindings int                    `json:"undetermined_findings,omitempty"`
+	Regressions          int                    `json:"regressions,omitempty"`
+	ExpiredSuppressions  []string               `json:"expired_suppressions,omitempty"`
+	BySeverity           map[model.Severity]int `json:"by_severity"`
+	ByPillar             map[model.Pillar]int   `json:"by_pillar"`
+	Stacks               []StackSummary         `json:"stacks,omitempty"`
+	Findings             []model.Finding        `json:"findings"`
+	RuleMetadata         []model.RuleMetadata   `json:"rule_metadata,omitempty"`
+	EngineErrors         []model.EngineError    `json:"engine_errors,omitempty"`
 }
 
-// Reporter generates output in a specific format.
+// StackSummary holds the counts for one stack of a multi-stack run.
+type StackSummary struct {
+	Stack                string                 `json:"stack"`
+	TotalResources       int                    `json:"total_resources"`
+	TotalFindings        int                    `json:"total_findings"`
+	SuppressedFindings   int                    `json:"suppressed_findings"`
+	UndeterminedFindings int                    `json:"undetermined_findings,omitempty"`
+	Regressions          int                    `json:"regressions,omitempty"`
+	BySeverity           map[model.Severity]int `json:"by_severity"`
+}
+
+// Reporter generates output in a specific format. Generate stops with ctx's
+// error once ctx is done.
 type Reporter interface {
-	Generate(w io.Writer, summary Summary) error
+	Generate(ctx context.Context, w io.Writer, summary Summary) error
+}
+
+// contextWriter fails every write once ctx is done, so a report being written
+// when the run is cancelled stops there.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c contextWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
 }
 
 // NewReporter creates a reporter for the given format.
@@ -57,8 +88,14 @@ func NewReporter(format Format) Reporter {
 
 // BuildSummary creates a Summary from resources and findings.
 func BuildSummary(resources []model.TerraformResource, findings []model.Finding) Summary {
+	return NewSummary(len(resources), findings)
+}
+
+// NewSummary creates a Summary from a resource count and findings, for callers
+// that stream resources rather than hold them.
+func NewSummary(totalResources int, findings []model.Finding) Summary {
 	summary := Summary{
-		TotalResources: len(resources),
+		TotalResources: totalResources,
 		TotalFindings:  len(findings),
 		BySeverity:     make(map[model.Severity]int),
 		ByPillar:       make(map[model.Pillar]int),
@@ -68,10 +105,19 @@ func BuildSummary(resources []model.TerraformResource, findings []model.Finding)
 	for _, f := range findings {
 		summary.BySeverity[f.Severity]++
 		summary.ByPillar[f.Pillar]++
+		if f.Undetermined {
+			summary.UndeterminedFindings++
+		}
+		if f.Regression {
+			summary.Regressions++
+		}
 	}
 
-	// Sort findings by severity (most severe first)
+	// Sort findings with regressions first, then by severity (most severe first)
 	sort.Slice(summary.Findings, func(i, j int) bool {
+		if summary.Findings[i].Regression != summary.Findings[j].Regression {
+			return summary.Findings[i].Regression
+		}
 		ri := model.SeverityRank(summary.Findings[i].Severity)
 		rj := model.SeverityRank(summary.Findings[j].Severity)
 		if ri != rj {
@@ -82,3 +128,23 @@ func BuildSummary(resources []model.TerraformResource, findings []model.Finding)
 
 	return summary
 }
+
+// NewStackSummary counts the findings of one stack.
+func NewStackSummary(stack string, totalResources int, findings []model.Finding) StackSummary {
+	ss := StackSummary{
+		Stack:          stack,
+		TotalResources: totalResources,
+		TotalFindings:  len(findings),
+		BySeverity:     make(map[model.Severity]int),
+	}
+	for _, f := range findings {
+		ss.BySeverity[f.Severity]++
+		if f.Undetermined {
+			ss.UndeterminedFindings++
+		}
+		if f.Regression {
+			ss.Regressions++
+		}
+	}
+	return ss
+}
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 
@@ -16,8 +17,26 @@ type sarifLog struct {
 }
 
 type sarifRun struct {
-	Tool    sarifTool     `json:"tool"`
-	Results []sarifResult `json:"results"`
+	Tool        sarifTool              `json:"tool"`
+	Invocations []sarifInvocation      `json:"invocations,omitempty"`
+	Results     []sarifResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level          string                 `json:"level"`
+	Message        sarifMessage           `json:"message"`
+	AssociatedRule *sarifRuleReference    `json:"associatedRule,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifRuleReference struct {
+	ID string `json:"id"`
 }
 
 type sarifTool struct {
@@ -40,10 +59,11 @@ type sarifReportingDescriptor struct {
 }
 
 type sarifResult struct {
-	RuleID    string          `json:"ruleId"`
-	Level     string          `json:"level"`
-	Message   sarifMessage    `json:"message"`
-	Locations []sarifLocation `json:"locations,omitempty"`
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
 }
 
 type sarifMessage struct {
@@ -70,7 +90,8 @@ type sarifRegion struct {
 // SARIFReporter outputs findings in SARIF 2.1.0 JSON format.
 type SARIFReporter struct{}
 
-func (r *SARIFReporter) Generate(w io.Writer, summary Summary) error {
+func (r *SARIFReporter) Generate(ctx context.Context, w io.Writer, summary Summary) error {
+	w = contextWriter{ctx: ctx, w: w}
 	// Build rule descriptors from metadata
 	ruleIndex := make(map[string]bool)
 	var rules []sarifReportingDescriptor
@@ -114,6 +135,19 @@ func (r *SARIFReporter) Generate(w io.Writer, summary Summary) error {
 			Level:   severityToSARIFLevel(f.Severity),
 			Message: sarifMessage{Text: f.Description + " Remediation: " + f.Remediation},
 		}
+		if f.Undetermined {
+			result.Level = "note"
+		}
+		if f.Regression {
+			result.Message.Text = "Regression: " + result.Message.Text
+			result.Properties = map[string]interface{}{"regression": true}
+		}
+		if f.Stack != "" {
+			if result.Properties == nil {
+				result.Properties = make(map[string]interface{})
+			}
+			result.Properties["stack"] = f.Stack
+		}
 		if f.File != "" {
 			loc := sarifLocation{
 				PhysicalLocation: sarifPhysicalLocation{
@@ -128,18 +162,26 @@ func (r *SARIFReporter) Generate(w io.Writer, summary Summary) error {
 		results = append(results, result)
 	}
 
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:  "wat",
+				Rules: rules,
+			},
+		},
+		Results: results,
+	}
+	if len(summary.Stacks) > 0 {
+		run.Properties = map[string]interface{}{"stacks": summary.Stacks}
+	}
+	if len(summary.EngineErrors) > 0 {
+		run.Invocations = []sarifInvocation{engineErrorInvocation(summary.EngineErrors)}
+	}
+
 	log := sarifLog{
 		Version: "2.1.0",
 		Schema:  "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json",
-		Runs: []sarifRun{{
-			Tool: sarifTool{
-				Driver: sarifDriver{
-					Name:  "wat",
-					Rules: rules,
-				},
-			},
-			Results: results,
-		}},
+		Runs:    []sarifRun{run},
 	}
 
 	enc := json.NewEncoder(w)
@@ -147,6 +189,31 @@ func (r *SARIFReporter) Generate(w io.Writer, summary Summary) error {
 	return enc.Encode(log)
 }
 
+// engineErrorInvocation reports failed rule evaluations as tool execution
+// notifications of an unsuccessful invocation.
+func engineErrorInvocation(errs []model.EngineError) sarifInvocation {
+	inv := sarifInvocation{ExecutionSuccessful: false}
+	for _, e := range errs {
+		n := sarifNotification{
+			Level:          "error",
+			Message:        sarifMessage{Text: e.Message},
+			AssociatedRule: &sarifRuleReference{ID: e.RuleID},
+			Properties:     make(map[string]interface{}),
+		}
+		if e.Resource != "" {
+			n.Properties["resource"] = e.Resource
+		}
+		if e.Stack != "" {
+			n.Properties["stack"] = e.Stack
+		}
+		if e.Trace != "" {
+			n.Properties["trace"] = e.Trace
+		}
+		inv.ToolExecutionNotifications = append(inv.ToolExecutionNotifications, n)
+	}
+	return inv
+}
+
 func severityToSARIFLevel(s model.Severity) string {
 	switch s {
 	case model.SeverityCritical, model.SeverityHigh:
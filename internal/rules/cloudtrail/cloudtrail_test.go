@@ -115,7 +115,7 @@ func makeRes(resType, name string, attrs map[string]interface{}) model.Terraform
 
 func TestCrossLogGroup_NoTrails(t *testing.T) {
 	r := &CrossLogGroupRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
 
@@ -129,7 +129,7 @@ func TestCrossLogGroup_TrailWithMatchingLogGroup(t *testing.T) {
 			"name": "cloudtrail-logs",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -140,7 +140,7 @@ func TestCrossLogGroup_TrailMissingLogGroup(t *testing.T) {
 			"cloud_watch_logs_group_arn": "arn:aws:logs:us-east-1:123456789012:log-group:cloudtrail-logs:*",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "CT-007", findings[0].RuleID)
 }
@@ -150,7 +150,7 @@ func TestCrossLogGroup_TrailNoARN_NoLogGroupInPlan(t *testing.T) {
 	resources := []model.TerraformResource{
 		makeRes("aws_cloudtrail", "main", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "CT-007", findings[0].RuleID)
 }
@@ -26,22 +26,29 @@ func (r *CrossLogGroupRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	logGroupNames := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_cloudwatch_log_group" {
-			name, ok := res.GetStringAttr("name")
-			if ok && name != "" {
-				logGroupNames[name] = true
-			}
-			logGroupNames[res.Name] = true
-			logGroupNames[res.Address()] = true
-		}
-	}
+func (r *CrossLogGroupRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	hasLogGroups := len(resources.OfType("aws_cloudwatch_log_group")) > 0
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_cloudtrail" {
+	for _, res := range resources.OfType("aws_cloudtrail") {
+		// The ARN references, or equals the ARN of, a log group in the plan
+		if len(resources.Referenced(res, "cloud_watch_logs_group_arn", "aws_cloudwatch_log_group")) > 0 {
+			continue
+		}
+
+		if res.IsUnknown("cloud_watch_logs_group_arn") {
+			// The ARN is only known after apply and does not reference a log
+			// group in the plan, e.g. because it comes from a module output.
+			findings = append(findings, model.Finding{
+				RuleID:      "CT-007",
+				RuleName:    "CloudTrail Missing CloudWatch Log Group",
+				Severity:    model.SeverityHigh,
+				Pillar:      model.PillarOperationalExcellence,
+				Resource:    res.Address(),
+				File:        res.File,
+				Line:        res.Line,
+				Remediation: "Add an aws_cloudwatch_log_group resource and reference it in the CloudTrail's cloud_watch_logs_group_arn attribute.",
+			}.AsUndetermined("cloud_watch_logs_group_arn is only known after apply."))
 			continue
 		}
 
@@ -49,7 +56,7 @@ func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []m
 		if !hasARN || arn == "" {
 			// No log group configured at all — CT-004 already flags this as an attribute issue;
 			// CT-007 flags that no log group resource exists in the plan.
-			if len(logGroupNames) == 0 {
+			if !hasLogGroups {
 				findings = append(findings, model.Finding{
 					RuleID:      "CT-007",
 					RuleName:    "CloudTrail Missing CloudWatch Log Group",
@@ -65,14 +72,8 @@ func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []m
 			continue
 		}
 
-		// Check if the ARN references a log group that exists in the plan.
-		found := false
-		for groupName := range logGroupNames {
-			if strings.Contains(arn, groupName) {
-				found = true
-				break
-			}
-		}
+		// Check if the ARN names a log group that exists in the plan.
+		found := len(resources.Lookup("aws_cloudwatch_log_group", logGroupName(arn))) > 0
 
 		if !found {
 			findings = append(findings, model.Finding{
@@ -91,3 +92,14 @@ func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []m
 
 	return findings
 }
+
+// logGroupName returns the log group name in a CloudWatch Logs log group ARN,
+// e.g. "trail" for "arn:aws:logs:us-east-1:123456789012:log-group:trail:*".
+func logGroupName(arn string) string {
+	const marker = ":log-group:"
+	i := strings.Index(arn, marker)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(arn[i+len(marker):], ":*")
+}
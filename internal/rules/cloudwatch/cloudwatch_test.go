@@ -3,8 +3,10 @@ package cloudwatch
 import (
 	"testing"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // LogRetentionRule Tests
@@ -18,7 +20,8 @@ func TestLogRetentionRule_Pass(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &LogRetentionRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
 }
 
@@ -30,8 +33,29 @@ func TestLogRetentionRule_Fail(t *testing.T) {
 		Blocks:     map[string][]model.Block{},
 	}
 	rule := &LogRetentionRule{}
-	findings := rule.Evaluate(resource)
-	assert.NotEmpty(t, findings)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
+	assert.Equal(t, rule.Metadata().DocURL, findings[0].DocURL)
+}
+
+func TestLogRetentionRule_MinRetentionParam(t *testing.T) {
+	resource := model.TerraformResource{
+		Type: "aws_cloudwatch_log_group",
+		Name: "test",
+		Attributes: map[string]interface{}{
+			"retention_in_days": float64(90),
+		},
+	}
+	rule := &LogRetentionRule{}
+	findings, err := engine.CheckResource(rule, resource, map[string]string{"min_retention_days": "365"})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "CloudWatch Log Group retention period is 90 days, should be at least 365", findings[0].Description)
+
+	findings, err = engine.CheckResource(rule, resource, map[string]string{"min_retention_days": "90"})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
 }
 
 // LogEncryptionRule Tests
@@ -1,12 +1,14 @@
 package cloudwatch
 
 import (
+	"fmt"
+
 	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
 func init() {
-	engine.Register(&LogRetentionRule{})
+	engine.RegisterResourceEval(&LogRetentionRule{})
 }
 
 type LogRetentionRule struct{}
@@ -20,26 +22,30 @@ func (r *LogRetentionRule) Metadata() model.RuleMetadata {
 		Pillar:        model.PillarCostOptimization,
 		ResourceTypes: []string{"aws_cloudwatch_log_group"},
 		DocURL:        "https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/Working-with-log-groups-and-streams.html#SettingLogRetention",
+		Parameters: []model.RuleParameter{{
+			Name:        "min_retention_days",
+			Type:        model.ParamInt,
+			Default:     "1",
+			Description: "Minimum retention_in_days a log group must set",
+		}},
 	}
 }
 
-func (r *LogRetentionRule) Evaluate(resource model.TerraformResource) []model.Finding {
-	var findings []model.Finding
+func (r *LogRetentionRule) Check(ctx *model.EvalContext) {
+	minDays := ctx.IntParam("min_retention_days")
 
-	retention, exists := resource.GetNumberAttr("retention_in_days")
+	retention, exists := ctx.Resource.GetNumberAttr("retention_in_days")
 	if !exists || retention <= 0 {
-		findings = append(findings, model.Finding{
-			RuleID:      "CW-001",
-			RuleName:    r.Metadata().Name,
-			Severity:    model.SeverityMedium,
-			Pillar:      model.PillarCostOptimization,
-			Resource:    resource.Address(),
-			File:        resource.File,
-			Line:        resource.Line,
-			Description: "CloudWatch Log Group does not have retention period configured",
-			Remediation: "Set retention_in_days to a positive value to control log retention and costs",
-		})
+		ctx.Report(ctx.Finding(ctx.Resource,
+			"CloudWatch Log Group does not have retention period configured",
+			"Set retention_in_days to a positive value to control log retention and costs",
+		))
+		return
+	}
+	if retention < float64(minDays) {
+		ctx.Report(ctx.Finding(ctx.Resource,
+			fmt.Sprintf("CloudWatch Log Group retention period is %.0f days, should be at least %d", retention, minDays),
+			fmt.Sprintf("Set retention_in_days to at least %d", minDays),
+		))
 	}
-
-	return findings
 }
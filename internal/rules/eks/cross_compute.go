@@ -24,30 +24,26 @@ func (r *CrossComputeRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossComputeRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect all cluster names referenced by node groups and Fargate profiles
+func (r *CrossComputeRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the clusters that node groups and Fargate profiles reference or name
 	clustersWithCompute := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_eks_node_group" || res.Type == "aws_eks_fargate_profile" {
-			clusterName, ok := res.GetStringAttr("cluster_name")
-			if ok && clusterName != "" {
-				clustersWithCompute[clusterName] = true
-			}
-			// Also index by resource name as fallback for reference-based values
-			clustersWithCompute[res.Name] = true
+	// A companion whose cluster_name is only known after apply, and does not
+	// reference a cluster in the plan, may belong to any cluster.
+	unresolved := false
+	for _, res := range resources.OfType("aws_eks_node_group", "aws_eks_fargate_profile") {
+		clusters := resources.Referenced(res, "cluster_name", "aws_eks_cluster")
+		if res.IsUnknown("cluster_name") && len(clusters) == 0 {
+			unresolved = true
+		}
+		for _, cluster := range clusters {
+			clustersWithCompute[cluster.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_eks_cluster" {
-			continue
-		}
-
-		clusterName, _ := res.GetStringAttr("name")
-
-		if !clustersWithCompute[clusterName] && !clustersWithCompute[res.Name] && !clustersWithCompute[res.Address()] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_eks_cluster") {
+		if !clustersWithCompute[res.Address()] {
+			finding := model.Finding{
 				RuleID:      "EKS-009",
 				RuleName:    "EKS Cluster Missing Compute (Node Group or Fargate Profile)",
 				Severity:    model.SeverityHigh,
@@ -57,7 +53,11 @@ func (r *CrossComputeRule) EvaluateAll(resources []model.TerraformResource) []mo
 				Line:        res.Line,
 				Description: "This EKS cluster has no aws_eks_node_group or aws_eks_fargate_profile in the plan. Without compute resources, workloads cannot be scheduled.",
 				Remediation: "Add an aws_eks_node_group or aws_eks_fargate_profile that references this cluster via cluster_name.",
-			})
+			}
+			if unresolved {
+				finding = finding.AsUndetermined("the cluster_name of an aws_eks_node_group or aws_eks_fargate_profile resource is only known after apply, so it may refer to this cluster.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
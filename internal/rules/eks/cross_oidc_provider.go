@@ -26,25 +26,26 @@ func (r *CrossOIDCProviderRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossOIDCProviderRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
+func (r *CrossOIDCProviderRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	providers := resources.OfType("aws_iam_openid_connect_provider")
+
 	// Collect OIDC provider URLs
 	oidcURLs := make([]string, 0)
-	for _, res := range resources {
-		if res.Type == "aws_iam_openid_connect_provider" {
-			url, ok := res.GetStringAttr("url")
-			if ok && url != "" {
-				oidcURLs = append(oidcURLs, url)
-			}
+	for _, res := range providers {
+		url, ok := res.GetStringAttr("url")
+		if ok && url != "" {
+			oidcURLs = append(oidcURLs, url)
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_eks_cluster" {
+	for _, res := range resources.OfType("aws_eks_cluster") {
+		// A provider built from the cluster's identity issuer belongs to it
+		if len(resources.Referrers(res, "aws_iam_openid_connect_provider", "url")) > 0 {
 			continue
 		}
 
-		if len(oidcURLs) == 0 {
+		if len(providers) == 0 {
 			findings = append(findings, model.Finding{
 				RuleID:      "EKS-008",
 				RuleName:    "EKS Cluster Missing OIDC Provider",
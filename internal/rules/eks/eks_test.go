@@ -377,7 +377,7 @@ func TestCrossOIDCProvider_NoProvider(t *testing.T) {
 	resources := []model.TerraformResource{
 		makeEKSRes("aws_eks_cluster", "main", map[string]interface{}{"name": "my-cluster"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "EKS-008", findings[0].RuleID)
 }
@@ -390,13 +390,13 @@ func TestCrossOIDCProvider_WithMatchingProvider(t *testing.T) {
 			"url": "https://oidc.eks.us-east-1.amazonaws.com/id/ABC123",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
 func TestCrossOIDCProvider_NoClusters(t *testing.T) {
 	r := &CrossOIDCProviderRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
 
@@ -408,7 +408,7 @@ func TestCrossOIDCProvider_ProviderNotMatchingCluster(t *testing.T) {
 			"url": "https://cognito-identity.amazonaws.com",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "EKS-008", findings[0].RuleID)
 }
@@ -420,7 +420,7 @@ func TestCrossCompute_NoNodeGroup(t *testing.T) {
 	resources := []model.TerraformResource{
 		makeEKSRes("aws_eks_cluster", "main", map[string]interface{}{"name": "my-cluster"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "EKS-009", findings[0].RuleID)
 }
@@ -433,7 +433,7 @@ func TestCrossCompute_WithNodeGroup(t *testing.T) {
 			"cluster_name": "my-cluster",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -445,10 +445,36 @@ func TestCrossCompute_WithFargateProfile(t *testing.T) {
 			"cluster_name": "my-cluster",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
+func TestCrossCompute_NodeGroupNameIsNotACluster(t *testing.T) {
+	r := &CrossComputeRule{}
+	// A node group named like a cluster resource belongs to the cluster it names
+	resources := []model.TerraformResource{
+		makeEKSRes("aws_eks_cluster", "main", map[string]interface{}{"name": "my-cluster"}),
+		makeEKSRes("aws_eks_node_group", "main", map[string]interface{}{"cluster_name": "other-cluster"}),
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	assert.Len(t, findings, 1)
+}
+
+func TestCrossCompute_ReferencedCluster(t *testing.T) {
+	r := &CrossComputeRule{}
+	nodes := makeEKSRes("aws_eks_node_group", "workers", map[string]interface{}{})
+	nodes.Unknown = map[string]bool{"cluster_name": true}
+	nodes.References = map[string][]string{"cluster_name": {"module.eks.aws_eks_cluster.this"}}
+	cluster := makeEKSRes("aws_eks_cluster", "this", map[string]interface{}{})
+	cluster.FullAddress = "module.eks.aws_eks_cluster.this"
+	other := makeEKSRes("aws_eks_cluster", "other", map[string]interface{}{})
+
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{cluster, other, nodes}))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "aws_eks_cluster.other", findings[0].Resource)
+	assert.False(t, findings[0].Undetermined)
+}
+
 func TestCrossCompute_MultipleClusters_OneUncovered(t *testing.T) {
 	r := &CrossComputeRule{}
 	resources := []model.TerraformResource{
@@ -456,7 +482,7 @@ func TestCrossCompute_MultipleClusters_OneUncovered(t *testing.T) {
 		makeEKSRes("aws_eks_cluster", "cluster2", map[string]interface{}{"name": "cluster-2"}),
 		makeEKSRes("aws_eks_node_group", "workers", map[string]interface{}{"cluster_name": "cluster-1"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "cluster2")
 }
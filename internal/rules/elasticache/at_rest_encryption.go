@@ -34,7 +34,7 @@ func (r *AtRestEncryptionRule) Evaluate(resource model.TerraformResource) []mode
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityHigh,
 			Pillar:      model.PillarSecurity,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "ElastiCache replication group does not have at-rest encryption enabled",
@@ -29,7 +29,7 @@ func (r *AutoMinorVersionRule) Evaluate(resource model.TerraformResource) []mode
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityLow,
 			Pillar:      model.PillarOperationalExcellence,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "ElastiCache replication group does not have auto minor version upgrade enabled",
@@ -33,7 +33,7 @@ func (r *AutomaticFailoverRule) Evaluate(resource model.TerraformResource) []mod
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityHigh,
 			Pillar:      model.PillarReliability,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "ElastiCache replication group does not have automatic failover enabled",
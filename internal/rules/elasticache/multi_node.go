@@ -33,7 +33,7 @@ func (r *MultiNodeRule) Evaluate(resource model.TerraformResource) []model.Findi
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityMedium,
 			Pillar:      model.PillarReliability,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "ElastiCache replication group does not have multiple cache clusters configured",
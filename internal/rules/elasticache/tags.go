@@ -31,7 +31,7 @@ func (r *TagsRule) Evaluate(resource model.TerraformResource) []model.Finding {
 		RuleName:    r.Metadata().Name,
 		Severity:    model.SeverityLow,
 		Pillar:      model.PillarCostOptimization,
-		Resource:    resource.FullAddress,
+		Resource:    resource.Address(),
 		File:        resource.File,
 		Line:        resource.Line,
 		Description: "ElastiCache replication group does not have tags configured",
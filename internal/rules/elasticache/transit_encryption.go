@@ -33,7 +33,7 @@ func (r *TransitEncryptionRule) Evaluate(resource model.TerraformResource) []mod
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityHigh,
 			Pillar:      model.PillarSecurity,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "ElastiCache replication group does not have transit encryption enabled",
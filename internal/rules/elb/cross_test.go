@@ -26,7 +26,7 @@ func TestCrossWAF_ALBNoWAF(t *testing.T) {
 			"load_balancer_type": "application",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "ELB-007", findings[0].RuleID)
 }
@@ -42,7 +42,7 @@ func TestCrossWAF_ALBWithWAF(t *testing.T) {
 			"resource_arn": "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/abc123",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -53,7 +53,7 @@ func TestCrossWAF_NLBSkipped(t *testing.T) {
 			"load_balancer_type": "network",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -62,7 +62,7 @@ func TestCrossWAF_DefaultTypeIsApplication(t *testing.T) {
 	resources := []model.TerraformResource{
 		newRes("aws_lb", "web", map[string]interface{}{}), // default type is "application"
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 }
 
@@ -71,6 +71,6 @@ func TestCrossWAF_ALBResource(t *testing.T) {
 	resources := []model.TerraformResource{
 		newRes("aws_alb", "legacy", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 }
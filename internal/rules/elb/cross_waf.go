@@ -23,32 +23,27 @@ func (r *CrossWAFRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossWAFRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect resource ARNs that have WAF associations
+func (r *CrossWAFRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the load balancers that WAF associations reference or name by ARN
 	wafProtected := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_wafv2_web_acl_association" {
-			arn, ok := res.GetStringAttr("resource_arn")
-			if ok {
-				wafProtected[arn] = true
-			}
+	for _, res := range resources.OfType("aws_wafv2_web_acl_association") {
+		for _, lb := range resources.Referenced(res, "resource_arn", "aws_lb") {
+			wafProtected[lb.Address()] = true
+		}
+		for _, lb := range resources.Referenced(res, "resource_arn", "aws_alb") {
+			wafProtected[lb.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_lb" && res.Type != "aws_alb" {
-			continue
-		}
-
+	for _, res := range resources.OfType("aws_lb", "aws_alb") {
 		// Only check application load balancers
 		lbType, _ := res.GetStringAttr("load_balancer_type")
 		if lbType != "" && lbType != "application" {
 			continue
 		}
 
-		arn, _ := res.GetStringAttr("arn")
-		if !wafProtected[arn] && !wafProtected[res.Address()] {
+		if !wafProtected[res.Address()] {
 			findings = append(findings, model.Finding{
 				RuleID:      "ELB-007",
 				RuleName:    "ALB Missing WAF Association",
@@ -32,29 +32,26 @@ func (r *CrossAdminAttachmentRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossAdminAttachmentRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
+func (r *CrossAdminAttachmentRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
 	var findings []model.Finding
 
-	for _, res := range resources {
-		switch res.Type {
-		case "aws_iam_role_policy_attachment", "aws_iam_user_policy_attachment", "aws_iam_group_policy_attachment":
-			arn, ok := res.GetStringAttr("policy_arn")
-			if !ok {
-				continue
-			}
-			if isAdminPolicy(arn) {
-				findings = append(findings, model.Finding{
-					RuleID:      "IAM-013",
-					RuleName:    "AdministratorAccess Policy Attached",
-					Severity:    model.SeverityCritical,
-					Pillar:      model.PillarSecurity,
-					Resource:    res.Address(),
-					File:        res.File,
-					Line:        res.Line,
-					Description: "The AdministratorAccess managed policy is attached. This grants unrestricted access to all AWS services and resources.",
-					Remediation: "Replace AdministratorAccess with a least-privilege policy that grants only the permissions needed.",
-				})
-			}
+	for _, res := range resources.OfType("aws_iam_role_policy_attachment", "aws_iam_user_policy_attachment", "aws_iam_group_policy_attachment") {
+		arn, ok := res.GetStringAttr("policy_arn")
+		if !ok {
+			continue
+		}
+		if isAdminPolicy(arn) {
+			findings = append(findings, model.Finding{
+				RuleID:      "IAM-013",
+				RuleName:    "AdministratorAccess Policy Attached",
+				Severity:    model.SeverityCritical,
+				Pillar:      model.PillarSecurity,
+				Resource:    res.Address(),
+				File:        res.File,
+				Line:        res.Line,
+				Description: "The AdministratorAccess managed policy is attached. This grants unrestricted access to all AWS services and resources.",
+				Remediation: "Replace AdministratorAccess with a least-privilege policy that grants only the permissions needed.",
+			})
 		}
 	}
 
@@ -25,72 +25,61 @@ func (r *CrossInlineWildcardRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossInlineWildcardRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Index inline policies by role name
-	policyByRole := make(map[string][]model.TerraformResource)
-	for _, res := range resources {
-		if res.Type == "aws_iam_role_policy" {
-			role, ok := res.GetStringAttr("role")
-			if ok {
-				policyByRole[role] = append(policyByRole[role], res)
-			}
-		}
-	}
-
+func (r *CrossInlineWildcardRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_iam_role" {
+	for _, pol := range resources.OfType("aws_iam_role_policy") {
+		// Only check inline policies on roles in the plan, referenced or named
+		roles := resources.Referenced(pol, "role", "aws_iam_role")
+		if len(roles) == 0 {
 			continue
 		}
+		roleName, ok := roles[0].GetStringAttr("name")
+		if !ok {
+			roleName = roles[0].Address()
+		}
 
-		// Check inline policies associated with this role
-		roleName, _ := res.GetStringAttr("name")
-		policies := policyByRole[roleName]
+		policyStr, ok := pol.GetStringAttr("policy")
+		if !ok || policyStr == "" {
+			continue
+		}
 
-		for _, pol := range policies {
-			policyStr, ok := pol.GetStringAttr("policy")
-			if !ok || policyStr == "" {
-				continue
-			}
+		doc, err := ParsePolicyJSON(policyStr)
+		if err != nil || doc == nil {
+			continue
+		}
 
-			doc, err := ParsePolicyJSON(policyStr)
-			if err != nil || doc == nil {
+		for _, stmt := range doc.Statement {
+			if stmt.Effect != "Allow" {
 				continue
 			}
 
-			for _, stmt := range doc.Statement {
-				if stmt.Effect != "Allow" {
-					continue
-				}
+			actions := ActionsFromStatement(stmt)
+			resources := ResourcesFromStatement(stmt)
 
-				actions := ActionsFromStatement(stmt)
-				resources := ResourcesFromStatement(stmt)
+			hasWildcardAction := ContainsWildcard(actions)
+			hasWildcardResource := ContainsWildcard(resources)
 
-				hasWildcardAction := ContainsWildcard(actions)
-				hasWildcardResource := ContainsWildcard(resources)
-
-				// Check for service-level wildcards (e.g., "s3:*", "iam:*")
-				hasServiceWildcard := false
-				for _, a := range actions {
-					if strings.HasSuffix(a, ":*") {
-						hasServiceWildcard = true
-						break
-					}
+			// Check for service-level wildcards (e.g., "s3:*", "iam:*")
+			hasServiceWildcard := false
+			for _, a := range actions {
+				if strings.HasSuffix(a, ":*") {
+					hasServiceWildcard = true
+					break
 				}
+			}
 
-				if (hasWildcardAction || hasServiceWildcard) && hasWildcardResource {
-					findings = append(findings, model.Finding{
-						RuleID:      "IAM-014",
-						RuleName:    "Inline Policy With Wildcard Actions",
-						Severity:    model.SeverityHigh,
-						Pillar:      model.PillarSecurity,
-						Resource:    pol.Address(),
-						File:        pol.File,
-						Line:        pol.Line,
-						Description: "An inline policy on role " + roleName + " grants wildcard actions on wildcard resources. Use managed policies with least-privilege permissions.",
-						Remediation: "Replace the inline policy with a managed policy. Scope down Action and Resource to only what is needed.",
-					})
-				}
+			if (hasWildcardAction || hasServiceWildcard) && hasWildcardResource {
+				findings = append(findings, model.Finding{
+					RuleID:      "IAM-014",
+					RuleName:    "Inline Policy With Wildcard Actions",
+					Severity:    model.SeverityHigh,
+					Pillar:      model.PillarSecurity,
+					Resource:    pol.Address(),
+					File:        pol.File,
+					Line:        pol.Line,
+					Description: "An inline policy on role " + roleName + " grants wildcard actions on wildcard resources. Use managed policies with least-privilege permissions.",
+					Remediation: "Replace the inline policy with a managed policy. Scope down Action and Resource to only what is needed.",
+				})
 			}
 		}
 	}
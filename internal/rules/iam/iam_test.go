@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/ilijad1/well-architected-terraform/internal/parser"
 )
@@ -64,7 +65,8 @@ func TestPasswordLength_Short(t *testing.T) {
 	res := findResource(t, resources, "aws_iam_account_password_policy", "weak")
 
 	rule := &PasswordLength{}
-	findings := rule.Evaluate(res)
+	findings, err := engine.CheckResource(rule, res, nil)
+	require.NoError(t, err)
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "IAM-002", findings[0].RuleID)
 }
@@ -74,7 +76,8 @@ func TestPasswordLength_Long(t *testing.T) {
 	res := findResource(t, resources, "aws_iam_account_password_policy", "strict")
 
 	rule := &PasswordLength{}
-	findings := rule.Evaluate(res)
+	findings, err := engine.CheckResource(rule, res, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
 }
 
@@ -227,7 +227,7 @@ func TestCrossAdminAttachment_AdminPolicy(t *testing.T) {
 			"policy_arn": "arn:aws:iam::aws:policy/AdministratorAccess",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "IAM-013", findings[0].RuleID)
 	assert.Equal(t, model.SeverityCritical, findings[0].Severity)
@@ -240,7 +240,7 @@ func TestCrossAdminAttachment_NonAdminPolicy(t *testing.T) {
 			"policy_arn": "arn:aws:iam::aws:policy/ReadOnlyAccess",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -251,7 +251,7 @@ func TestCrossAdminAttachment_UserAttachment(t *testing.T) {
 			"policy_arn": "arn:aws:iam::aws:policy/AdministratorAccess",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 }
 
@@ -268,7 +268,7 @@ func TestCrossInlineWildcard_WildcardPolicy(t *testing.T) {
 			"policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "IAM-014", findings[0].RuleID)
 }
@@ -284,7 +284,7 @@ func TestCrossInlineWildcard_ScopedPolicy(t *testing.T) {
 			"policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -299,6 +299,6 @@ func TestCrossInlineWildcard_ServiceWildcard(t *testing.T) {
 			"policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 }
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	engine.Register(&PasswordLength{})
+	engine.RegisterResourceEval(&PasswordLength{})
 }
 
 type PasswordLength struct{}
@@ -17,28 +17,28 @@ func (r *PasswordLength) Metadata() model.RuleMetadata {
 	return model.RuleMetadata{
 		ID:            "IAM-002",
 		Name:          "IAM Password Policy Minimum Length",
-		Description:   "IAM account password policy should require minimum password length of 14 or more.",
+		Description:   "IAM account password policy should require an adequate minimum password length.",
 		Severity:      model.SeverityHigh,
 		Pillar:        model.PillarSecurity,
 		ResourceTypes: []string{"aws_iam_account_password_policy"},
+		Parameters: []model.RuleParameter{{
+			Name:        "min_length",
+			Type:        model.ParamInt,
+			Default:     "14",
+			Description: "Lowest minimum_password_length the policy may set",
+		}},
 	}
 }
 
-func (r *PasswordLength) Evaluate(resource model.TerraformResource) []model.Finding {
-	minLen, ok := resource.GetNumberAttr("minimum_password_length")
-	if ok && minLen >= 14 {
-		return nil
+func (r *PasswordLength) Check(ctx *model.EvalContext) {
+	required := ctx.IntParam("min_length")
+	minLen, ok := ctx.Resource.GetNumberAttr("minimum_password_length")
+	if ok && minLen >= float64(required) {
+		return
 	}
 
-	return []model.Finding{{
-		RuleID:      "IAM-002",
-		RuleName:    r.Metadata().Name,
-		Severity:    model.SeverityHigh,
-		Pillar:      model.PillarSecurity,
-		Resource:    resource.Address(),
-		File:        resource.File,
-		Line:        resource.Line,
-		Description: fmt.Sprintf("IAM password policy minimum length is %.0f, should be at least 14.", minLen),
-		Remediation: "Set minimum_password_length to at least 14 in the password policy.",
-	}}
+	ctx.Report(ctx.Finding(ctx.Resource,
+		fmt.Sprintf("IAM password policy minimum length is %.0f, should be at least %d.", minLen, required),
+		fmt.Sprintf("Set minimum_password_length to at least %d in the password policy.", required),
+	))
 }
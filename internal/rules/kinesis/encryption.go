@@ -34,7 +34,7 @@ func (r *EncryptionRule) Evaluate(resource model.TerraformResource) []model.Find
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityHigh,
 			Pillar:      model.PillarSecurity,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "Kinesis stream does not use KMS encryption",
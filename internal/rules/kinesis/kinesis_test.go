@@ -3,8 +3,10 @@ package kinesis
 import (
 	"testing"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // EncryptionRule Tests
@@ -61,7 +63,8 @@ func TestRetentionRule_Pass(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &RetentionRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
 }
 
@@ -73,7 +76,8 @@ func TestRetentionRule_Fail_NotSet(t *testing.T) {
 		Blocks:     map[string][]model.Block{},
 	}
 	rule := &RetentionRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.NotEmpty(t, findings)
 	assert.Equal(t, "KIN-002", findings[0].RuleID)
 }
@@ -88,7 +92,8 @@ func TestRetentionRule_Fail_TooLow(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &RetentionRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.NotEmpty(t, findings)
 	assert.Equal(t, "KIN-002", findings[0].RuleID)
 }
@@ -103,9 +108,33 @@ func TestRetentionRule_Fail_Exact24(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &RetentionRule{}
-	findings := rule.Evaluate(resource)
-	assert.NotEmpty(t, findings)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
 	assert.Equal(t, "KIN-002", findings[0].RuleID)
+	assert.Equal(t, "Kinesis stream retention period is not greater than 24 hours", findings[0].Description)
+	assert.Equal(t, "Set retention_period to a value greater than 24", findings[0].Remediation)
+}
+
+func TestRetentionRule_ThresholdParam(t *testing.T) {
+	rule := &RetentionRule{}
+	params := map[string]string{"retention_threshold_hours": "48"}
+	stream := func(hours int) model.TerraformResource {
+		return model.TerraformResource{
+			Type:       "aws_kinesis_stream",
+			Name:       "test",
+			Attributes: map[string]interface{}{"retention_period": hours},
+		}
+	}
+
+	findings, err := engine.CheckResource(rule, stream(48), params)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Kinesis stream retention period is not greater than 48 hours", findings[0].Description)
+
+	findings, err = engine.CheckResource(rule, stream(49), params)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
 }
 
 // TagsRule Tests
@@ -1,12 +1,14 @@
 package kinesis
 
 import (
+	"fmt"
+
 	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
 func init() {
-	engine.Register(&RetentionRule{})
+	engine.RegisterResourceEval(&RetentionRule{})
 }
 
 type RetentionRule struct{}
@@ -14,33 +16,28 @@ type RetentionRule struct{}
 func (r *RetentionRule) Metadata() model.RuleMetadata {
 	return model.RuleMetadata{
 		ID:            "KIN-002",
-		Name:          "Kinesis stream should have retention period greater than 24 hours",
+		Name:          "Kinesis stream should have adequate retention period",
 		Description:   "Ensures Kinesis streams have adequate data retention for reliability and recovery.",
 		Severity:      model.SeverityLow,
 		Pillar:        model.PillarReliability,
 		ResourceTypes: []string{"aws_kinesis_stream"},
 		DocURL:        "https://docs.aws.amazon.com/streams/latest/dev/kinesis-extended-retention.html",
+		Parameters: []model.RuleParameter{{
+			Name:        "retention_threshold_hours",
+			Type:        model.ParamInt,
+			Default:     "24",
+			Description: "Hours retention_period must be greater than",
+		}},
 	}
 }
 
-func (r *RetentionRule) Evaluate(resource model.TerraformResource) []model.Finding {
-	var findings []model.Finding
-
-	retentionPeriod, exists := resource.GetNumberAttr("retention_period")
-	if !exists || retentionPeriod <= 24 {
-		findings = append(findings, model.Finding{
-			RuleID:      "KIN-002",
-			RuleName:    r.Metadata().Name,
-			Severity:    model.SeverityLow,
-			Pillar:      model.PillarReliability,
-			Resource:    resource.FullAddress,
-			File:        resource.File,
-			Line:        resource.Line,
-			Description: "Kinesis stream retention period is not greater than 24 hours",
-			Remediation: "Set retention_period to a value greater than 24",
-			DocURL:      r.Metadata().DocURL,
-		})
+func (r *RetentionRule) Check(ctx *model.EvalContext) {
+	threshold := ctx.IntParam("retention_threshold_hours")
+	retentionPeriod, exists := ctx.Resource.GetNumberAttr("retention_period")
+	if !exists || retentionPeriod <= float64(threshold) {
+		ctx.Report(ctx.Finding(ctx.Resource,
+			fmt.Sprintf("Kinesis stream retention period is not greater than %d hours", threshold),
+			fmt.Sprintf("Set retention_period to a value greater than %d", threshold),
+		))
 	}
-
-	return findings
 }
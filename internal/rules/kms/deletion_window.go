@@ -2,12 +2,14 @@
 package kms
 
 import (
+	"fmt"
+
 	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
 
 func init() {
-	engine.Register(&DeletionWindowRule{})
+	engine.RegisterResourceEval(&DeletionWindowRule{})
 }
 
 type DeletionWindowRule struct{}
@@ -15,34 +17,32 @@ type DeletionWindowRule struct{}
 func (r *DeletionWindowRule) Metadata() model.RuleMetadata {
 	return model.RuleMetadata{
 		ID:            "KMS-002",
-		Name:          "KMS Key Deletion Window Should Be At Least 14 Days",
-		Description:   "KMS key deletion window should be at least 14 days to allow sufficient time for recovery from accidental deletion.",
+		Name:          "KMS Key Deletion Window Should Allow Recovery",
+		Description:   "KMS key deletion window should be long enough to allow sufficient time for recovery from accidental deletion.",
 		Severity:      model.SeverityMedium,
 		Pillar:        model.PillarReliability,
 		ResourceTypes: []string{"aws_kms_key"},
 		DocURL:        "https://docs.aws.amazon.com/kms/latest/developerguide/deleting-keys.html",
+		Parameters: []model.RuleParameter{{
+			Name:        "min_deletion_window_days",
+			Type:        model.ParamInt,
+			Default:     "14",
+			Description: "Minimum deletion_window_in_days",
+		}},
 	}
 }
 
-func (r *DeletionWindowRule) Evaluate(resource model.TerraformResource) []model.Finding {
-	var findings []model.Finding
-
-	// Default is 30 days if not set, so only flag if explicitly set and < 14
-	deletionWindow, exists := resource.GetNumberAttr("deletion_window_in_days")
-	if exists && deletionWindow < 14 {
-		findings = append(findings, model.Finding{
-			RuleID:      r.Metadata().ID,
-			RuleName:    r.Metadata().Name,
-			Severity:    model.SeverityMedium,
-			Pillar:      model.PillarReliability,
-			Resource:    resource.Address(),
-			File:        resource.File,
-			Line:        resource.Line,
-			Description: "KMS key has a deletion window of less than 14 days, which may not provide sufficient time to recover from accidental deletion",
-			Remediation: "Set deletion_window_in_days to at least 14 (recommended 30 days)",
-			DocURL:      "https://docs.aws.amazon.com/kms/latest/developerguide/deleting-keys.html",
-		})
+func (r *DeletionWindowRule) Check(ctx *model.EvalContext) {
+	// Default is 30 days if not set, so only flag if explicitly set and below the minimum
+	deletionWindow, exists := ctx.Resource.GetNumberAttr("deletion_window_in_days")
+	if !exists {
+		return
+	}
+	minDays := ctx.IntParam("min_deletion_window_days")
+	if deletionWindow < float64(minDays) {
+		ctx.Report(ctx.Finding(ctx.Resource,
+			fmt.Sprintf("KMS key has a deletion window of less than %d days, which may not provide sufficient time to recover from accidental deletion", minDays),
+			fmt.Sprintf("Set deletion_window_in_days to at least %d (recommended 30 days)", minDays),
+		))
 	}
-
-	return findings
 }
@@ -3,8 +3,10 @@ package kms
 import (
 	"testing"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // KeyRotationRule Tests
@@ -48,7 +50,8 @@ func TestDeletionWindowRule_Pass(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &DeletionWindowRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
 }
 
@@ -62,7 +65,8 @@ func TestDeletionWindowRule_Fail(t *testing.T) {
 		Blocks: map[string][]model.Block{},
 	}
 	rule := &DeletionWindowRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.NotEmpty(t, findings)
 	assert.Equal(t, "KMS-002", findings[0].RuleID)
 }
@@ -75,8 +79,27 @@ func TestDeletionWindowRule_Pass_NotSet(t *testing.T) {
 		Blocks:     map[string][]model.Block{},
 	}
 	rule := &DeletionWindowRule{}
-	findings := rule.Evaluate(resource)
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestDeletionWindowRule_MinWindowParam(t *testing.T) {
+	resource := model.TerraformResource{
+		Type: "aws_kms_key",
+		Name: "test",
+		Attributes: map[string]interface{}{
+			"deletion_window_in_days": float64(20),
+		},
+	}
+	rule := &DeletionWindowRule{}
+	findings, err := engine.CheckResource(rule, resource, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
+
+	findings, err = engine.CheckResource(rule, resource, map[string]string{"min_deletion_window_days": "30"})
+	require.NoError(t, err)
+	assert.Len(t, findings, 1)
 }
 
 // TagsRule Tests
@@ -24,21 +24,21 @@ func (r *CrossLogGroupRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Index CloudWatch log group names
-	logGroupNames := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_cloudwatch_log_group" {
-			name, ok := res.GetStringAttr("name")
-			if ok && name != "" {
-				logGroupNames[name] = true
-			}
+func (r *CrossLogGroupRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// A companion whose name is only known after apply, and does not reference
+	// a function in the plan, may belong to any function.
+	unresolved := false
+	for _, res := range resources.OfType("aws_cloudwatch_log_group") {
+		if res.IsUnknown("name") && len(resources.Targets(res, "name")) == 0 {
+			unresolved = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_lambda_function" {
+	for _, res := range resources.OfType("aws_lambda_function") {
+		// A log group named from the function's attributes, e.g.
+		// "/aws/lambda/${aws_lambda_function.app.function_name}", belongs to it
+		if len(resources.Referrers(res, "aws_cloudwatch_log_group", "name")) > 0 {
 			continue
 		}
 
@@ -49,8 +49,8 @@ func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []m
 
 		expectedLogGroup := "/aws/lambda/" + fnName
 
-		if !logGroupNames[expectedLogGroup] {
-			findings = append(findings, model.Finding{
+		if len(resources.Lookup("aws_cloudwatch_log_group", expectedLogGroup)) == 0 {
+			finding := model.Finding{
 				RuleID:      "LAM-008",
 				RuleName:    "Lambda Function Missing Explicit Log Group",
 				Severity:    model.SeverityMedium,
@@ -60,7 +60,11 @@ func (r *CrossLogGroupRule) EvaluateAll(resources []model.TerraformResource) []m
 				Line:        res.Line,
 				Description: "No aws_cloudwatch_log_group with name \"" + expectedLogGroup + "\" found in the plan. Without an explicit log group, Lambda creates one automatically with no retention policy.",
 				Remediation: "Add an aws_cloudwatch_log_group resource with name = \"/aws/lambda/" + fnName + "\" and set a retention_in_days value.",
-			})
+			}
+			if unresolved {
+				finding = finding.AsUndetermined("the name of an aws_cloudwatch_log_group resource is only known after apply, so it may be this function's log group.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -241,7 +241,7 @@ func TestCrossLogGroup_NoLogGroup(t *testing.T) {
 			"function_name": "my-function",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "LAM-008", findings[0].RuleID)
 }
@@ -256,7 +256,7 @@ func TestCrossLogGroup_WithMatchingLogGroup(t *testing.T) {
 			"name": "/aws/lambda/my-function",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -267,13 +267,13 @@ func TestCrossLogGroup_MultipleFunctions_OneUncovered(t *testing.T) {
 		makeLamRes("aws_lambda_function", "fn2", map[string]interface{}{"function_name": "function-2"}),
 		makeLamRes("aws_cloudwatch_log_group", "fn1_logs", map[string]interface{}{"name": "/aws/lambda/function-1"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "fn2")
 }
 
 func TestCrossLogGroup_NoFunctions(t *testing.T) {
 	r := &CrossLogGroupRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
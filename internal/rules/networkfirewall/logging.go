@@ -23,28 +23,18 @@ func (r *LoggingConfigurationRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *LoggingConfigurationRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect firewall ARNs/addresses that have logging configured
+func (r *LoggingConfigurationRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the firewalls that logging configurations reference or name by ARN
 	firewallsWithLogging := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_networkfirewall_logging_configuration" {
-			fwARN, ok := res.GetStringAttr("firewall_arn")
-			if ok {
-				firewallsWithLogging[fwARN] = true
-			}
+	for _, res := range resources.OfType("aws_networkfirewall_logging_configuration") {
+		for _, fw := range resources.Referenced(res, "firewall_arn", "aws_networkfirewall_firewall") {
+			firewallsWithLogging[fw.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_networkfirewall_firewall" {
-			continue
-		}
-
-		fwARN, _ := res.GetStringAttr("arn")
-		fwID, _ := res.GetStringAttr("id")
-
-		if !firewallsWithLogging[fwARN] && !firewallsWithLogging[fwID] && !firewallsWithLogging[res.Address()] {
+	for _, res := range resources.OfType("aws_networkfirewall_firewall") {
+		if !firewallsWithLogging[res.Address()] {
 			findings = append(findings, model.Finding{
 				RuleID:      "NFW-003",
 				RuleName:    "Network Firewall Missing Logging Configuration",
@@ -77,7 +77,7 @@ func TestLoggingConfiguration_Missing(t *testing.T) {
 	resources := []model.TerraformResource{
 		res("aws_networkfirewall_firewall", "fw", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "NFW-003", findings[0].RuleID)
 }
@@ -90,7 +90,7 @@ func TestLoggingConfiguration_Present(t *testing.T) {
 	logging := res("aws_networkfirewall_logging_configuration", "fw_logging", map[string]interface{}{
 		"firewall_arn": "arn:aws:network-firewall:us-east-1:123456789012:firewall/fw",
 	})
-	findings := r.EvaluateAll([]model.TerraformResource{fw, logging})
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{fw, logging}))
 	assert.Empty(t, findings)
 }
 
@@ -102,7 +102,7 @@ func TestLoggingConfiguration_WrongFirewall(t *testing.T) {
 	logging := res("aws_networkfirewall_logging_configuration", "other_logging", map[string]interface{}{
 		"firewall_arn": "arn:aws:network-firewall:us-east-1:123456789012:firewall/fw-b",
 	})
-	findings := r.EvaluateAll([]model.TerraformResource{fw, logging})
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{fw, logging}))
 	assert.Len(t, findings, 1)
 }
 
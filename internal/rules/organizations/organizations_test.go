@@ -65,7 +65,7 @@ func TestSCPUnattached_NoAttachment(t *testing.T) {
 			"type": "SERVICE_CONTROL_POLICY",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "ORG-002", findings[0].RuleID)
 }
@@ -80,7 +80,7 @@ func TestSCPUnattached_WithAttachment(t *testing.T) {
 		"policy_id": "p-abc123",
 		"target_id": "ou-root-123",
 	})
-	findings := r.EvaluateAll([]model.TerraformResource{policy, attachment})
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{policy, attachment}))
 	assert.Empty(t, findings)
 }
 
@@ -91,7 +91,7 @@ func TestSCPUnattached_NonSCPIgnored(t *testing.T) {
 			"type": "TAG_POLICY",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -102,7 +102,7 @@ func TestOUNoSCP_NoAttachment(t *testing.T) {
 	resources := []model.TerraformResource{
 		res("aws_organizations_organizational_unit", "dev", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "ORG-003", findings[0].RuleID)
 }
@@ -116,6 +116,6 @@ func TestOUNoSCP_WithAttachment(t *testing.T) {
 		"policy_id": "p-abc",
 		"target_id": "ou-dev-123",
 	})
-	findings := r.EvaluateAll([]model.TerraformResource{ou, attachment})
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{ou, attachment}))
 	assert.Empty(t, findings)
 }
@@ -24,26 +24,18 @@ func (r *OUNoSCPRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *OUNoSCPRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect target IDs that have policy attachments
+func (r *OUNoSCPRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the OUs that policy attachments reference or name by ID
 	targetsWithSCP := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_organizations_policy_attachment" {
-			targetID, ok := res.GetStringAttr("target_id")
-			if ok {
-				targetsWithSCP[targetID] = true
-			}
+	for _, res := range resources.OfType("aws_organizations_policy_attachment") {
+		for _, ou := range resources.Referenced(res, "target_id", "aws_organizations_organizational_unit") {
+			targetsWithSCP[ou.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_organizations_organizational_unit" {
-			continue
-		}
-
-		ouID, _ := res.GetStringAttr("id")
-		if !targetsWithSCP[ouID] && !targetsWithSCP[res.Address()] {
+	for _, res := range resources.OfType("aws_organizations_organizational_unit") {
+		if !targetsWithSCP[res.Address()] {
 			findings = append(findings, model.Finding{
 				RuleID:      "ORG-003",
 				RuleName:    "Organizational Unit Without SCP",
@@ -23,32 +23,24 @@ func (r *SCPUnattachedRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *SCPUnattachedRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect policy IDs/addresses that have attachments
+func (r *SCPUnattachedRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the policies that attachments reference or name by ID
 	attachedPolicies := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_organizations_policy_attachment" {
-			policyID, ok := res.GetStringAttr("policy_id")
-			if ok {
-				attachedPolicies[policyID] = true
-			}
+	for _, res := range resources.OfType("aws_organizations_policy_attachment") {
+		for _, policy := range resources.Referenced(res, "policy_id", "aws_organizations_policy") {
+			attachedPolicies[policy.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_organizations_policy" {
-			continue
-		}
-
+	for _, res := range resources.OfType("aws_organizations_policy") {
 		// Only check SCPs
 		policyType, _ := res.GetStringAttr("type")
 		if policyType != "" && policyType != "SERVICE_CONTROL_POLICY" {
 			continue
 		}
 
-		policyID, _ := res.GetStringAttr("id")
-		if !attachedPolicies[policyID] && !attachedPolicies[res.Address()] {
+		if !attachedPolicies[res.Address()] {
 			findings = append(findings, model.Finding{
 				RuleID:      "ORG-002",
 				RuleName:    "SCP Not Attached to Any Target",
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	engine.Register(&BackupRetentionMin{})
+	engine.RegisterResourceEval(&BackupRetentionMin{})
 }
 
 type BackupRetentionMin struct{}
@@ -16,32 +16,32 @@ type BackupRetentionMin struct{}
 func (r *BackupRetentionMin) Metadata() model.RuleMetadata {
 	return model.RuleMetadata{
 		ID:            "RDS-009",
-		Name:          "RDS Backup Retention Minimum 7 Days",
-		Description:   "RDS instances should have backup retention period of at least 7 days.",
+		Name:          "RDS Backup Retention Minimum",
+		Description:   "RDS instances should have an adequate backup retention period.",
 		Severity:      model.SeverityMedium,
 		Pillar:        model.PillarReliability,
 		ResourceTypes: []string{"aws_db_instance"},
+		Parameters: []model.RuleParameter{{
+			Name:        "min_retention_days",
+			Type:        model.ParamInt,
+			Default:     "7",
+			Description: "Minimum backup_retention_period in days",
+		}},
 	}
 }
 
-func (r *BackupRetentionMin) Evaluate(resource model.TerraformResource) []model.Finding {
-	retention, ok := resource.GetNumberAttr("backup_retention_period")
+func (r *BackupRetentionMin) Check(ctx *model.EvalContext) {
+	retention, ok := ctx.Resource.GetNumberAttr("backup_retention_period")
 	if !ok {
-		return nil
+		return
 	}
-	if retention >= 7 {
-		return nil
+	minDays := ctx.IntParam("min_retention_days")
+	if retention >= float64(minDays) {
+		return
 	}
 
-	return []model.Finding{{
-		RuleID:      "RDS-009",
-		RuleName:    r.Metadata().Name,
-		Severity:    model.SeverityMedium,
-		Pillar:      model.PillarReliability,
-		Resource:    resource.Address(),
-		File:        resource.File,
-		Line:        resource.Line,
-		Description: fmt.Sprintf("RDS instance backup retention period is %.0f days, should be at least 7.", retention),
-		Remediation: "Set backup_retention_period to at least 7 days.",
-	}}
+	ctx.Report(ctx.Finding(ctx.Resource,
+		fmt.Sprintf("RDS instance backup retention period is %.0f days, should be at least %d.", retention, minDays),
+		fmt.Sprintf("Set backup_retention_period to at least %d days.", minDays),
+	))
 }
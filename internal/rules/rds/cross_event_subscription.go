@@ -24,25 +24,15 @@ func (r *CrossEventSubscriptionRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossEventSubscriptionRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	hasFailureSubscription := false
-	for _, res := range resources {
-		if res.Type == "aws_db_event_subscription" && subscriptionCoversFailures(res) {
-			hasFailureSubscription = true
-			break
+func (r *CrossEventSubscriptionRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	for _, res := range resources.OfType("aws_db_event_subscription") {
+		if subscriptionCoversFailures(res) {
+			return nil
 		}
 	}
 
-	if hasFailureSubscription {
-		return nil
-	}
-
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_db_instance" && res.Type != "aws_rds_cluster" {
-			continue
-		}
-
+	for _, res := range resources.OfType("aws_db_instance", "aws_rds_cluster") {
 		findings = append(findings, model.Finding{
 			RuleID:      "RDS-016",
 			RuleName:    "RDS Missing Failure Event Subscription",
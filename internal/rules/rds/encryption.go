@@ -30,7 +30,7 @@ func (r *StorageEncryption) Evaluate(resource model.TerraformResource) []model.F
 		return nil
 	}
 
-	return []model.Finding{{
+	finding := model.Finding{
 		RuleID:      "RDS-001",
 		RuleName:    r.Metadata().Name,
 		Severity:    model.SeverityHigh,
@@ -41,5 +41,9 @@ func (r *StorageEncryption) Evaluate(resource model.TerraformResource) []model.F
 		Description: "RDS instance does not have storage encryption enabled.",
 		Remediation: "Set storage_encrypted = true. Note: encryption can only be enabled at creation time.",
 		DocURL:      r.Metadata().DocURL,
-	}}
+	}
+	if resource.IsUnknown("storage_encrypted") {
+		finding = finding.AsUndetermined("storage_encrypted is only known after apply.")
+	}
+	return []model.Finding{finding}
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/ilijad1/well-architected-terraform/internal/parser"
 )
@@ -48,6 +49,21 @@ func TestStorageEncryption_Enabled(t *testing.T) {
 	assert.Empty(t, findings)
 }
 
+func TestStorageEncryption_KnownAfterApply(t *testing.T) {
+	db := model.TerraformResource{
+		Type:       "aws_db_instance",
+		Name:       "main",
+		Attributes: map[string]interface{}{"engine": "postgres"},
+		Unknown:    map[string]bool{"storage_encrypted": true},
+	}
+
+	rule := &StorageEncryption{}
+	findings := rule.Evaluate(db)
+	require.Len(t, findings, 1)
+	assert.True(t, findings[0].Undetermined)
+	assert.Contains(t, findings[0].Description, "Cannot determine")
+}
+
 func TestPublicAccess_Public(t *testing.T) {
 	resources := loadResources(t, "../../../testdata/rds/bad.tf")
 	db := findDB(t, resources, "insecure")
@@ -246,7 +262,7 @@ func TestCrossEventSubscription_NoSubscription(t *testing.T) {
 	resources := []model.TerraformResource{
 		makeRDSRes("aws_db_instance", "primary", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "RDS-016", findings[0].RuleID)
 }
@@ -260,7 +276,7 @@ func TestCrossEventSubscription_WithFailureSubscription(t *testing.T) {
 			"sns_topic_arn":    "arn:aws:sns:us-east-1:123456789012:db-alerts",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -272,12 +288,35 @@ func TestCrossEventSubscription_SubscriptionAllEvents(t *testing.T) {
 			"sns_topic_arn": "arn:aws:sns:us-east-1:123456789012:db-alerts",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
 func TestCrossEventSubscription_NoRDSResources(t *testing.T) {
 	r := &CrossEventSubscriptionRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
+	assert.Empty(t, findings)
+}
+
+func TestBackupRetentionMin(t *testing.T) {
+	db := model.TerraformResource{
+		Type:       "aws_db_instance",
+		Name:       "db",
+		Attributes: map[string]interface{}{"backup_retention_period": float64(7)},
+	}
+	rule := &BackupRetentionMin{}
+
+	findings, err := engine.CheckResource(rule, db, nil)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	findings, err = engine.CheckResource(rule, db, map[string]string{"min_retention_days": "14"})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "RDS instance backup retention period is 7 days, should be at least 14.", findings[0].Description)
+	assert.Equal(t, "Set backup_retention_period to at least 14 days.", findings[0].Remediation)
+
+	findings, err = engine.CheckResource(rule, model.TerraformResource{Type: "aws_db_instance", Name: "unset"}, nil)
+	require.NoError(t, err)
 	assert.Empty(t, findings)
 }
@@ -0,0 +1,34 @@
+package s3
+
+import "github.com/ilijad1/well-architected-terraform/internal/model"
+
+// bucketCompanions records which aws_s3_bucket resources have a companion
+// resource of one type, such as aws_s3_bucket_versioning. A companion
+// configures a bucket when its bucket attribute references the bucket, or
+// holds the bucket's name or ID.
+type bucketCompanions struct {
+	configured map[string]bool
+
+	// unresolved is set when a companion's bucket is only known after apply
+	// and references no bucket in the plan, so it may configure any bucket.
+	unresolved bool
+}
+
+func newBucketCompanions(resources *model.ResourceSet, companionTypes ...string) bucketCompanions {
+	c := bucketCompanions{configured: make(map[string]bool)}
+	for _, res := range resources.OfType(companionTypes...) {
+		buckets := resources.Referenced(res, "bucket", "aws_s3_bucket")
+		if res.IsUnknown("bucket") && len(buckets) == 0 {
+			c.unresolved = true
+		}
+		for _, bucket := range buckets {
+			c.configured[bucket.Address()] = true
+		}
+	}
+	return c
+}
+
+// configures reports whether a companion configures the bucket.
+func (c bucketCompanions) configures(bucket model.TerraformResource) bool {
+	return c.configured[bucket.Address()]
+}
@@ -27,28 +27,13 @@ func (r *CrossEncryptionConfigRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossEncryptionConfigRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	encryptedBuckets := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_s3_bucket_server_side_encryption_configuration" {
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok && bucket != "" {
-				encryptedBuckets[bucket] = true
-			}
-			encryptedBuckets[res.Name] = true
-		}
-	}
+func (r *CrossEncryptionConfigRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	encrypted := newBucketCompanions(resources, "aws_s3_bucket_server_side_encryption_configuration")
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_s3_bucket" {
-			continue
-		}
-
-		bucketName, _ := res.GetStringAttr("bucket")
-
-		if !encryptedBuckets[bucketName] && !encryptedBuckets[res.Address()] && !encryptedBuckets[res.Name] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if !encrypted.configures(res) {
+			finding := model.Finding{
 				RuleID:      "S3-012",
 				RuleName:    "S3 Bucket Missing Server-Side Encryption Configuration",
 				Severity:    model.SeverityHigh,
@@ -58,7 +43,11 @@ func (r *CrossEncryptionConfigRule) EvaluateAll(resources []model.TerraformResou
 				Line:        res.Line,
 				Description: "This S3 bucket has no aws_s3_bucket_server_side_encryption_configuration resource. Data at rest may be unencrypted.",
 				Remediation: "Add an aws_s3_bucket_server_side_encryption_configuration resource with an AES256 or aws:kms rule.",
-			})
+			}
+			if encrypted.unresolved {
+				finding = finding.AsUndetermined("the bucket of an aws_s3_bucket_server_side_encryption_configuration resource is only known after apply, so it may refer to this bucket.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -24,28 +24,13 @@ func (r *CrossLoggingRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossLoggingRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	loggedBuckets := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_s3_bucket_logging" {
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok && bucket != "" {
-				loggedBuckets[bucket] = true
-			}
-			loggedBuckets[res.Name] = true
-		}
-	}
+func (r *CrossLoggingRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	logged := newBucketCompanions(resources, "aws_s3_bucket_logging")
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_s3_bucket" {
-			continue
-		}
-
-		bucketName, _ := res.GetStringAttr("bucket")
-
-		if !loggedBuckets[bucketName] && !loggedBuckets[res.Address()] && !loggedBuckets[res.Name] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if !logged.configures(res) {
+			finding := model.Finding{
 				RuleID:      "S3-011",
 				RuleName:    "S3 Bucket Missing Access Logging",
 				Severity:    model.SeverityMedium,
@@ -55,7 +40,11 @@ func (r *CrossLoggingRule) EvaluateAll(resources []model.TerraformResource) []mo
 				Line:        res.Line,
 				Description: "This S3 bucket has no aws_s3_bucket_logging resource. Access logging enables auditing of requests and helps detect unauthorized access.",
 				Remediation: "Add an aws_s3_bucket_logging resource that references this bucket via the bucket attribute.",
-			})
+			}
+			if logged.unresolved {
+				finding = finding.AsUndetermined("the bucket of an aws_s3_bucket_logging resource is only known after apply, so it may refer to this bucket.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
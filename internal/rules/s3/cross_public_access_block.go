@@ -27,29 +27,13 @@ func (r *CrossPublicAccessBlockRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossPublicAccessBlockRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Index which bucket names/IDs have a public access block
-	blockedBuckets := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_s3_bucket_public_access_block" {
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok {
-				blockedBuckets[bucket] = true
-			}
-		}
-	}
+func (r *CrossPublicAccessBlockRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	blocked := newBucketCompanions(resources, "aws_s3_bucket_public_access_block")
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_s3_bucket" {
-			continue
-		}
-
-		bucketName, _ := res.GetStringAttr("bucket")
-		bucketID, _ := res.GetStringAttr("id")
-
-		if !blockedBuckets[bucketName] && !blockedBuckets[bucketID] && !blockedBuckets[res.Address()] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if !blocked.configures(res) {
+			finding := model.Finding{
 				RuleID:      "S3-009",
 				RuleName:    "S3 Bucket Missing Public Access Block",
 				Severity:    model.SeverityCritical,
@@ -59,7 +43,11 @@ func (r *CrossPublicAccessBlockRule) EvaluateAll(resources []model.TerraformReso
 				Line:        res.Line,
 				Description: "This S3 bucket has no aws_s3_bucket_public_access_block resource, leaving it vulnerable to accidental public access.",
 				Remediation: "Add an aws_s3_bucket_public_access_block resource with block_public_acls, block_public_policy, ignore_public_acls, and restrict_public_buckets all set to true.",
-			})
+			}
+			if blocked.unresolved {
+				finding = finding.AsUndetermined("the bucket of an aws_s3_bucket_public_access_block resource is only known after apply, so it may refer to this bucket.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 )
@@ -26,7 +27,7 @@ func TestCrossPublicAccessBlock_Missing(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "S3-009", findings[0].RuleID)
 	assert.Equal(t, model.SeverityCritical, findings[0].Severity)
@@ -46,7 +47,7 @@ func TestCrossPublicAccessBlock_Present(t *testing.T) {
 			"restrict_public_buckets": true,
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -57,11 +58,28 @@ func TestCrossPublicAccessBlock_MultipleBuckets(t *testing.T) {
 		newRes("aws_s3_bucket", "b", map[string]interface{}{"bucket": "bucket-b"}),
 		newRes("aws_s3_bucket_public_access_block", "a_pab", map[string]interface{}{"bucket": "bucket-a"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "b")
 }
 
+func TestCrossPublicAccessBlock_ReferencedBucket(t *testing.T) {
+	r := &CrossPublicAccessBlockRule{}
+	// The block's bucket is only known after apply, but it references exactly one bucket
+	pab := newRes("aws_s3_bucket_public_access_block", "data", map[string]interface{}{})
+	pab.Unknown = map[string]bool{"bucket": true}
+	pab.References = map[string][]string{"bucket": {"aws_s3_bucket.data"}}
+	resources := []model.TerraformResource{
+		newRes("aws_s3_bucket", "data", map[string]interface{}{}),
+		newRes("aws_s3_bucket", "logs", map[string]interface{}{}),
+		pab,
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	require.Len(t, findings, 1)
+	assert.Equal(t, "aws_s3_bucket.logs", findings[0].Resource)
+	assert.False(t, findings[0].Undetermined)
+}
+
 // --- S3-010: Cross Versioning ---
 
 func TestCrossVersioning_Missing(t *testing.T) {
@@ -71,7 +89,7 @@ func TestCrossVersioning_Missing(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "S3-010", findings[0].RuleID)
 }
@@ -86,7 +104,7 @@ func TestCrossVersioning_Present(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -99,7 +117,7 @@ func TestCrossLogging_Missing(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "S3-011", findings[0].RuleID)
 }
@@ -114,7 +132,7 @@ func TestCrossLogging_Present(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -125,14 +143,14 @@ func TestCrossLogging_MultipleBuckets_OneUncovered(t *testing.T) {
 		newRes("aws_s3_bucket", "b", map[string]interface{}{"bucket": "bucket-b"}),
 		newRes("aws_s3_bucket_logging", "a_log", map[string]interface{}{"bucket": "bucket-a"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "b")
 }
 
 func TestCrossLogging_NoBuckets(t *testing.T) {
 	r := &CrossLoggingRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
 
@@ -145,7 +163,7 @@ func TestCrossEncryptionConfig_Missing(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "S3-012", findings[0].RuleID)
 	assert.Equal(t, model.SeverityHigh, findings[0].Severity)
@@ -161,7 +179,7 @@ func TestCrossEncryptionConfig_Present(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -172,13 +190,37 @@ func TestCrossEncryptionConfig_MultipleBuckets_OneUncovered(t *testing.T) {
 		newRes("aws_s3_bucket", "b", map[string]interface{}{"bucket": "bucket-b"}),
 		newRes("aws_s3_bucket_server_side_encryption_configuration", "a_enc", map[string]interface{}{"bucket": "bucket-a"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "b")
 }
 
+func TestCrossEncryptionConfig_BucketKnownAfterApply(t *testing.T) {
+	r := &CrossEncryptionConfigRule{}
+	enc := newRes("aws_s3_bucket_server_side_encryption_configuration", "logs_enc", map[string]interface{}{})
+	enc.Unknown = map[string]bool{"bucket": true}
+	resources := []model.TerraformResource{
+		newRes("aws_s3_bucket", "logs", map[string]interface{}{"bucket": "my-logs-bucket"}),
+		enc,
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	assert.Len(t, findings, 1)
+	assert.True(t, findings[0].Undetermined)
+}
+
+func TestCrossEncryptionConfig_CompanionNameIsNotABucket(t *testing.T) {
+	r := &CrossEncryptionConfigRule{}
+	// A companion named like a bucket does not configure it
+	resources := []model.TerraformResource{
+		newRes("aws_s3_bucket", "logs", map[string]interface{}{"bucket": "my-logs-bucket"}),
+		newRes("aws_s3_bucket_server_side_encryption_configuration", "logs", map[string]interface{}{"bucket": "other-bucket"}),
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	assert.Len(t, findings, 1)
+}
+
 func TestCrossEncryptionConfig_NoBuckets(t *testing.T) {
 	r := &CrossEncryptionConfigRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
@@ -24,28 +24,13 @@ func (r *CrossVersioningRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossVersioningRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	versionedBuckets := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_s3_bucket_versioning" {
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok {
-				versionedBuckets[bucket] = true
-			}
-		}
-	}
+func (r *CrossVersioningRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	versioned := newBucketCompanions(resources, "aws_s3_bucket_versioning")
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_s3_bucket" {
-			continue
-		}
-
-		bucketName, _ := res.GetStringAttr("bucket")
-		bucketID, _ := res.GetStringAttr("id")
-
-		if !versionedBuckets[bucketName] && !versionedBuckets[bucketID] && !versionedBuckets[res.Address()] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if !versioned.configures(res) {
+			finding := model.Finding{
 				RuleID:      "S3-010",
 				RuleName:    "S3 Bucket Missing Versioning Configuration",
 				Severity:    model.SeverityMedium,
@@ -55,7 +40,11 @@ func (r *CrossVersioningRule) EvaluateAll(resources []model.TerraformResource) [
 				Line:        res.Line,
 				Description: "This S3 bucket has no aws_s3_bucket_versioning resource. Versioning protects against accidental deletion and enables object recovery.",
 				Remediation: "Add an aws_s3_bucket_versioning resource with versioning_configuration { status = \"Enabled\" }.",
-			})
+			}
+			if versioned.unresolved {
+				finding = finding.AsUndetermined("the bucket of an aws_s3_bucket_versioning resource is only known after apply, so it may refer to this bucket.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -24,29 +24,26 @@ func (r *CrossRotationRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossRotationRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Index rotation resources by their secret_id attribute (or resource name)
+func (r *CrossRotationRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the secrets that rotation resources reference or name
 	rotatedSecrets := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_secretsmanager_secret_rotation" {
-			secretID, ok := res.GetStringAttr("secret_id")
-			if ok && secretID != "" {
-				rotatedSecrets[secretID] = true
-			}
-			rotatedSecrets[res.Name] = true
+	// A companion whose secret_id is only known after apply, and does not
+	// reference a secret in the plan, may belong to any secret.
+	unresolved := false
+	for _, res := range resources.OfType("aws_secretsmanager_secret_rotation") {
+		secrets := resources.Referenced(res, "secret_id", "aws_secretsmanager_secret")
+		if res.IsUnknown("secret_id") && len(secrets) == 0 {
+			unresolved = true
+		}
+		for _, secret := range secrets {
+			rotatedSecrets[secret.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_secretsmanager_secret" {
-			continue
-		}
-
-		secretName, _ := res.GetStringAttr("name")
-
-		if !rotatedSecrets[secretName] && !rotatedSecrets[res.Address()] && !rotatedSecrets[res.Name] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_secretsmanager_secret") {
+		if !rotatedSecrets[res.Address()] {
+			finding := model.Finding{
 				RuleID:      "SEC-004",
 				RuleName:    "Secrets Manager Secret Missing Rotation",
 				Severity:    model.SeverityHigh,
@@ -56,7 +53,11 @@ func (r *CrossRotationRule) EvaluateAll(resources []model.TerraformResource) []m
 				Line:        res.Line,
 				Description: "This Secrets Manager secret has no aws_secretsmanager_secret_rotation resource. Without automatic rotation, long-lived credentials increase the risk of compromise.",
 				Remediation: "Add an aws_secretsmanager_secret_rotation resource referencing this secret via secret_id and configure a rotation Lambda function.",
-			})
+			}
+			if unresolved {
+				finding = finding.AsUndetermined("the secret_id of an aws_secretsmanager_secret_rotation resource is only known after apply, so it may refer to this secret.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -92,7 +92,7 @@ func TestCrossRotation_Missing(t *testing.T) {
 			"name": "db-password",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "SEC-004", findings[0].RuleID)
 	assert.Equal(t, model.SeverityHigh, findings[0].Severity)
@@ -108,7 +108,7 @@ func TestCrossRotation_Present(t *testing.T) {
 			"secret_id": "db-password",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -119,13 +119,13 @@ func TestCrossRotation_MultipleSecrets_OneUncovered(t *testing.T) {
 		makeSecRes("aws_secretsmanager_secret", "s2", map[string]interface{}{"name": "secret-2"}),
 		makeSecRes("aws_secretsmanager_secret_rotation", "r1", map[string]interface{}{"secret_id": "secret-1"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "s2")
 }
 
 func TestCrossRotation_NoSecrets(t *testing.T) {
 	r := &CrossRotationRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
@@ -33,7 +33,7 @@ func (r *SubscriptionDLQRule) Evaluate(resource model.TerraformResource) []model
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityMedium,
 			Pillar:      model.PillarReliability,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "SNS topic subscription does not have a redrive policy configured",
@@ -28,24 +28,11 @@ func (r *CrossDLQRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossDLQRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect all SQS queue names and ARNs in the plan
-	queueIdentifiers := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type != "aws_sqs_queue" {
-			continue
-		}
-		name, ok := res.GetStringAttr("name")
-		if ok && name != "" {
-			queueIdentifiers[name] = true
-		}
-		queueIdentifiers[res.Name] = true
-		queueIdentifiers[res.Address()] = true
-	}
-
+func (r *CrossDLQRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_sqs_queue" {
+	for _, res := range resources.OfType("aws_sqs_queue") {
+		// A redrive_policy built from another queue's attributes names its DLQ exactly
+		if referencesQueue(resources, res) {
 			continue
 		}
 
@@ -60,29 +47,14 @@ func (r *CrossDLQRule) EvaluateAll(resources []model.TerraformResource) []model.
 			continue
 		}
 
-		// Check if any known queue identifier appears in the redrive policy
+		// The DLQ is in the plan if its ARN, or the queue name the ARN ends
+		// with, belongs to one of the plan's queues
 		found := false
-
-		// Try to parse as JSON to extract deadLetterTargetArn
 		var redriveMap map[string]interface{}
 		if err := json.Unmarshal([]byte(redriveStr), &redriveMap); err == nil {
 			if dlqArn, ok := redriveMap["deadLetterTargetArn"].(string); ok {
-				for id := range queueIdentifiers {
-					if id != "" && strings.Contains(dlqArn, id) {
-						found = true
-						break
-					}
-				}
-			}
-		}
-
-		// Fallback: check raw string for any queue identifier
-		if !found {
-			for id := range queueIdentifiers {
-				if id != "" && strings.Contains(redriveStr, id) {
-					found = true
-					break
-				}
+				dlqName := dlqArn[strings.LastIndex(dlqArn, ":")+1:]
+				found = len(resources.Lookup("aws_sqs_queue", dlqArn)) > 0 || len(resources.Lookup("aws_sqs_queue", dlqName)) > 0
 			}
 		}
 
@@ -103,3 +75,13 @@ func (r *CrossDLQRule) EvaluateAll(resources []model.TerraformResource) []model.
 
 	return findings
 }
+
+// referencesQueue reports whether a queue's redrive_policy refers to another queue in the set.
+func referencesQueue(resources *model.ResourceSet, queue model.TerraformResource) bool {
+	for _, target := range resources.Targets(queue, "redrive_policy") {
+		if target.Type == "aws_sqs_queue" && target.Address() != queue.Address() {
+			return true
+		}
+	}
+	return false
+}
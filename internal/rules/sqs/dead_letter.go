@@ -33,7 +33,7 @@ func (r *DeadLetterRule) Evaluate(resource model.TerraformResource) []model.Find
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityMedium,
 			Pillar:      model.PillarReliability,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "SQS queue does not have a redrive policy configured",
@@ -41,7 +41,7 @@ func (r *EncryptionRule) Evaluate(resource model.TerraformResource) []model.Find
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityHigh,
 			Pillar:      model.PillarSecurity,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "SQS queue does not have encryption enabled",
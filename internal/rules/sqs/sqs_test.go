@@ -199,7 +199,7 @@ func TestCrossDLQ_QueueWithNoDLQInPlan(t *testing.T) {
 			"redrive_policy": `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:external-dlq","maxReceiveCount":5}`,
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "SQS-005", findings[0].RuleID)
 }
@@ -215,7 +215,34 @@ func TestCrossDLQ_QueueWithDLQInPlan(t *testing.T) {
 			"name": "my-dlq",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	assert.Empty(t, findings)
+}
+
+func TestCrossDLQ_QueueNameIsNotADLQ(t *testing.T) {
+	r := &CrossDLQRule{}
+	// "orders" appears in the DLQ's ARN but is not the DLQ
+	resources := []model.TerraformResource{
+		makeSQSRes("aws_sqs_queue", "orders", map[string]interface{}{
+			"name":           "orders",
+			"redrive_policy": `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":5}`,
+		}),
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
+	assert.Len(t, findings, 1)
+}
+
+func TestCrossDLQ_ReferencedDLQ(t *testing.T) {
+	r := &CrossDLQRule{}
+	// redrive_policy = jsonencode({ deadLetterTargetArn = aws_sqs_queue.dlq.arn })
+	main := makeSQSRes("aws_sqs_queue", "main", map[string]interface{}{"name": "main-queue"})
+	main.Unknown = map[string]bool{"redrive_policy": true}
+	main.References = map[string][]string{"redrive_policy": {"aws_sqs_queue.dlq"}}
+	resources := []model.TerraformResource{
+		main,
+		makeSQSRes("aws_sqs_queue", "dlq", map[string]interface{}{}),
+	}
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -226,12 +253,12 @@ func TestCrossDLQ_QueueWithNoRedrivePolicy(t *testing.T) {
 			"name": "main-queue",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
 func TestCrossDLQ_NoQueues(t *testing.T) {
 	r := &CrossDLQRule{}
-	findings := r.EvaluateAll(nil)
+	findings := r.EvaluateAll(model.NewResourceSet(nil))
 	assert.Empty(t, findings)
 }
@@ -34,7 +34,7 @@ func (r *TagsRule) Evaluate(resource model.TerraformResource) []model.Finding {
 			RuleName:    r.Metadata().Name,
 			Severity:    model.SeverityLow,
 			Pillar:      model.PillarCostOptimization,
-			Resource:    resource.FullAddress,
+			Resource:    resource.Address(),
 			File:        resource.File,
 			Line:        resource.Line,
 			Description: "SQS queue does not have tags configured",
@@ -52,7 +52,7 @@ func (r *TagsRule) Evaluate(resource model.TerraformResource) []model.Finding {
 				RuleName:    r.Metadata().Name,
 				Severity:    model.SeverityLow,
 				Pillar:      model.PillarCostOptimization,
-				Resource:    resource.FullAddress,
+				Resource:    resource.Address(),
 				File:        resource.File,
 				Line:        resource.Line,
 				Description: "SQS queue has an empty tags map",
@@ -23,34 +23,18 @@ func (r *S3IntelligentTieringRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *S3IntelligentTieringRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect bucket names/IDs that have tiering or lifecycle configs
+func (r *S3IntelligentTieringRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the buckets that tiering or lifecycle configurations reference or name
 	optimized := make(map[string]bool)
-	for _, res := range resources {
-		switch res.Type {
-		case "aws_s3_bucket_intelligent_tiering_configuration":
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok {
-				optimized[bucket] = true
-			}
-		case "aws_s3_bucket_lifecycle_configuration":
-			bucket, ok := res.GetStringAttr("bucket")
-			if ok {
-				optimized[bucket] = true
-			}
+	for _, res := range resources.OfType("aws_s3_bucket_intelligent_tiering_configuration", "aws_s3_bucket_lifecycle_configuration") {
+		for _, bucket := range resources.Referenced(res, "bucket", "aws_s3_bucket") {
+			optimized[bucket.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_s3_bucket" {
-			continue
-		}
-
-		bucketName, _ := res.GetStringAttr("bucket")
-		bucketID, _ := res.GetStringAttr("id")
-
-		if !optimized[bucketName] && !optimized[bucketID] && !optimized[res.Address()] {
+	for _, res := range resources.OfType("aws_s3_bucket") {
+		if !optimized[res.Address()] {
 			findings = append(findings, model.Finding{
 				RuleID:      "SUS-004",
 				RuleName:    "S3 Bucket Missing Intelligent Tiering or Lifecycle Rules",
@@ -114,7 +114,7 @@ func TestS3IntelligentTiering_NoCoverage(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "SUS-004", findings[0].RuleID)
 }
@@ -129,7 +129,7 @@ func TestS3IntelligentTiering_WithTiering(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -143,7 +143,7 @@ func TestS3IntelligentTiering_WithLifecycle(t *testing.T) {
 			"bucket": "my-data-bucket",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
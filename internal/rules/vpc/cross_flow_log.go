@@ -27,28 +27,26 @@ func (r *CrossFlowLogRule) Metadata() model.RuleMetadata {
 	}
 }
 
-func (r *CrossFlowLogRule) EvaluateAll(resources []model.TerraformResource) []model.Finding {
-	// Collect VPC IDs/addresses that have flow logs
+func (r *CrossFlowLogRule) EvaluateAll(resources *model.ResourceSet) []model.Finding {
+	// Collect the VPCs that flow logs reference or name by ID
 	vpcsWithFlowLogs := make(map[string]bool)
-	for _, res := range resources {
-		if res.Type == "aws_flow_log" {
-			vpcID, ok := res.GetStringAttr("vpc_id")
-			if ok {
-				vpcsWithFlowLogs[vpcID] = true
-			}
+	// A companion whose vpc_id is only known after apply, and does not
+	// reference a VPC in the plan, may belong to any VPC.
+	unresolved := false
+	for _, res := range resources.OfType("aws_flow_log") {
+		vpcs := resources.Referenced(res, "vpc_id", "aws_vpc")
+		if res.IsUnknown("vpc_id") && len(vpcs) == 0 {
+			unresolved = true
+		}
+		for _, vpc := range vpcs {
+			vpcsWithFlowLogs[vpc.Address()] = true
 		}
 	}
 
 	var findings []model.Finding
-	for _, res := range resources {
-		if res.Type != "aws_vpc" {
-			continue
-		}
-
-		vpcID, _ := res.GetStringAttr("id")
-
-		if !vpcsWithFlowLogs[vpcID] && !vpcsWithFlowLogs[res.Address()] {
-			findings = append(findings, model.Finding{
+	for _, res := range resources.OfType("aws_vpc") {
+		if !vpcsWithFlowLogs[res.Address()] {
+			finding := model.Finding{
 				RuleID:      "VPC-007",
 				RuleName:    "VPC Missing Flow Logs",
 				Severity:    model.SeverityMedium,
@@ -58,7 +56,11 @@ func (r *CrossFlowLogRule) EvaluateAll(resources []model.TerraformResource) []mo
 				Line:        res.Line,
 				Description: "This VPC has no aws_flow_log resource associated with it. VPC flow logs are essential for network traffic analysis, security monitoring, and incident investigation.",
 				Remediation: "Add an aws_flow_log resource with vpc_id pointing to this VPC, traffic_type set to ALL, and a log destination (CloudWatch Logs or S3).",
-			})
+			}
+			if unresolved {
+				finding = finding.AsUndetermined("the vpc_id of an aws_flow_log resource is only known after apply, so it may refer to this VPC.")
+			}
+			findings = append(findings, finding)
 		}
 	}
 
@@ -24,7 +24,7 @@ func TestCrossFlowLog_Missing(t *testing.T) {
 	resources := []model.TerraformResource{
 		newRes("aws_vpc", "main", map[string]interface{}{}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "VPC-007", findings[0].RuleID)
 }
@@ -39,7 +39,7 @@ func TestCrossFlowLog_Present(t *testing.T) {
 			"vpc_id": "vpc-abc123",
 		}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Empty(t, findings)
 }
 
@@ -50,7 +50,35 @@ func TestCrossFlowLog_MultipleVPCs(t *testing.T) {
 		newRes("aws_vpc", "dev", map[string]interface{}{"id": "vpc-dev"}),
 		newRes("aws_flow_log", "prod_flow", map[string]interface{}{"vpc_id": "vpc-prod"}),
 	}
-	findings := r.EvaluateAll(resources)
+	findings := r.EvaluateAll(model.NewResourceSet(resources))
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Resource, "dev")
 }
+
+func TestCrossFlowLog_ReferencedVPC(t *testing.T) {
+	r := &CrossFlowLogRule{}
+	// Plan-time VPC IDs are unknown; the flow log references one instance
+	flowLog := newRes("aws_flow_log", "main", map[string]interface{}{})
+	flowLog.Unknown = map[string]bool{"vpc_id": true}
+	flowLog.References = map[string][]string{"vpc_id": {"aws_vpc.main[0]"}}
+	first := newRes("aws_vpc", "main", map[string]interface{}{})
+	first.FullAddress = "aws_vpc.main[0]"
+	second := newRes("aws_vpc", "main", map[string]interface{}{})
+	second.FullAddress = "aws_vpc.main[1]"
+
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{first, second, flowLog}))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "aws_vpc.main[1]", findings[0].Resource)
+	assert.False(t, findings[0].Undetermined)
+}
+
+func TestCrossFlowLog_UnresolvedVPC(t *testing.T) {
+	r := &CrossFlowLogRule{}
+	// vpc_id comes from a module output, so it may be any VPC
+	flowLog := newRes("aws_flow_log", "main", map[string]interface{}{})
+	flowLog.Unknown = map[string]bool{"vpc_id": true}
+
+	findings := r.EvaluateAll(model.NewResourceSet([]model.TerraformResource{newRes("aws_vpc", "main", map[string]interface{}{}), flowLog}))
+	assert.Len(t, findings, 1)
+	assert.True(t, findings[0].Undetermined)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	engine.Register(&OpenIngress{})
+	engine.RegisterResourceEval(&OpenIngress{})
 }
 
 // OpenIngress checks that security groups don't allow unrestricted access on sensitive ports.
@@ -23,11 +23,17 @@ func (r *OpenIngress) Metadata() model.RuleMetadata {
 		Pillar:        model.PillarSecurity,
 		ResourceTypes: []string{"aws_security_group", "aws_security_group_rule"},
 		DocURL:        "https://docs.aws.amazon.com/vpc/latest/userguide/security-group-rules.html",
+		Parameters: []model.RuleParameter{{
+			Name:        "ports",
+			Type:        model.ParamIntList,
+			Default:     "22,3389,3306,5432,1433,6379,27017",
+			Description: "Ports that must not be open to the internet",
+		}},
 	}
 }
 
-// sensitivePorts maps port numbers to their service names.
-var sensitivePorts = map[int]string{
+// serviceNames maps well-known sensitive ports to their service names.
+var serviceNames = map[int]string{
 	22:    "SSH",
 	3389:  "RDP",
 	3306:  "MySQL",
@@ -35,19 +41,30 @@ var sensitivePorts = map[int]string{
 	1433:  "MSSQL",
 	6379:  "Redis",
 	27017: "MongoDB",
+	9200:  "Elasticsearch",
+	5601:  "Kibana",
+	11211: "Memcached",
 }
 
-func (r *OpenIngress) Evaluate(resource model.TerraformResource) []model.Finding {
-	if resource.Type == "aws_security_group" {
-		return r.evaluateSecurityGroup(resource)
+// portName returns the port with its service name, if it is well known.
+func portName(port int) string {
+	if service, ok := serviceNames[port]; ok {
+		return fmt.Sprintf("%d (%s)", port, service)
 	}
-	return r.evaluateSecurityGroupRule(resource)
+	return fmt.Sprintf("%d", port)
 }
 
-func (r *OpenIngress) evaluateSecurityGroup(resource model.TerraformResource) []model.Finding {
-	var findings []model.Finding
+func (r *OpenIngress) Check(ctx *model.EvalContext) {
+	ports := ctx.IntListParam("ports")
+	if ctx.Resource.Type == "aws_security_group" {
+		r.checkSecurityGroup(ctx, ports)
+		return
+	}
+	r.checkSecurityGroupRule(ctx, ports)
+}
 
-	for _, ingress := range resource.GetBlocks("ingress") {
+func (r *OpenIngress) checkSecurityGroup(ctx *model.EvalContext, ports []int) {
+	for _, ingress := range ctx.Resource.GetBlocks("ingress") {
 		if !hasOpenCIDR(ingress) {
 			continue
 		}
@@ -55,59 +72,38 @@ func (r *OpenIngress) evaluateSecurityGroup(resource model.TerraformResource) []
 		fromPort := getPort(ingress.Attributes["from_port"])
 		toPort := getPort(ingress.Attributes["to_port"])
 
-		for port, service := range sensitivePorts {
+		for _, port := range ports {
 			if portInRange(port, fromPort, toPort) {
-				findings = append(findings, model.Finding{
-					RuleID:      "VPC-001",
-					RuleName:    r.Metadata().Name,
-					Severity:    model.SeverityCritical,
-					Pillar:      model.PillarSecurity,
-					Resource:    resource.Address(),
-					File:        resource.File,
-					Line:        resource.Line,
-					Description: fmt.Sprintf("Security group allows unrestricted ingress (0.0.0.0/0 or ::/0) on port %d (%s).", port, service),
-					Remediation: fmt.Sprintf("Restrict ingress on port %d to specific CIDR blocks or security groups instead of 0.0.0.0/0.", port),
-					DocURL:      r.Metadata().DocURL,
-				})
+				ctx.Report(ctx.Finding(ctx.Resource,
+					fmt.Sprintf("Security group allows unrestricted ingress (0.0.0.0/0 or ::/0) on port %s.", portName(port)),
+					fmt.Sprintf("Restrict ingress on port %d to specific CIDR blocks or security groups instead of 0.0.0.0/0.", port),
+				))
 			}
 		}
 	}
-
-	return findings
 }
 
-func (r *OpenIngress) evaluateSecurityGroupRule(resource model.TerraformResource) []model.Finding {
-	ruleType, _ := resource.GetStringAttr("type")
+func (r *OpenIngress) checkSecurityGroupRule(ctx *model.EvalContext, ports []int) {
+	ruleType, _ := ctx.Resource.GetStringAttr("type")
 	if ruleType != "ingress" {
-		return nil
+		return
 	}
 
-	if !hasOpenCIDRFromAttrs(resource.Attributes) {
-		return nil
+	if !hasOpenCIDRFromAttrs(ctx.Resource.Attributes) {
+		return
 	}
 
-	fromPort := getPort(resource.Attributes["from_port"])
-	toPort := getPort(resource.Attributes["to_port"])
+	fromPort := getPort(ctx.Resource.Attributes["from_port"])
+	toPort := getPort(ctx.Resource.Attributes["to_port"])
 
-	var findings []model.Finding
-	for port, service := range sensitivePorts {
+	for _, port := range ports {
 		if portInRange(port, fromPort, toPort) {
-			findings = append(findings, model.Finding{
-				RuleID:      "VPC-001",
-				RuleName:    r.Metadata().Name,
-				Severity:    model.SeverityCritical,
-				Pillar:      model.PillarSecurity,
-				Resource:    resource.Address(),
-				File:        resource.File,
-				Line:        resource.Line,
-				Description: fmt.Sprintf("Security group rule allows unrestricted ingress (0.0.0.0/0 or ::/0) on port %d (%s).", port, service),
-				Remediation: fmt.Sprintf("Restrict ingress on port %d to specific CIDR blocks or security groups.", port),
-				DocURL:      r.Metadata().DocURL,
-			})
+			ctx.Report(ctx.Finding(ctx.Resource,
+				fmt.Sprintf("Security group rule allows unrestricted ingress (0.0.0.0/0 or ::/0) on port %s.", portName(port)),
+				fmt.Sprintf("Restrict ingress on port %d to specific CIDR blocks or security groups.", port),
+			))
 		}
 	}
-
-	return findings
 }
 
 func hasOpenCIDR(block model.Block) bool {
@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/ilijad1/well-architected-terraform/internal/engine"
 	"github.com/ilijad1/well-architected-terraform/internal/model"
 	"github.com/ilijad1/well-architected-terraform/internal/parser"
 )
@@ -25,7 +27,8 @@ func TestOpenIngress_OpenSSH(t *testing.T) {
 		}
 	}
 
-	findings := rule.Evaluate(sshSG)
+	findings, err := engine.CheckResource(rule, sshSG, nil)
+	require.NoError(t, err)
 	// Should flag SSH (port 22) but NOT HTTPS (port 443)
 	assert.Len(t, findings, 1)
 	assert.Equal(t, "VPC-001", findings[0].RuleID)
@@ -49,7 +52,8 @@ func TestOpenIngress_OpenRDP(t *testing.T) {
 		}
 	}
 
-	findings := rule.Evaluate(rdpSG)
+	findings, err := engine.CheckResource(rule, rdpSG, nil)
+	require.NoError(t, err)
 	assert.Len(t, findings, 1)
 	assert.Contains(t, findings[0].Description, "3389")
 	assert.Contains(t, findings[0].Description, "RDP")
@@ -65,7 +69,8 @@ func TestOpenIngress_RestrictedSSH(t *testing.T) {
 	rule := &OpenIngress{}
 	for _, r := range resources {
 		if r.Type == "aws_security_group" {
-			findings := rule.Evaluate(r)
+			findings, err := engine.CheckResource(rule, r, nil)
+			require.NoError(t, err)
 			assert.Empty(t, findings)
 			return
 		}
@@ -73,6 +78,36 @@ func TestOpenIngress_RestrictedSSH(t *testing.T) {
 	t.Fatal("no security group found")
 }
 
+func TestOpenIngress_PortsParam(t *testing.T) {
+	sg := model.TerraformResource{
+		Type: "aws_security_group",
+		Name: "search",
+		Blocks: map[string][]model.Block{
+			"ingress": {{
+				Type: "ingress",
+				Attributes: map[string]interface{}{
+					"from_port":   float64(9200),
+					"to_port":     float64(9300),
+					"cidr_blocks": []interface{}{"0.0.0.0/0"},
+				},
+			}},
+		},
+	}
+	rule := &OpenIngress{}
+
+	findings, err := engine.CheckResource(rule, sg, nil)
+	require.NoError(t, err)
+	assert.Empty(t, findings, "9200 is not sensitive by default")
+
+	findings, err = engine.CheckResource(rule, sg, map[string]string{"ports": "22, 9200"})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Contains(t, findings[0].Description, "port 9200 (Elasticsearch)")
+
+	_, err = engine.CheckResource(rule, sg, map[string]string{"ports": "ssh"})
+	assert.ErrorContains(t, err, "parameter ports")
+}
+
 func loadResources(t *testing.T, file string) []model.TerraformResource {
 	t.Helper()
 	p := parser.New()
@@ -0,0 +1,69 @@
+variable "create_replica" {
+  type    = bool
+  default = false
+}
+
+variable "open_ports" {
+  type    = list(number)
+  default = [22, 443]
+}
+
+locals {
+  buckets = {
+    logs   = "acme-logs"
+    assets = "acme-assets"
+  }
+}
+
+resource "aws_instance" "web" {
+  count         = 2
+  ami           = "ami-12345678"
+  instance_type = "t3.micro"
+
+  tags = {
+    Name = "web-${count.index}"
+  }
+}
+
+resource "aws_db_instance" "replica" {
+  count             = var.create_replica ? 1 : 0
+  instance_class    = "db.t3.micro"
+  storage_encrypted = false
+}
+
+resource "aws_s3_bucket" "b" {
+  for_each = local.buckets
+  bucket   = each.value
+
+  tags = {
+    Purpose = each.key
+  }
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  dynamic "ingress" {
+    for_each = var.open_ports
+    content {
+      from_port   = ingress.value
+      to_port     = ingress.value
+      protocol    = "tcp"
+      cidr_blocks = ["0.0.0.0/0"]
+    }
+  }
+
+  dynamic "egress" {
+    for_each = aws_instance.web
+    iterator = target
+    content {
+      from_port = 0
+      to_port   = 0
+      protocol  = "-1"
+    }
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
@@ -0,0 +1,33 @@
+locals {
+  name = "core"
+}
+
+module "network" {
+  source = "./modules/network"
+  cidr   = "10.0.0.0/16"
+  name   = local.name
+}
+
+module "buckets" {
+  source   = "./modules/bucket"
+  for_each = toset(["assets", "logs"])
+  name     = each.key
+}
+
+module "registry_vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+  cidr    = "10.1.0.0/16"
+}
+
+module "unresolved" {
+  source = "git::https://example.com/modules/unknown.git"
+}
+
+resource "aws_flow_log" "network" {
+  vpc_id       = module.network.vpc_id
+  traffic_type = "ALL"
+  tags = {
+    Network = module.network.name
+  }
+}
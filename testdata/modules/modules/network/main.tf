@@ -0,0 +1,22 @@
+variable "cidr" {
+  type = string
+}
+
+variable "name" {
+  type = string
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = var.cidr
+  tags = {
+    Name = var.name
+  }
+}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+
+output "name" {
+  value = var.name
+}
@@ -0,0 +1,19 @@
+resource "aws_s3_bucket" "root_bucket" {
+  bucket = "my-root-bucket"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-12345678"
+  instance_type = "t3.micro"
+}
+
+data "aws_iam_policy_document" "example" {}
+
+module "vpc" {
+  source = "./modules/vpc"
+}
+
+module "eks" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "20.0.0"
+}
@@ -0,0 +1,4 @@
+resource "aws_security_group" "default" {
+  name        = "default-sg"
+  description = "Default security group"
+}
@@ -0,0 +1,43 @@
+variable "log_prefix" {
+  default = "logs/"
+}
+
+resource "aws_vpc" "main" {
+  count      = 2
+  cidr_block = "10.${count.index}.0.0/16"
+}
+
+resource "aws_flow_log" "main" {
+  vpc_id       = aws_vpc.main[0].id
+  traffic_type = "ALL"
+}
+
+resource "aws_subnet" "private" {
+  count      = 2
+  vpc_id     = aws_vpc.main[count.index].id
+  cidr_block = "10.${count.index}.1.0/24"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "access-logs"
+}
+
+resource "aws_s3_bucket" "data" {
+  bucket = "data"
+
+  logging {
+    target_bucket = aws_s3_bucket.logs.id
+    target_prefix = var.log_prefix
+  }
+}
+
+resource "aws_s3_bucket_policy" "data" {
+  bucket = aws_s3_bucket.data.id
+  policy = data.aws_iam_policy_document.data.json
+}
+
+data "aws_iam_policy_document" "data" {
+  statement {
+    resources = ["${aws_s3_bucket.data.arn}/*"]
+  }
+}
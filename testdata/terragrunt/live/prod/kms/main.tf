@@ -0,0 +1,8 @@
+resource "aws_kms_key" "this" {
+  description         = "Logs encryption key"
+  enable_key_rotation = true
+}
+
+output "key_arn" {
+  value = aws_kms_key.this.arn
+}
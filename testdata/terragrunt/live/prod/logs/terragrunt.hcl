@@ -0,0 +1,21 @@
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "../../../modules//bucket"
+}
+
+dependency "kms" {
+  config_path = "../kms"
+}
+
+locals {
+  name = "acme-${replace(path_relative_to_include(), "/", "-")}"
+}
+
+inputs = {
+  bucket_name = local.name
+  versioning  = true
+  kms_key_arn = dependency.kms.outputs.key_arn
+}
@@ -0,0 +1,11 @@
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "git::https://example.com/acme/modules.git//bucket?ref=v1.2.0"
+}
+
+inputs = {
+  bucket_name = "acme-remote"
+}
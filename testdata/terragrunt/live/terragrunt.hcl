@@ -0,0 +1,8 @@
+locals {
+  environment = "prod"
+}
+
+inputs = {
+  environment = local.environment
+  versioning  = false
+}
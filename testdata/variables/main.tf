@@ -0,0 +1,47 @@
+variable "encrypt" {
+  type    = bool
+  default = false
+}
+
+variable "environment" {
+  type = string
+}
+
+variable "retention_days" {
+  type    = number
+  default = 7
+}
+
+variable "kms_key_id" {
+  type = string
+}
+
+locals {
+  bucket_name = "${local.prefix}-data"
+  prefix      = "acme-${var.environment}"
+  common_tags = {
+    Environment = var.environment
+    Team        = "platform"
+  }
+}
+
+resource "aws_kms_key" "data" {
+  description = "data key"
+}
+
+resource "aws_s3_bucket" "data" {
+  bucket = local.bucket_name
+  tags   = merge(local.common_tags, { Name = upper(local.bucket_name) })
+}
+
+resource "aws_ebs_volume" "data" {
+  availability_zone = "us-east-1a"
+  encrypted         = var.encrypt
+  kms_key_id        = aws_kms_key.data.arn
+}
+
+resource "aws_cloudwatch_log_group" "app" {
+  name              = "/app/${var.environment}"
+  retention_in_days = var.retention_days
+  kms_key_id        = var.kms_key_id
+}